@@ -38,6 +38,10 @@ var (
 	// Storage section errors
 	ErrPermissionsUnset            = errors.New("permissions unset, defaulting to 0000")
 	ErrDiskDeviceRequired          = errors.New("disk device is required")
+	ErrDiskDeviceAndSelector       = errors.New("disk device and selector are mutually exclusive")
+	ErrDiskSelectorEmpty           = errors.New("disk selector has no criteria")
+	ErrDiskSelectorSizeInvalid     = errors.New("disk selector sizes must be positive and minSizeMiB must not exceed maxSizeMiB")
+	ErrDiskSelectorBadPattern      = errors.New("disk selector pattern is malformed")
 	ErrPartitionNumbersCollide     = errors.New("partition numbers collide")
 	ErrPartitionsOverlap           = errors.New("partitions overlap")
 	ErrPartitionsMisaligned        = errors.New("partitions misaligned")
@@ -67,6 +71,30 @@ var (
 	ErrPartitionsUnitsMismatch     = errors.New("cannot mix MBs and sectors within a disk")
 	ErrSizeDeprecated              = errors.New("size is deprecated; use sizeMB instead")
 	ErrStartDeprecated             = errors.New("start is deprecated; use startMB instead")
+	ErrResizeWithoutNumber         = errors.New("resize requires a partition number")
+	ErrInvalidPartitionTable       = errors.New("partitionTable must be gpt or dos")
+	ErrDosTypeInvalid              = errors.New("dosType must be a hexadecimal partition type between 1 and ff")
+	ErrDosTypeOnGpt                = errors.New("dosType is only supported on dos partition tables")
+	ErrGptFieldsOnDos              = errors.New("label, guid and typeGuid are not supported on dos partition tables")
+	ErrDosPartitionNumber          = errors.New("partitions on dos partition tables need a number: 1 to 4 for primary and 5 or higher for logical partitions")
+	ErrDosExtendedPartition        = errors.New("dos partition tables can have at most one extended partition, numbered 1 to 4")
+	ErrDosLogicalWithoutExtended   = errors.New("logical partitions need an extended partition")
+
+	// LUKS errors
+	ErrLuksNameInvalid  = errors.New("luks name must not be empty or contain slashes")
+	ErrLuksLabelTooLong = errors.New("luks labels cannot be longer than 47 characters")
+	ErrKeyFileRequired  = errors.New("luks key file source is required")
+
+	// LVM errors
+	ErrLvmNameInvalid           = errors.New("LVM names may only contain letters, digits and +_.- and must not start with -")
+	ErrVolumeGroupNoDevices     = errors.New("volume groups need at least one device")
+	ErrDuplicateLogicalVolume   = errors.New("logical volume names must be unique within a volume group")
+	ErrLogicalVolumeSize        = errors.New("exactly one of sizeMiB and percentFree must be specified")
+	ErrLogicalVolumeSizeInvalid = errors.New("sizeMiB must be positive")
+	ErrPercentFreeInvalid       = errors.New("percentFree must be between 1 and 100")
+	ErrStripesInvalid           = errors.New("stripes must be positive")
+	ErrThinVolumeInvalid        = errors.New("thin volumes need sizeMiB and cannot set percentFree, stripes or thinPool")
+	ErrThinPoolNotFound         = errors.New("pool must name a thin pool listed earlier in the volume group")
 
 	// Passwd section errors
 	ErrPasswdCreateDeprecated      = errors.New("the create object has been deprecated in favor of user-level options")
@@ -95,6 +123,28 @@ var (
 	ErrHashUnrecognized    = errors.New("unrecognized hash function")
 	ErrEngineConfiguration = errors.New("engine incorrectly configured")
 
+	// Timeout and retry errors
+	ErrTimeoutNegative      = errors.New("timeouts and retry settings cannot be negative")
+	ErrRetryJitterTooLarge  = errors.New("retryJitter cannot be greater than 100")
+	ErrRetryBackoffInverted = errors.New("retryInitialBackoff cannot be greater than retryMaxBackoff")
+
+	// HTTP header errors
+	ErrEmptyHTTPHeaderName             = errors.New("HTTP header name can't be empty")
+	ErrInvalidHTTPHeader               = errors.New("HTTP header name or value contains invalid characters")
+	ErrDuplicateHTTPHeaders            = errors.New("HTTP header names must be unique")
+	ErrUnsupportedSchemeForHTTPHeaders = errors.New("HTTP headers can only be used with http and https sources")
+
+	// Decryption errors
+	ErrDecryptionKeyRequired = errors.New("decryption key must be specified")
+	ErrInvalidDecryptionKey  = errors.New("decryption key must be an oem URL or a file name in the system config directory")
+
+	// TLS errors
+	ErrClientCertificateWithoutKey = errors.New("clientCertificate and clientKey must be specified together")
+
+	// Signature errors
+	ErrInvalidSigningKey    = errors.New("trusted keys must be base64 encoded ed25519 public keys")
+	ErrSignatureUnsupported = errors.New("signatures are only supported for config references")
+
 	// AWS S3 specific errors
 	ErrInvalidS3ObjectVersionId = errors.New("invalid S3 object VersionId")
 )
@@ -53,6 +53,7 @@ func TestParse(t *testing.T) {
 							Line:      1,
 							Column:    87,
 							Highlight: "    1: {\"ignitionVersion\": 1, \"storage\": {\"filesystems\": [{\"device\": \"this/is/a/relative/path\"\n                                                                                            ^\n",
+							Path:      "/storage/filesystems/0/device",
 						},
 					},
 				},
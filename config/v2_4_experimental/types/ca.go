@@ -15,6 +15,7 @@
 package types
 
 import (
+	"github.com/flatcar-linux/ignition/config/shared/errors"
 	"github.com/flatcar-linux/ignition/config/validate/report"
 )
 
@@ -25,3 +26,14 @@ func (c CaReference) ValidateSource() report.Report {
 	}
 	return report.Report{}
 }
+
+func (c CaReference) ValidateHTTPHeaders() report.Report {
+	return validateHTTPHeadersScheme(c.Source, c.HTTPHeaders)
+}
+
+func (c CaReference) ValidateVerification() report.Report {
+	if c.Verification.Signature != nil {
+		return report.ReportFromError(errors.ErrSignatureUnsupported, report.EntryError)
+	}
+	return report.Report{}
+}
@@ -0,0 +1,44 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/flatcar-linux/ignition/config/shared/errors"
+	"github.com/flatcar-linux/ignition/config/validate/report"
+)
+
+// ValidateKey checks that the key is either an oem URL or a relative path,
+// which is looked up in the system config directory.
+func (d Decryption) ValidateKey() report.Report {
+	if d.Key == "" {
+		return report.ReportFromError(errors.ErrDecryptionKeyRequired, report.EntryError)
+	}
+	u, err := url.Parse(d.Key)
+	if err != nil {
+		return report.ReportFromError(errors.ErrInvalidDecryptionKey, report.EntryError)
+	}
+	switch {
+	case u.Scheme == "oem":
+		return report.Report{}
+	case u.Scheme == "" && path.Clean(d.Key) == d.Key && !path.IsAbs(d.Key) && !strings.HasPrefix(d.Key, ".."):
+		return report.Report{}
+	default:
+		return report.ReportFromError(errors.ErrInvalidDecryptionKey, report.EntryError)
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flatcar-linux/ignition/config/shared/errors"
+	"github.com/flatcar-linux/ignition/config/validate/report"
+)
+
+func TestDecryptionValidateKey(t *testing.T) {
+	tests := []struct {
+		in  Decryption
+		out error
+	}{
+		{
+			in: Decryption{Key: "oem:///keys/ignition.key"},
+		},
+		{
+			in: Decryption{Key: "ignition.key"},
+		},
+		{
+			in: Decryption{Key: "keys/ignition.key"},
+		},
+		{
+			in:  Decryption{},
+			out: errors.ErrDecryptionKeyRequired,
+		},
+		{
+			in:  Decryption{Key: "/etc/ignition.key"},
+			out: errors.ErrInvalidDecryptionKey,
+		},
+		{
+			in:  Decryption{Key: "../ignition.key"},
+			out: errors.ErrInvalidDecryptionKey,
+		},
+		{
+			in:  Decryption{Key: "https://example.com/ignition.key"},
+			out: errors.ErrInvalidDecryptionKey,
+		},
+	}
+
+	for i, test := range tests {
+		r := test.in.ValidateKey()
+		expected := report.Report{}
+		if test.out != nil {
+			expected = report.ReportFromError(test.out, report.EntryError)
+		}
+		if !reflect.DeepEqual(expected, r) {
+			t.Errorf("#%d: expected %v, got %v", i, expected, r)
+		}
+	}
+}
@@ -15,6 +15,9 @@
 package types
 
 import (
+	"path/filepath"
+	"strconv"
+
 	"github.com/flatcar-linux/ignition/config/shared/errors"
 	"github.com/flatcar-linux/ignition/config/validate/report"
 )
@@ -24,6 +27,12 @@ func (n Disk) Validate() report.Report {
 }
 
 func (n Disk) ValidateDevice() report.Report {
+	if n.Selector != nil {
+		if len(n.Device) != 0 {
+			return report.ReportFromError(errors.ErrDiskDeviceAndSelector, report.EntryError)
+		}
+		return report.Report{}
+	}
 	if len(n.Device) == 0 {
 		return report.ReportFromError(errors.ErrDiskDeviceRequired, report.EntryError)
 	}
@@ -33,6 +42,43 @@ func (n Disk) ValidateDevice() report.Report {
 	return report.Report{}
 }
 
+func (s DiskSelector) Validate() report.Report {
+	r := report.Report{}
+	if !s.LargestUnused && s.MinSizeMiB == nil && s.MaxSizeMiB == nil && s.Rotational == nil &&
+		s.Model == nil && s.Serial == nil && s.Path == nil {
+		r.Add(report.Entry{
+			Message: errors.ErrDiskSelectorEmpty.Error(),
+			Kind:    report.EntryError,
+		})
+	}
+	if (s.MinSizeMiB != nil && *s.MinSizeMiB <= 0) || (s.MaxSizeMiB != nil && *s.MaxSizeMiB <= 0) ||
+		(s.MinSizeMiB != nil && s.MaxSizeMiB != nil && *s.MinSizeMiB > *s.MaxSizeMiB) {
+		r.Add(report.Entry{
+			Message: errors.ErrDiskSelectorSizeInvalid.Error(),
+			Kind:    report.EntryError,
+		})
+	}
+	for _, pattern := range []*string{s.Model, s.Serial, s.Path} {
+		if pattern == nil {
+			continue
+		}
+		if _, err := filepath.Match(*pattern, ""); err != nil {
+			r.Add(report.Entry{
+				Message: errors.ErrDiskSelectorBadPattern.Error(),
+				Kind:    report.EntryError,
+			})
+		}
+	}
+	return r
+}
+
+func (n Disk) ValidatePartitionTable() report.Report {
+	if n.PartitionTable != nil && *n.PartitionTable != "gpt" && *n.PartitionTable != "dos" {
+		return report.ReportFromError(errors.ErrInvalidPartitionTable, report.EntryError)
+	}
+	return report.Report{}
+}
+
 func (n Disk) ValidatePartitions() report.Report {
 	r := report.Report{}
 	if n.partitionNumbersCollide() {
@@ -65,6 +111,19 @@ func (n Disk) ValidatePartitions() report.Report {
 			Kind:    report.EntryError,
 		})
 	}
+	if n.isDos() {
+		r.Merge(n.validateDosPartitions())
+	} else {
+		for _, p := range n.Partitions {
+			if p.DosType != "" {
+				r.Add(report.Entry{
+					Message: errors.ErrDosTypeOnGpt.Error(),
+					Kind:    report.EntryError,
+				})
+				break
+			}
+		}
+	}
 	// Disks which have no errors at this point will likely succeed in sgdisk
 	return r
 }
@@ -162,3 +221,59 @@ func (n Disk) partitionsUnitsMismatch() bool {
 	}
 	return partsInMb && partsNotInMb
 }
+
+func (n Disk) isDos() bool {
+	return n.PartitionTable != nil && *n.PartitionTable == "dos"
+}
+
+// isExtendedDosType returns whether t is the type of an extended partition:
+// 05 (CHS), 0f (LBA) or 85 (Linux).
+func isExtendedDosType(t string) bool {
+	v, err := strconv.ParseUint(t, 16, 8)
+	return err == nil && (v == 0x05 || v == 0x0f || v == 0x85)
+}
+
+// validateDosPartitions checks the constraints of the dos partition table
+// format: partitions 1 to 4 are primary, one of which may be an extended
+// partition holding the logical partitions numbered from 5.
+func (n Disk) validateDosPartitions() report.Report {
+	r := report.Report{}
+	extended := 0
+	misplaced := false
+	logical := false
+	for _, p := range n.Partitions {
+		if p.Label != nil || p.GUID != "" || p.TypeGUID != "" {
+			r.Add(report.Entry{
+				Message: errors.ErrGptFieldsOnDos.Error(),
+				Kind:    report.EntryError,
+			})
+		}
+		if p.Number <= 0 {
+			r.Add(report.Entry{
+				Message: errors.ErrDosPartitionNumber.Error(),
+				Kind:    report.EntryError,
+			})
+		}
+		if p.ShouldExist != nil && !*p.ShouldExist {
+			continue
+		}
+		if isExtendedDosType(p.DosType) {
+			extended++
+			misplaced = misplaced || p.Number > 4
+		}
+		logical = logical || p.Number > 4
+	}
+	if extended > 1 || misplaced {
+		r.Add(report.Entry{
+			Message: errors.ErrDosExtendedPartition.Error(),
+			Kind:    report.EntryError,
+		})
+	}
+	if logical && extended == 0 {
+		r.Add(report.Entry{
+			Message: errors.ErrDosLogicalWithoutExtended.Error(),
+			Kind:    report.EntryError,
+		})
+	}
+	return r
+}
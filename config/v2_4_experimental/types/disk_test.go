@@ -0,0 +1,179 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flatcar-linux/ignition/config/shared/errors"
+	"github.com/flatcar-linux/ignition/config/validate"
+	"github.com/flatcar-linux/ignition/config/validate/report"
+)
+
+func TestDiskSelectorValidate(t *testing.T) {
+	type in struct {
+		disk Disk
+	}
+	type out struct {
+		err error
+	}
+
+	intToPtr := func(i int) *int { return &i }
+	strToPtr := func(s string) *string { return &s }
+	boolToPtr := func(b bool) *bool { return &b }
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{disk: Disk{Device: "/dev/sda"}},
+			out: out{},
+		},
+		{
+			in: in{disk: Disk{Selector: &DiskSelector{
+				MinSizeMiB: intToPtr(100000),
+				MaxSizeMiB: intToPtr(200000),
+				Rotational: boolToPtr(false),
+				Model:      strToPtr("Samsung SSD 8?0*"),
+				Serial:     strToPtr("S3Z*"),
+				Path:       strToPtr("pci-0000:00:1f.2-ata-*"),
+			}}},
+			out: out{},
+		},
+		{
+			in:  in{disk: Disk{Selector: &DiskSelector{LargestUnused: true}}},
+			out: out{},
+		},
+		{
+			in:  in{disk: Disk{}},
+			out: out{err: errors.ErrDiskDeviceRequired},
+		},
+		{
+			in:  in{disk: Disk{Device: "/dev/sda", Selector: &DiskSelector{LargestUnused: true}}},
+			out: out{err: errors.ErrDiskDeviceAndSelector},
+		},
+		{
+			in:  in{disk: Disk{Selector: &DiskSelector{}}},
+			out: out{err: errors.ErrDiskSelectorEmpty},
+		},
+		{
+			in:  in{disk: Disk{Selector: &DiskSelector{MinSizeMiB: intToPtr(0)}}},
+			out: out{err: errors.ErrDiskSelectorSizeInvalid},
+		},
+		{
+			in:  in{disk: Disk{Selector: &DiskSelector{MinSizeMiB: intToPtr(2000), MaxSizeMiB: intToPtr(1000)}}},
+			out: out{err: errors.ErrDiskSelectorSizeInvalid},
+		},
+		{
+			in:  in{disk: Disk{Selector: &DiskSelector{Serial: strToPtr("[")}}},
+			out: out{err: errors.ErrDiskSelectorBadPattern},
+		},
+	}
+
+	for i, test := range tests {
+		r := validate.ValidateWithoutSource(reflect.ValueOf(test.in.disk))
+		expected := report.ReportFromError(test.out.err, report.EntryError)
+		if !reflect.DeepEqual(expected, r) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, expected, r)
+		}
+	}
+}
+
+func TestDosPartitionsValidate(t *testing.T) {
+	type in struct {
+		disk Disk
+	}
+	type out struct {
+		err error
+	}
+
+	strToPtr := func(s string) *string { return &s }
+	boolToPtr := func(b bool) *bool { return &b }
+	dos := strToPtr("dos")
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in: in{disk: Disk{Device: "/dev/sda", PartitionTable: dos, Partitions: []Partition{
+				{Number: 1, DosType: "83"},
+				{Number: 2, DosType: "5"},
+				{Number: 5, DosType: "82"},
+				{Number: 6},
+			}}},
+			out: out{},
+		},
+		{
+			in:  in{disk: Disk{Device: "/dev/sda", PartitionTable: strToPtr("gpt"), Partitions: []Partition{{Number: 1}}}},
+			out: out{},
+		},
+		{
+			in:  in{disk: Disk{Device: "/dev/sda", PartitionTable: strToPtr("mbr")}},
+			out: out{err: errors.ErrInvalidPartitionTable},
+		},
+		{
+			in:  in{disk: Disk{Device: "/dev/sda", Partitions: []Partition{{Number: 1, DosType: "83"}}}},
+			out: out{err: errors.ErrDosTypeOnGpt},
+		},
+		{
+			in:  in{disk: Disk{Device: "/dev/sda", PartitionTable: dos, Partitions: []Partition{{Number: 1, DosType: "zz"}}}},
+			out: out{err: errors.ErrDosTypeInvalid},
+		},
+		{
+			in:  in{disk: Disk{Device: "/dev/sda", PartitionTable: dos, Partitions: []Partition{{Number: 1, Label: strToPtr("ROOT")}}}},
+			out: out{err: errors.ErrGptFieldsOnDos},
+		},
+		{
+			in:  in{disk: Disk{Device: "/dev/sda", PartitionTable: dos, Partitions: []Partition{{DosType: "83"}}}},
+			out: out{err: errors.ErrDosPartitionNumber},
+		},
+		{
+			in: in{disk: Disk{Device: "/dev/sda", PartitionTable: dos, Partitions: []Partition{
+				{Number: 1, DosType: "f"},
+				{Number: 2, DosType: "5"},
+			}}},
+			out: out{err: errors.ErrDosExtendedPartition},
+		},
+		{
+			in:  in{disk: Disk{Device: "/dev/sda", PartitionTable: dos, Partitions: []Partition{{Number: 5, DosType: "5"}}}},
+			out: out{err: errors.ErrDosExtendedPartition},
+		},
+		{
+			in: in{disk: Disk{Device: "/dev/sda", PartitionTable: dos, Partitions: []Partition{
+				{Number: 1, DosType: "83"},
+				{Number: 5, DosType: "83"},
+			}}},
+			out: out{err: errors.ErrDosLogicalWithoutExtended},
+		},
+		{
+			in: in{disk: Disk{Device: "/dev/sda", PartitionTable: dos, Partitions: []Partition{
+				{Number: 2, ShouldExist: boolToPtr(false)},
+				{Number: 5, DosType: "83"},
+			}}},
+			out: out{err: errors.ErrDosLogicalWithoutExtended},
+		},
+	}
+
+	for i, test := range tests {
+		r := validate.ValidateWithoutSource(reflect.ValueOf(test.in.disk))
+		expected := report.ReportFromError(test.out.err, report.EntryError)
+		if !reflect.DeepEqual(expected, r) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, expected, r)
+		}
+	}
+}
@@ -48,7 +48,7 @@ func (f File) ValidateMode() report.Report {
 func (fc FileContents) ValidateCompression() report.Report {
 	r := report.Report{}
 	switch fc.Compression {
-	case "", "gzip":
+	case "", "gzip", "bzip2", "xz", "zstd":
 	default:
 		r.Add(report.Entry{
 			Message: errors.ErrCompressionInvalid.Error(),
@@ -58,6 +58,10 @@ func (fc FileContents) ValidateCompression() report.Report {
 	return r
 }
 
+func (fc FileContents) ValidateHTTPHeaders() report.Report {
+	return validateHTTPHeadersScheme(fc.Source, fc.HTTPHeaders)
+}
+
 func (fc FileContents) ValidateSource() report.Report {
 	r := report.Report{}
 	err := validateURL(fc.Source)
@@ -69,3 +73,10 @@ func (fc FileContents) ValidateSource() report.Report {
 	}
 	return r
 }
+
+func (fc FileContents) ValidateVerification() report.Report {
+	if fc.Verification.Signature != nil {
+		return report.ReportFromError(errors.ErrSignatureUnsupported, report.EntryError)
+	}
+	return report.Report{}
+}
@@ -0,0 +1,71 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"net/textproto"
+	"net/url"
+	"strings"
+
+	"github.com/flatcar-linux/ignition/config/shared/errors"
+	"github.com/flatcar-linux/ignition/config/validate/report"
+)
+
+func (h HTTPHeaders) Validate() report.Report {
+	r := report.Report{}
+	seen := map[string]bool{}
+	for _, header := range h {
+		// header names are case-insensitive
+		name := textproto.CanonicalMIMEHeaderKey(header.Name)
+		if seen[name] {
+			r.Add(report.Entry{
+				Message: errors.ErrDuplicateHTTPHeaders.Error(),
+				Kind:    report.EntryError,
+			})
+			break
+		}
+		seen[name] = true
+	}
+	return r
+}
+
+func (h HTTPHeadersItem) Validate() report.Report {
+	if h.Name == "" {
+		return report.ReportFromError(errors.ErrEmptyHTTPHeaderName, report.EntryError)
+	}
+	if strings.ContainsAny(h.Name, " \t\r\n:") || strings.ContainsAny(h.Value, "\r\n") {
+		return report.ReportFromError(errors.ErrInvalidHTTPHeader, report.EntryError)
+	}
+	return report.Report{}
+}
+
+// validateHTTPHeadersScheme checks that headers are only given for sources
+// fetched over HTTP.
+func validateHTTPHeadersScheme(source string, headers HTTPHeaders) report.Report {
+	if len(headers) == 0 {
+		return report.Report{}
+	}
+	u, err := url.Parse(source)
+	if err != nil {
+		// reported by ValidateSource
+		return report.Report{}
+	}
+	switch u.Scheme {
+	case "http", "https":
+		return report.Report{}
+	default:
+		return report.ReportFromError(errors.ErrUnsupportedSchemeForHTTPHeaders, report.EntryError)
+	}
+}
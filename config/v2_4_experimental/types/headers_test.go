@@ -0,0 +1,112 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flatcar-linux/ignition/config/shared/errors"
+	"github.com/flatcar-linux/ignition/config/validate/report"
+)
+
+func TestHTTPHeadersValidate(t *testing.T) {
+	tests := []struct {
+		in  HTTPHeaders
+		out error
+	}{
+		{
+			in: HTTPHeaders{},
+		},
+		{
+			in: HTTPHeaders{{Name: "Authorization", Value: "Bearer token"}, {Name: "X-Api-Key", Value: "key"}},
+		},
+		{
+			in:  HTTPHeaders{{Name: "Authorization", Value: "a"}, {Name: "authorization", Value: "b"}},
+			out: errors.ErrDuplicateHTTPHeaders,
+		},
+	}
+
+	for i, test := range tests {
+		r := test.in.Validate()
+		expected := report.Report{}
+		if test.out != nil {
+			expected = report.ReportFromError(test.out, report.EntryError)
+		}
+		if !reflect.DeepEqual(expected, r) {
+			t.Errorf("#%d: expected %v, got %v", i, expected, r)
+		}
+	}
+}
+
+func TestHTTPHeadersItemValidate(t *testing.T) {
+	tests := []struct {
+		in  HTTPHeadersItem
+		out error
+	}{
+		{
+			in: HTTPHeadersItem{Name: "Authorization", Value: "Bearer token"},
+		},
+		{
+			in:  HTTPHeadersItem{Value: "value"},
+			out: errors.ErrEmptyHTTPHeaderName,
+		},
+		{
+			in:  HTTPHeadersItem{Name: "X-Foo:", Value: "value"},
+			out: errors.ErrInvalidHTTPHeader,
+		},
+		{
+			in:  HTTPHeadersItem{Name: "X-Foo", Value: "value\r\nX-Bar: injected"},
+			out: errors.ErrInvalidHTTPHeader,
+		},
+	}
+
+	for i, test := range tests {
+		r := test.in.Validate()
+		expected := report.Report{}
+		if test.out != nil {
+			expected = report.ReportFromError(test.out, report.EntryError)
+		}
+		if !reflect.DeepEqual(expected, r) {
+			t.Errorf("#%d: expected %v, got %v", i, expected, r)
+		}
+	}
+}
+
+func TestValidateHTTPHeadersScheme(t *testing.T) {
+	headers := HTTPHeaders{{Name: "Authorization", Value: "Bearer token"}}
+	tests := []struct {
+		source  string
+		headers HTTPHeaders
+		out     error
+	}{
+		{source: "https://example.com/config.ign", headers: headers},
+		{source: "http://example.com/config.ign", headers: headers},
+		{source: "s3://bucket/config.ign"},
+		{source: "s3://bucket/config.ign", headers: headers, out: errors.ErrUnsupportedSchemeForHTTPHeaders},
+		{source: "data:,", headers: headers, out: errors.ErrUnsupportedSchemeForHTTPHeaders},
+	}
+
+	for i, test := range tests {
+		r := ConfigReference{Source: test.source, HTTPHeaders: test.headers}.ValidateHTTPHeaders()
+		expected := report.Report{}
+		if test.out != nil {
+			expected = report.ReportFromError(test.out, report.EntryError)
+		}
+		if !reflect.DeepEqual(expected, r) {
+			t.Errorf("#%d: expected %v, got %v", i, expected, r)
+		}
+	}
+}
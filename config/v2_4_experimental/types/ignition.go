@@ -21,6 +21,10 @@ import (
 	"github.com/flatcar-linux/ignition/config/validate/report"
 )
 
+func (c ConfigReference) ValidateHTTPHeaders() report.Report {
+	return validateHTTPHeadersScheme(c.Source, c.HTTPHeaders)
+}
+
 func (c ConfigReference) ValidateSource() report.Report {
 	r := report.Report{}
 	err := validateURL(c.Source)
@@ -50,3 +54,36 @@ func (v Ignition) Validate() report.Report {
 	}
 	return report.Report{}
 }
+
+func (t TLS) Validate() report.Report {
+	if (t.ClientCertificate == nil) != (t.ClientKey == nil) {
+		return report.ReportFromError(errors.ErrClientCertificateWithoutKey, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (t Timeouts) Validate() report.Report {
+	r := report.Report{}
+	for _, v := range []*int{t.HTTPResponseHeaders, t.HTTPTotal, t.RetryInitialBackoff, t.RetryJitter, t.RetryMaxAttempts, t.RetryMaxBackoff} {
+		if v != nil && *v < 0 {
+			r.Add(report.Entry{
+				Message: errors.ErrTimeoutNegative.Error(),
+				Kind:    report.EntryError,
+			})
+			return r
+		}
+	}
+	if t.RetryJitter != nil && *t.RetryJitter > 100 {
+		r.Add(report.Entry{
+			Message: errors.ErrRetryJitterTooLarge.Error(),
+			Kind:    report.EntryError,
+		})
+	}
+	if t.RetryInitialBackoff != nil && t.RetryMaxBackoff != nil && *t.RetryMaxBackoff != 0 && *t.RetryInitialBackoff > *t.RetryMaxBackoff {
+		r.Add(report.Entry{
+			Message: errors.ErrRetryBackoffInverted.Error(),
+			Kind:    report.EntryError,
+		})
+	}
+	return r
+}
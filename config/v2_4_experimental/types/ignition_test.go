@@ -0,0 +1,127 @@
+// Copyright 2017 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flatcar-linux/ignition/config/shared/errors"
+	"github.com/flatcar-linux/ignition/config/validate/report"
+)
+
+func TestTLSValidate(t *testing.T) {
+	type in struct {
+		tls TLS
+	}
+	type out struct {
+		err error
+	}
+
+	cert := &CaReference{Source: "data:,cert"}
+	key := &CaReference{Source: "data:,key"}
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{tls: TLS{}},
+			out: out{},
+		},
+		{
+			in:  in{tls: TLS{ClientCertificate: cert, ClientKey: key}},
+			out: out{},
+		},
+		{
+			in:  in{tls: TLS{ClientCertificate: cert}},
+			out: out{errors.ErrClientCertificateWithoutKey},
+		},
+		{
+			in:  in{tls: TLS{ClientKey: key}},
+			out: out{errors.ErrClientCertificateWithoutKey},
+		},
+	}
+
+	for i, test := range tests {
+		r := test.in.tls.Validate()
+		expect := report.Report{}
+		if test.out.err != nil {
+			expect = report.ReportFromError(test.out.err, report.EntryError)
+		}
+		if !reflect.DeepEqual(expect, r) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, expect, r)
+		}
+	}
+}
+
+func TestTimeoutsValidate(t *testing.T) {
+	type in struct {
+		timeouts Timeouts
+	}
+	type out struct {
+		err error
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{timeouts: Timeouts{}},
+			out: out{},
+		},
+		{
+			in: in{timeouts: Timeouts{
+				HTTPTotal:           intToPtr(30),
+				RetryInitialBackoff: intToPtr(200),
+				RetryJitter:         intToPtr(100),
+				RetryMaxAttempts:    intToPtr(5),
+				RetryMaxBackoff:     intToPtr(2000),
+			}},
+			out: out{},
+		},
+		{
+			in:  in{timeouts: Timeouts{RetryInitialBackoff: intToPtr(500)}},
+			out: out{},
+		},
+		{
+			in:  in{timeouts: Timeouts{RetryMaxAttempts: intToPtr(-1)}},
+			out: out{errors.ErrTimeoutNegative},
+		},
+		{
+			in:  in{timeouts: Timeouts{HTTPTotal: intToPtr(-5)}},
+			out: out{errors.ErrTimeoutNegative},
+		},
+		{
+			in:  in{timeouts: Timeouts{RetryJitter: intToPtr(101)}},
+			out: out{errors.ErrRetryJitterTooLarge},
+		},
+		{
+			in:  in{timeouts: Timeouts{RetryInitialBackoff: intToPtr(3000), RetryMaxBackoff: intToPtr(2000)}},
+			out: out{errors.ErrRetryBackoffInverted},
+		},
+	}
+
+	for i, test := range tests {
+		r := test.in.timeouts.Validate()
+		expect := report.Report{}
+		if test.out.err != nil {
+			expect = report.ReportFromError(test.out.err, report.EntryError)
+		}
+		if !reflect.DeepEqual(expect, r) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, expect, r)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"strings"
+
+	"github.com/flatcar-linux/ignition/config/shared/errors"
+	"github.com/flatcar-linux/ignition/config/validate/report"
+)
+
+func (l Luks) ValidateName() report.Report {
+	// the name becomes /dev/mapper/<name>
+	if l.Name == "" || strings.Contains(l.Name, "/") {
+		return report.ReportFromError(errors.ErrLuksNameInvalid, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (l Luks) ValidateDevice() report.Report {
+	if err := validatePath(l.Device); err != nil {
+		return report.ReportFromError(err, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (l Luks) ValidateLabel() report.Report {
+	// source: LUKS2 on-disk format specification
+	if l.Label != nil && len(*l.Label) > 47 {
+		return report.ReportFromError(errors.ErrLuksLabelTooLong, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (l Luks) ValidateUUID() report.Report {
+	if l.UUID == nil {
+		return report.Report{}
+	}
+	return validateGUID(*l.UUID)
+}
+
+func (k KeyFile) ValidateSource() report.Report {
+	if k.Source == "" {
+		return report.ReportFromError(errors.ErrKeyFileRequired, report.EntryError)
+	}
+	if err := validateURL(k.Source); err != nil {
+		return report.ReportFromError(err, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (k KeyFile) ValidateHTTPHeaders() report.Report {
+	return validateHTTPHeadersScheme(k.Source, k.HTTPHeaders)
+}
+
+func (k KeyFile) ValidateVerification() report.Report {
+	if k.Verification.Signature != nil {
+		return report.ReportFromError(errors.ErrSignatureUnsupported, report.EntryError)
+	}
+	return report.Report{}
+}
@@ -0,0 +1,98 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/flatcar-linux/ignition/config/shared/errors"
+	"github.com/flatcar-linux/ignition/config/validate"
+	"github.com/flatcar-linux/ignition/config/validate/report"
+)
+
+func TestLuksValidate(t *testing.T) {
+	type in struct {
+		luks Luks
+	}
+	type out struct {
+		err error
+	}
+
+	strToPtr := func(s string) *string { return &s }
+	keyFile := KeyFile{Source: "data:,secret"}
+	sig := "https://example.com/key.sig"
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{luks: Luks{Name: "data", Device: "/dev/sdb", KeyFile: keyFile}},
+			out: out{},
+		},
+		{
+			in: in{luks: Luks{
+				Name:    "data",
+				Device:  "/dev/sdb",
+				KeyFile: keyFile,
+				Label:   strToPtr("data"),
+				UUID:    strToPtr("6a5b3f2c-95d3-4bd7-8f1e-7d6c3b2a1f00"),
+			}},
+			out: out{},
+		},
+		{
+			in:  in{luks: Luks{Name: "", Device: "/dev/sdb", KeyFile: keyFile}},
+			out: out{err: errors.ErrLuksNameInvalid},
+		},
+		{
+			in:  in{luks: Luks{Name: "mapper/data", Device: "/dev/sdb", KeyFile: keyFile}},
+			out: out{err: errors.ErrLuksNameInvalid},
+		},
+		{
+			in:  in{luks: Luks{Name: "data", Device: "sdb", KeyFile: keyFile}},
+			out: out{err: errors.ErrPathRelative},
+		},
+		{
+			in:  in{luks: Luks{Name: "data", Device: "/dev/sdb", KeyFile: keyFile, Label: strToPtr(strings.Repeat("a", 48))}},
+			out: out{err: errors.ErrLuksLabelTooLong},
+		},
+		{
+			in:  in{luks: Luks{Name: "data", Device: "/dev/sdb", KeyFile: keyFile, UUID: strToPtr("not-a-uuid")}},
+			out: out{err: errors.ErrDoesntMatchGUIDRegex},
+		},
+		{
+			in:  in{luks: Luks{Name: "data", Device: "/dev/sdb"}},
+			out: out{err: errors.ErrKeyFileRequired},
+		},
+		{
+			in:  in{luks: Luks{Name: "data", Device: "/dev/sdb", KeyFile: KeyFile{Source: "file:///key"}}},
+			out: out{err: errors.ErrInvalidScheme},
+		},
+		{
+			in:  in{luks: Luks{Name: "data", Device: "/dev/sdb", KeyFile: KeyFile{Source: "https://example.com/key", Verification: Verification{Signature: &sig}}}},
+			out: out{err: errors.ErrSignatureUnsupported},
+		},
+	}
+
+	for i, test := range tests {
+		r := validate.ValidateWithoutSource(reflect.ValueOf(test.in.luks))
+		expected := report.ReportFromError(test.out.err, report.EntryError)
+		if !reflect.DeepEqual(expected, r) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, expected, r)
+		}
+	}
+}
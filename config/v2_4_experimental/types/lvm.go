@@ -0,0 +1,121 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"regexp"
+
+	"github.com/flatcar-linux/ignition/config/shared/errors"
+	"github.com/flatcar-linux/ignition/config/validate/report"
+)
+
+var (
+	// source: man lvm, VALID NAMES
+	lvmNameRegex = regexp.MustCompile(`^[a-zA-Z0-9+_.][a-zA-Z0-9+_.-]*$`)
+)
+
+func validateLvmName(name string) report.Report {
+	if !lvmNameRegex.MatchString(name) || name == "." || name == ".." {
+		return report.ReportFromError(errors.ErrLvmNameInvalid, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (v VolumeGroup) Validate() report.Report {
+	r := report.Report{}
+	seen := map[string]bool{}
+	thinPools := map[string]bool{}
+	for _, lv := range v.LogicalVolumes {
+		if seen[lv.Name] {
+			r.Add(report.Entry{
+				Message: errors.ErrDuplicateLogicalVolume.Error(),
+				Kind:    report.EntryError,
+			})
+		}
+		seen[lv.Name] = true
+		if lv.Pool != nil && !thinPools[*lv.Pool] {
+			r.Add(report.Entry{
+				Message: errors.ErrThinPoolNotFound.Error(),
+				Kind:    report.EntryError,
+			})
+		}
+		if lv.ThinPool {
+			thinPools[lv.Name] = true
+		}
+	}
+	return r
+}
+
+func (v VolumeGroup) ValidateName() report.Report {
+	return validateLvmName(v.Name)
+}
+
+func (v VolumeGroup) ValidateDevices() report.Report {
+	r := report.Report{}
+	if len(v.Devices) == 0 {
+		r.Add(report.Entry{
+			Message: errors.ErrVolumeGroupNoDevices.Error(),
+			Kind:    report.EntryError,
+		})
+	}
+	for _, d := range v.Devices {
+		if err := validatePath(string(d)); err != nil {
+			r.Add(report.Entry{
+				Message: err.Error(),
+				Kind:    report.EntryError,
+			})
+		}
+	}
+	return r
+}
+
+func (l LogicalVolume) Validate() report.Report {
+	if l.Pool != nil {
+		// thin volumes only have a virtual size
+		if l.SizeMiB == nil || l.PercentFree != nil || l.Stripes != nil || l.ThinPool {
+			return report.ReportFromError(errors.ErrThinVolumeInvalid, report.EntryError)
+		}
+		return report.Report{}
+	}
+	if (l.SizeMiB == nil) == (l.PercentFree == nil) {
+		return report.ReportFromError(errors.ErrLogicalVolumeSize, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (l LogicalVolume) ValidateName() report.Report {
+	return validateLvmName(l.Name)
+}
+
+func (l LogicalVolume) ValidateSizeMiB() report.Report {
+	if l.SizeMiB != nil && *l.SizeMiB <= 0 {
+		return report.ReportFromError(errors.ErrLogicalVolumeSizeInvalid, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (l LogicalVolume) ValidatePercentFree() report.Report {
+	if l.PercentFree != nil && (*l.PercentFree < 1 || *l.PercentFree > 100) {
+		return report.ReportFromError(errors.ErrPercentFreeInvalid, report.EntryError)
+	}
+	return report.Report{}
+}
+
+func (l LogicalVolume) ValidateStripes() report.Report {
+	if l.Stripes != nil && *l.Stripes < 1 {
+		return report.ReportFromError(errors.ErrStripesInvalid, report.EntryError)
+	}
+	return report.Report{}
+}
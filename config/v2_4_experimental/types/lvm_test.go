@@ -0,0 +1,136 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flatcar-linux/ignition/config/shared/errors"
+	"github.com/flatcar-linux/ignition/config/validate"
+	"github.com/flatcar-linux/ignition/config/validate/report"
+)
+
+func TestVolumeGroupValidate(t *testing.T) {
+	type in struct {
+		vg VolumeGroup
+	}
+	type out struct {
+		err error
+	}
+
+	intToPtr := func(i int) *int { return &i }
+	strToPtr := func(s string) *string { return &s }
+	devs := []Device{"/dev/sdb", "/dev/sdc"}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{vg: VolumeGroup{Name: "data", Devices: devs}},
+			out: out{},
+		},
+		{
+			in: in{vg: VolumeGroup{Name: "data", Devices: devs, LogicalVolumes: []LogicalVolume{
+				{Name: "root", SizeMiB: intToPtr(1024), Stripes: intToPtr(2)},
+				{Name: "pool", PercentFree: intToPtr(80), ThinPool: true},
+				{Name: "thin", Pool: strToPtr("pool"), SizeMiB: intToPtr(4096)},
+			}}},
+			out: out{},
+		},
+		{
+			in:  in{vg: VolumeGroup{Name: "-data", Devices: devs}},
+			out: out{err: errors.ErrLvmNameInvalid},
+		},
+		{
+			in:  in{vg: VolumeGroup{Name: "", Devices: devs}},
+			out: out{err: errors.ErrLvmNameInvalid},
+		},
+		{
+			in:  in{vg: VolumeGroup{Name: "data"}},
+			out: out{err: errors.ErrVolumeGroupNoDevices},
+		},
+		{
+			in:  in{vg: VolumeGroup{Name: "data", Devices: []Device{"sdb"}}},
+			out: out{err: errors.ErrPathRelative},
+		},
+		{
+			in: in{vg: VolumeGroup{Name: "data", Devices: devs, LogicalVolumes: []LogicalVolume{
+				{Name: "root", SizeMiB: intToPtr(1024)},
+				{Name: "root", SizeMiB: intToPtr(1024)},
+			}}},
+			out: out{err: errors.ErrDuplicateLogicalVolume},
+		},
+		{
+			in: in{vg: VolumeGroup{Name: "data", Devices: devs, LogicalVolumes: []LogicalVolume{
+				{Name: "thin", Pool: strToPtr("pool"), SizeMiB: intToPtr(4096)},
+				{Name: "pool", PercentFree: intToPtr(80), ThinPool: true},
+			}}},
+			out: out{err: errors.ErrThinPoolNotFound},
+		},
+		{
+			in: in{vg: VolumeGroup{Name: "data", Devices: devs, LogicalVolumes: []LogicalVolume{
+				{Name: "root/x", SizeMiB: intToPtr(1024)},
+			}}},
+			out: out{err: errors.ErrLvmNameInvalid},
+		},
+		{
+			in: in{vg: VolumeGroup{Name: "data", Devices: devs, LogicalVolumes: []LogicalVolume{
+				{Name: "root"},
+			}}},
+			out: out{err: errors.ErrLogicalVolumeSize},
+		},
+		{
+			in: in{vg: VolumeGroup{Name: "data", Devices: devs, LogicalVolumes: []LogicalVolume{
+				{Name: "root", SizeMiB: intToPtr(1024), PercentFree: intToPtr(50)},
+			}}},
+			out: out{err: errors.ErrLogicalVolumeSize},
+		},
+		{
+			in: in{vg: VolumeGroup{Name: "data", Devices: devs, LogicalVolumes: []LogicalVolume{
+				{Name: "root", SizeMiB: intToPtr(0)},
+			}}},
+			out: out{err: errors.ErrLogicalVolumeSizeInvalid},
+		},
+		{
+			in: in{vg: VolumeGroup{Name: "data", Devices: devs, LogicalVolumes: []LogicalVolume{
+				{Name: "root", PercentFree: intToPtr(101)},
+			}}},
+			out: out{err: errors.ErrPercentFreeInvalid},
+		},
+		{
+			in: in{vg: VolumeGroup{Name: "data", Devices: devs, LogicalVolumes: []LogicalVolume{
+				{Name: "root", SizeMiB: intToPtr(1024), Stripes: intToPtr(0)},
+			}}},
+			out: out{err: errors.ErrStripesInvalid},
+		},
+		{
+			in: in{vg: VolumeGroup{Name: "data", Devices: devs, LogicalVolumes: []LogicalVolume{
+				{Name: "pool", SizeMiB: intToPtr(1024), ThinPool: true},
+				{Name: "thin", Pool: strToPtr("pool"), PercentFree: intToPtr(50)},
+			}}},
+			out: out{err: errors.ErrThinVolumeInvalid},
+		},
+	}
+
+	for i, test := range tests {
+		r := validate.ValidateWithoutSource(reflect.ValueOf(test.in.vg))
+		expected := report.ReportFromError(test.out.err, report.EntryError)
+		if !reflect.DeepEqual(expected, r) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, expected, r)
+		}
+	}
+}
@@ -17,6 +17,7 @@ package types
 import (
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/flatcar-linux/ignition/config/shared/errors"
@@ -36,12 +37,18 @@ func (p Partition) Validate() report.Report {
 		})
 	}
 	if p.ShouldExist != nil && !*p.ShouldExist &&
-		(p.Label != nil || p.TypeGUID != "" || p.GUID != "" || p.Start != nil || p.Size != nil) {
+		(p.Label != nil || p.TypeGUID != "" || p.GUID != "" || p.DosType != "" || p.Start != nil || p.Size != nil || p.Resize) {
 		r.Add(report.Entry{
 			Message: errors.ErrShouldNotExistWithOthers.Error(),
 			Kind:    report.EntryError,
 		})
 	}
+	if p.Resize && p.Number == 0 {
+		r.Add(report.Entry{
+			Message: errors.ErrResizeWithoutNumber.Error(),
+			Kind:    report.EntryError,
+		})
+	}
 	return r
 }
 
@@ -86,6 +93,16 @@ func (p Partition) ValidateLabel() report.Report {
 	return r
 }
 
+func (p Partition) ValidateDosType() report.Report {
+	if p.DosType == "" {
+		return report.Report{}
+	}
+	if t, err := strconv.ParseUint(p.DosType, 16, 8); err != nil || t == 0 {
+		return report.ReportFromError(errors.ErrDosTypeInvalid, report.EntryError)
+	}
+	return report.Report{}
+}
+
 func (p Partition) ValidateTypeGUID() report.Report {
 	return validateGUID(p.TypeGUID)
 }
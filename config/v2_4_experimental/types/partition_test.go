@@ -198,3 +198,40 @@ func TestValidateStart(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateResize(t *testing.T) {
+	type in struct {
+		partition Partition
+	}
+	type out struct {
+		report report.Report
+	}
+	falseVal := false
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in{Partition{Number: 9, Resize: true}},
+			out{report.Report{}},
+		},
+		{
+			in{Partition{Number: 9, Resize: true, SizeMiB: intToPtr(0)}},
+			out{report.Report{}},
+		},
+		{
+			in{Partition{Resize: true}},
+			out{report.ReportFromError(errors.ErrResizeWithoutNumber, report.EntryError)},
+		},
+		{
+			in{Partition{Number: 9, Resize: true, ShouldExist: &falseVal}},
+			out{report.ReportFromError(errors.ErrShouldNotExistWithOthers, report.EntryError)},
+		},
+	}
+	for i, test := range tests {
+		r := test.in.partition.Validate()
+		if !reflect.DeepEqual(r, test.out.report) {
+			t.Errorf("#%d: wanted %v, got %v", i, test.out.report, r)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package types
 // generated by "schematyper --package=types schema/ignition.json -o internal/config/types/schema.go --root-type=Config" -- DO NOT EDIT
 
 type CaReference struct {
+	HTTPHeaders  HTTPHeaders  `json:"httpHeaders,omitempty"`
 	Source       string       `json:"source"`
 	Verification Verification `json:"verification,omitempty"`
 }
@@ -16,6 +17,8 @@ type Config struct {
 }
 
 type ConfigReference struct {
+	Decryption   *Decryption  `json:"decryption,omitempty"`
+	HTTPHeaders  HTTPHeaders  `json:"httpHeaders,omitempty"`
 	Source       string       `json:"source"`
 	Verification Verification `json:"verification,omitempty"`
 }
@@ -27,6 +30,10 @@ type Create struct {
 
 type CreateOption string
 
+type Decryption struct {
+	Key string `json:"key"`
+}
+
 type Device string
 
 type Directory struct {
@@ -39,9 +46,21 @@ type DirectoryEmbedded1 struct {
 }
 
 type Disk struct {
-	Device     string      `json:"device"`
-	Partitions []Partition `json:"partitions,omitempty"`
-	WipeTable  bool        `json:"wipeTable,omitempty"`
+	Device         string        `json:"device"`
+	PartitionTable *string       `json:"partitionTable,omitempty"`
+	Partitions     []Partition   `json:"partitions,omitempty"`
+	Selector       *DiskSelector `json:"selector,omitempty"`
+	WipeTable      bool          `json:"wipeTable,omitempty"`
+}
+
+type DiskSelector struct {
+	LargestUnused bool    `json:"largestUnused,omitempty"`
+	MaxSizeMiB    *int    `json:"maxSizeMiB,omitempty"`
+	MinSizeMiB    *int    `json:"minSizeMiB,omitempty"`
+	Model         *string `json:"model,omitempty"`
+	Path          *string `json:"path,omitempty"`
+	Rotational    *bool   `json:"rotational,omitempty"`
+	Serial        *string `json:"serial,omitempty"`
 }
 
 type File struct {
@@ -51,6 +70,8 @@ type File struct {
 
 type FileContents struct {
 	Compression  string       `json:"compression,omitempty"`
+	Decryption   *Decryption  `json:"decryption,omitempty"`
+	HTTPHeaders  HTTPHeaders  `json:"httpHeaders,omitempty"`
 	Source       string       `json:"source,omitempty"`
 	Verification Verification `json:"verification,omitempty"`
 }
@@ -69,6 +90,13 @@ type Filesystem struct {
 
 type Group string
 
+type HTTPHeaders []HTTPHeadersItem
+
+type HTTPHeadersItem struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
 type Ignition struct {
 	Config   IgnitionConfig `json:"config,omitempty"`
 	Proxy    Proxy          `json:"proxy,omitempty"`
@@ -82,6 +110,12 @@ type IgnitionConfig struct {
 	Replace *ConfigReference  `json:"replace,omitempty"`
 }
 
+type KeyFile struct {
+	HTTPHeaders  HTTPHeaders  `json:"httpHeaders,omitempty"`
+	Source       string       `json:"source"`
+	Verification Verification `json:"verification,omitempty"`
+}
+
 type Link struct {
 	Node
 	LinkEmbedded1
@@ -92,6 +126,36 @@ type LinkEmbedded1 struct {
 	Target string `json:"target"`
 }
 
+type LogicalVolume struct {
+	Name              string                `json:"name"`
+	Options           []LogicalVolumeOption `json:"options,omitempty"`
+	PercentFree       *int                  `json:"percentFree,omitempty"`
+	Pool              *string               `json:"pool,omitempty"`
+	SizeMiB           *int                  `json:"sizeMiB,omitempty"`
+	Stripes           *int                  `json:"stripes,omitempty"`
+	ThinPool          bool                  `json:"thinPool,omitempty"`
+	WipeLogicalVolume bool                  `json:"wipeLogicalVolume,omitempty"`
+}
+
+type LogicalVolumeOption string
+
+type Luks struct {
+	Cipher     *string      `json:"cipher,omitempty"`
+	Device     string       `json:"device"`
+	KeyFile    KeyFile      `json:"keyFile"`
+	Label      *string      `json:"label,omitempty"`
+	Name       string       `json:"name"`
+	Options    []LuksOption `json:"options,omitempty"`
+	UUID       *string      `json:"uuid,omitempty"`
+	WipeVolume bool         `json:"wipeVolume,omitempty"`
+}
+
+type LuksOption string
+
+type Lvm struct {
+	VolumeGroups []VolumeGroup `json:"volumeGroups,omitempty"`
+}
+
 type Mount struct {
 	Create         *Create       `json:"create,omitempty"`
 	Device         string        `json:"device"`
@@ -140,9 +204,11 @@ type NodeUser struct {
 }
 
 type Partition struct {
+	DosType            string  `json:"dosType,omitempty"`
 	GUID               string  `json:"guid,omitempty"`
 	Label              *string `json:"label,omitempty"`
 	Number             int     `json:"number,omitempty"`
+	Resize             bool    `json:"resize,omitempty"`
 	ShouldExist        *bool   `json:"shouldExist,omitempty"`
 	Size               *int    `json:"size,omitempty"`
 	SizeMiB            *int    `json:"sizeMiB,omitempty"`
@@ -200,7 +266,12 @@ type RaidOption string
 type SSHAuthorizedKey string
 
 type Security struct {
-	TLS TLS `json:"tls,omitempty"`
+	Signing Signing `json:"signing,omitempty"`
+	TLS     TLS     `json:"tls,omitempty"`
+}
+
+type Signing struct {
+	TrustedKeys []TrustedKeysItem `json:"trustedKeys,omitempty"`
 }
 
 type Storage struct {
@@ -209,6 +280,8 @@ type Storage struct {
 	Files       []File       `json:"files,omitempty"`
 	Filesystems []Filesystem `json:"filesystems,omitempty"`
 	Links       []Link       `json:"links,omitempty"`
+	Luks        []Luks       `json:"luks,omitempty"`
+	Lvm         Lvm          `json:"lvm,omitempty"`
 	Raid        []Raid       `json:"raid,omitempty"`
 }
 
@@ -223,13 +296,21 @@ type SystemdDropin struct {
 
 type TLS struct {
 	CertificateAuthorities []CaReference `json:"certificateAuthorities,omitempty"`
+	ClientCertificate      *CaReference  `json:"clientCertificate,omitempty"`
+	ClientKey              *CaReference  `json:"clientKey,omitempty"`
 }
 
 type Timeouts struct {
 	HTTPResponseHeaders *int `json:"httpResponseHeaders,omitempty"`
 	HTTPTotal           *int `json:"httpTotal,omitempty"`
+	RetryInitialBackoff *int `json:"retryInitialBackoff,omitempty"`
+	RetryJitter         *int `json:"retryJitter,omitempty"`
+	RetryMaxAttempts    *int `json:"retryMaxAttempts,omitempty"`
+	RetryMaxBackoff     *int `json:"retryMaxBackoff,omitempty"`
 }
 
+type TrustedKeysItem string
+
 type Unit struct {
 	Contents string          `json:"contents,omitempty"`
 	Dropins  []SystemdDropin `json:"dropins,omitempty"`
@@ -255,5 +336,16 @@ type Usercreate struct {
 type UsercreateGroup string
 
 type Verification struct {
-	Hash *string `json:"hash,omitempty"`
+	Hash      *string `json:"hash,omitempty"`
+	Signature *string `json:"signature,omitempty"`
 }
+
+type VolumeGroup struct {
+	Devices         []Device            `json:"devices"`
+	LogicalVolumes  []LogicalVolume     `json:"logicalVolumes,omitempty"`
+	Name            string              `json:"name"`
+	Options         []VolumeGroupOption `json:"options,omitempty"`
+	WipeVolumeGroup bool                `json:"wipeVolumeGroup,omitempty"`
+}
+
+type VolumeGroupOption string
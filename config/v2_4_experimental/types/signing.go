@@ -0,0 +1,55 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+
+	"github.com/flatcar-linux/ignition/config/shared/errors"
+	"github.com/flatcar-linux/ignition/config/validate/report"
+)
+
+// ValidateTrustedKeys checks that every trusted key is an ed25519 public key,
+// either raw or PKIX encoded, in base64.
+func (s Signing) ValidateTrustedKeys() report.Report {
+	r := report.Report{}
+	for _, key := range s.TrustedKeys {
+		if !validSigningKey(string(key)) {
+			r.Add(report.Entry{
+				Message: errors.ErrInvalidSigningKey.Error(),
+				Kind:    report.EntryError,
+			})
+		}
+	}
+	return r
+}
+
+func validSigningKey(key string) bool {
+	der, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return false
+	}
+	if len(der) == ed25519.PublicKeySize {
+		return true
+	}
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return false
+	}
+	_, ok := pub.(ed25519.PublicKey)
+	return ok
+}
@@ -0,0 +1,63 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flatcar-linux/ignition/config/shared/errors"
+	"github.com/flatcar-linux/ignition/config/validate/report"
+)
+
+func TestSigningValidateTrustedKeys(t *testing.T) {
+	type in struct {
+		signing Signing
+	}
+	type out struct {
+		err error
+	}
+
+	raw := TrustedKeysItem("AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8=")
+	pkix := TrustedKeysItem("MCowBQYDK2VwAyEAAAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8=")
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{signing: Signing{}},
+			out: out{},
+		},
+		{
+			in:  in{signing: Signing{TrustedKeys: []TrustedKeysItem{raw, pkix}}},
+			out: out{},
+		},
+		{
+			in:  in{signing: Signing{TrustedKeys: []TrustedKeysItem{"AAECAwQFBgcICQoLDA0ODw=="}}},
+			out: out{err: errors.ErrInvalidSigningKey},
+		},
+		{
+			in:  in{signing: Signing{TrustedKeys: []TrustedKeysItem{"not base64"}}},
+			out: out{err: errors.ErrInvalidSigningKey},
+		},
+	}
+
+	for i, test := range tests {
+		r := test.in.signing.ValidateTrustedKeys()
+		if !reflect.DeepEqual(report.ReportFromError(test.out.err, report.EntryError), r) {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.out.err, r)
+		}
+	}
+}
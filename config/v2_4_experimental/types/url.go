@@ -33,7 +33,7 @@ func validateURL(s string) error {
 	}
 
 	switch u.Scheme {
-	case "http", "https", "oem", "tftp":
+	case "http", "https", "oem", "tftp", "gs":
 		return nil
 	case "s3":
 		if v, ok := u.Query()["versionId"]; ok {
@@ -73,6 +73,10 @@ func TestURLValidate(t *testing.T) {
 			in:  in{u: "s3://bucket/key?versionId=aVersionHash"},
 			out: out{},
 		},
+		{
+			in:  in{u: "gs://bucket/object"},
+			out: out{},
+		},
 	}
 
 	for i, test := range tests {
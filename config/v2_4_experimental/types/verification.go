@@ -56,6 +56,10 @@ func (v Verification) Validate() report.Report {
 	}
 	var hash crypto.Hash
 	switch function {
+	case "sha256":
+		hash = crypto.SHA256
+	case "sha384":
+		hash = crypto.SHA384
 	case "sha512":
 		hash = crypto.SHA512
 	default:
@@ -75,3 +79,18 @@ func (v Verification) Validate() report.Report {
 
 	return r
 }
+
+// ValidateSignature checks that the detached signature, if any, is fetched
+// from a supported URL.
+func (v Verification) ValidateSignature() report.Report {
+	if v.Signature == nil {
+		return report.Report{}
+	}
+	if *v.Signature == "" {
+		return report.ReportFromError(errors.ErrInvalidUrl, report.EntryError)
+	}
+	if err := validateURL(*v.Signature); err != nil {
+		return report.ReportFromError(err, report.EntryError)
+	}
+	return report.Report{}
+}
@@ -65,6 +65,9 @@ func TestHashValidate(t *testing.T) {
 	h1 := "xor-abcdef"
 	h2 := "sha512-123"
 	h3 := "sha512-0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	h4 := "sha256-0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	h5 := "sha384-0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	h6 := "sha256-0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
 
 	tests := []struct {
 		in  in
@@ -82,6 +85,18 @@ func TestHashValidate(t *testing.T) {
 			in:  in{v: Verification{Hash: &h3}},
 			out: out{},
 		},
+		{
+			in:  in{v: Verification{Hash: &h4}},
+			out: out{},
+		},
+		{
+			in:  in{v: Verification{Hash: &h5}},
+			out: out{},
+		},
+		{
+			in:  in{v: Verification{Hash: &h6}},
+			out: out{err: errors.ErrHashWrongSize},
+		},
 	}
 
 	for i, test := range tests {
@@ -91,3 +106,50 @@ func TestHashValidate(t *testing.T) {
 		}
 	}
 }
+
+func TestSignatureValidate(t *testing.T) {
+	type in struct {
+		v Verification
+	}
+	type out struct {
+		err error
+	}
+
+	s1 := "https://example.com/config.ign.sig"
+	s2 := "data:,c2lnbmF0dXJl"
+	s3 := ""
+	s4 := "file:///config.ign.sig"
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in:  in{v: Verification{}},
+			out: out{},
+		},
+		{
+			in:  in{v: Verification{Signature: &s1}},
+			out: out{},
+		},
+		{
+			in:  in{v: Verification{Signature: &s2}},
+			out: out{},
+		},
+		{
+			in:  in{v: Verification{Signature: &s3}},
+			out: out{err: errors.ErrInvalidUrl},
+		},
+		{
+			in:  in{v: Verification{Signature: &s4}},
+			out: out{err: errors.ErrInvalidScheme},
+		},
+	}
+
+	for i, test := range tests {
+		err := test.in.v.ValidateSignature()
+		if !reflect.DeepEqual(report.ReportFromError(test.out.err, report.EntryError), err) {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.out.err, err)
+		}
+	}
+}
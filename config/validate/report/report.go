@@ -127,13 +127,42 @@ type Entry struct {
 	Line      int       `json:"line,omitempty"`
 	Column    int       `json:"column,omitempty"`
 	Highlight string    `json:"-"`
+	// Path is the JSON pointer (RFC 6901) of the node the entry is about.
+	Path string `json:"path,omitempty"`
+	// Source names the config the entry is about when a report covers
+	// several configs.
+	Source string `json:"source,omitempty"`
 }
 
 func (e Entry) String() string {
+	var source string
+	if e.Source != "" {
+		source = fmt.Sprintf(" in %s", e.Source)
+	}
 	if e.Line != 0 {
-		return fmt.Sprintf("%s at line %d, column %d\n%s%v", e.Kind.String(), e.Line, e.Column, e.Highlight, e.Message)
+		return fmt.Sprintf("%s%s at line %d, column %d\n%s%v", e.Kind.String(), source, e.Line, e.Column, e.Highlight, e.Message)
+	}
+	return fmt.Sprintf("%s%s: %v", e.Kind.String(), source, e.Message)
+}
+
+// AddPath sets the Path of all the entries which don't have one yet. Since
+// reports are built from the innermost nodes outwards, this leaves every entry
+// with the path of the deepest node it was added at.
+func (r *Report) AddPath(path string) {
+	for i, e := range r.Entries {
+		if e.Path == "" {
+			r.Entries[i].Path = path
+		}
+	}
+}
+
+// SetSource sets the Source of all the entries which don't have one yet.
+func (r *Report) SetSource(source string) {
+	for i, e := range r.Entries {
+		if e.Source == "" {
+			r.Entries[i].Source = source
+		}
 	}
-	return fmt.Sprintf("%s: %v", e.Kind.String(), e.Message)
 }
 
 type entryKind int
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"io"
 	"reflect"
+	"strconv"
 	"strings"
 
 	json "github.com/ajeddeloh/go-json"
@@ -52,7 +53,21 @@ func ValidateConfig(rawConfig []byte, config interface{}) report.Report {
 // Validate walks down a struct tree calling Validate on every node that implements it, building
 // A report of all the errors, warnings, info, and deprecations it encounters. If checkUnusedKeys
 // is true, Validate will generate warnings for unused keys in the ast, otherwise it will not.
-func Validate(vObj reflect.Value, ast astnode.AstNode, source io.ReadSeeker, checkUnusedKeys bool) (r report.Report) {
+// If ast is set, entries are given the JSON pointer of the node they were found at, relative
+// to vObj.
+func Validate(vObj reflect.Value, ast astnode.AstNode, source io.ReadSeeker, checkUnusedKeys bool) report.Report {
+	return validate(vObj, ast, source, checkUnusedKeys, "")
+}
+
+// pointerEscaper escapes reference tokens of JSON pointers
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// childPath returns the JSON pointer of the child called token of the node at path.
+func childPath(path, token string) string {
+	return path + "/" + pointerEscaper.Replace(token)
+}
+
+func validate(vObj reflect.Value, ast astnode.AstNode, source io.ReadSeeker, checkUnusedKeys bool, path string) (r report.Report) {
 	if !vObj.IsValid() {
 		return
 	}
@@ -72,6 +87,9 @@ func Validate(vObj reflect.Value, ast astnode.AstNode, source io.ReadSeeker, che
 			(!vObj.IsNil() && !vObj.Elem().Type().Implements(reflect.TypeOf((*validator)(nil)).Elem()))) {
 		sub_r := obj.Validate()
 		sub_r.AddPosition(line, col, highlight)
+		if ast != nil {
+			sub_r.AddPath(path)
+		}
 		r.Merge(sub_r)
 
 		// Dont recurse on invalid inner nodes, it mostly leads to bogus messages
@@ -82,11 +100,11 @@ func Validate(vObj reflect.Value, ast astnode.AstNode, source io.ReadSeeker, che
 
 	switch vObj.Kind() {
 	case reflect.Ptr:
-		sub_report := Validate(vObj.Elem(), ast, source, checkUnusedKeys)
+		sub_report := validate(vObj.Elem(), ast, source, checkUnusedKeys, path)
 		sub_report.AddPosition(line, col, "")
 		r.Merge(sub_report)
 	case reflect.Struct:
-		sub_report := validateStruct(vObj, ast, source, checkUnusedKeys)
+		sub_report := validateStruct(vObj, ast, source, checkUnusedKeys, path)
 		sub_report.AddPosition(line, col, "")
 		r.Merge(sub_report)
 	case reflect.Slice:
@@ -97,7 +115,7 @@ func Validate(vObj reflect.Value, ast astnode.AstNode, source io.ReadSeeker, che
 					sub_node = n
 				}
 			}
-			sub_report := Validate(vObj.Index(i), sub_node, source, checkUnusedKeys)
+			sub_report := validate(vObj.Index(i), sub_node, source, checkUnusedKeys, childPath(path, strconv.Itoa(i)))
 			sub_report.AddPosition(line, col, "")
 			r.Merge(sub_report)
 		}
@@ -134,7 +152,7 @@ func getFields(vObj reflect.Value) []field {
 	return ret
 }
 
-func validateStruct(vObj reflect.Value, ast astnode.AstNode, source io.ReadSeeker, checkUnusedKeys bool) report.Report {
+func validateStruct(vObj reflect.Value, ast astnode.AstNode, source io.ReadSeeker, checkUnusedKeys bool, path string) report.Report {
 	r := report.Report{}
 
 	// isFromObject will be true if this struct was unmarshalled from a JSON object.
@@ -155,10 +173,13 @@ func validateStruct(vObj reflect.Value, ast astnode.AstNode, source io.ReadSeeke
 		// Default to passing a nil source if the field's corrosponding node cannot be found.
 		// This ensures the line numbers reported from all sub-structs are 0 and will be changed by AddPosition
 		var src io.ReadSeeker
+		// Paths are only known when the struct came from an object.
+		fieldPath := path
 
 		// Try to determine the json.Node that corrosponds with the struct field
 		if isFromObject {
 			tag := strings.SplitN(f.Type.Tag.Get(ast.Tag()), ",", 2)[0]
+			fieldPath = childPath(path, tag)
 			// Save the tag so we have a list of all the tags in the struct
 			tags = append(tags, tag)
 			// mark that this key was used
@@ -189,11 +210,18 @@ func validateStruct(vObj reflect.Value, ast astnode.AstNode, source io.ReadSeeke
 			res := funct.Call(nil)
 			sub_report := res[0].Interface().(report.Report)
 			sub_report.AddPosition(line, col, highlight)
+			if isFromObject {
+				sub_report.AddPath(fieldPath)
+			}
 			r.Merge(sub_report)
 		}
 
-		sub_report := Validate(f.Value, sub_node, src, checkUnusedKeys)
+		sub_report := validate(f.Value, sub_node, src, checkUnusedKeys, fieldPath)
 		sub_report.AddPosition(line, col, highlight)
+		if isFromObject {
+			// fields missing from the object still have a well defined path
+			sub_report.AddPath(fieldPath)
+		}
 		r.Merge(sub_report)
 	}
 	if !isFromObject || !checkUnusedKeys {
@@ -214,6 +242,7 @@ func validateStruct(vObj reflect.Value, ast astnode.AstNode, source io.ReadSeeke
 			Line:      line,
 			Column:    col,
 			Highlight: highlight,
+			Path:      childPath(path, k),
 		})
 
 		if typo != "" {
@@ -223,6 +252,7 @@ func validateStruct(vObj reflect.Value, ast astnode.AstNode, source io.ReadSeeke
 				Line:      line,
 				Column:    col,
 				Highlight: highlight,
+				Path:      childPath(path, k),
 			})
 		}
 	}
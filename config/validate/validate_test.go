@@ -188,9 +188,10 @@ func TestValidateLineCol(t *testing.T) {
 		r report.Report
 	}
 
-	reportFromDummyWithLineCol := func(line, col int) report.Report {
+	reportFromDummyWithLineCol := func(line, col int, path string) report.Report {
 		r := report.ReportFromError(dummyErr, report.EntryError)
 		r.AddPosition(line, col, "")
+		r.AddPath(path)
 		return r
 	}
 
@@ -209,7 +210,7 @@ func TestValidateLineCol(t *testing.T) {
 }`,
 				unmarshalInto: reflect.TypeOf(Simple{}),
 			},
-			out: out{r: reportFromDummyWithLineCol(2, 2)},
+			out: out{r: reportFromDummyWithLineCol(2, 2, "")},
 		},
 		{
 			in: in{
@@ -217,7 +218,7 @@ func TestValidateLineCol(t *testing.T) {
 }`,
 				unmarshalInto: reflect.TypeOf(simpleEmbedded{}),
 			},
-			out: out{r: reportFromDummyWithLineCol(2, 2)},
+			out: out{r: reportFromDummyWithLineCol(2, 2, "")},
 		},
 		{
 			in: in{
@@ -226,7 +227,7 @@ func TestValidateLineCol(t *testing.T) {
 }`,
 				unmarshalInto: reflect.TypeOf(NamedValidate{}),
 			},
-			out: out{r: reportFromDummyWithLineCol(2, 15)},
+			out: out{r: reportFromDummyWithLineCol(2, 15, "/a")},
 		},
 		{
 			in: in{
@@ -235,7 +236,7 @@ func TestValidateLineCol(t *testing.T) {
 }`,
 				unmarshalInto: reflect.TypeOf(NamedEmbedded{}),
 			},
-			out: out{r: reportFromDummyWithLineCol(2, 15)},
+			out: out{r: reportFromDummyWithLineCol(2, 15, "/a")},
 		},
 		{
 			in: in{
@@ -244,7 +245,7 @@ func TestValidateLineCol(t *testing.T) {
 }`,
 				unmarshalInto: reflect.TypeOf(twiceNestedAndNamed{}),
 			},
-			out: out{r: reportFromDummyWithLineCol(2, 15)},
+			out: out{r: reportFromDummyWithLineCol(2, 15, "/a")},
 		},
 	}
 
@@ -271,6 +272,29 @@ func TestValidateLineCol(t *testing.T) {
 	}
 }
 
+type sliceOfNamed struct {
+	B []NamedValidate `json:"b"`
+}
+
+func TestValidatePaths(t *testing.T) {
+	cfg := `{"b": [{"a": "x"}, {"a": "y", "c/~": 1}]}`
+	var v sliceOfNamed
+	if err := json.Unmarshal([]byte(cfg), &v); err != nil {
+		t.Fatalf("Failed to unmarshal into struct: %v", err)
+	}
+	var ast json.Node
+	if err := json.Unmarshal([]byte(cfg), &ast); err != nil {
+		t.Fatalf("Failed to unmarshal into ast: %v", err)
+	}
+
+	r := Validate(reflect.ValueOf(v), astjson.FromJsonRoot(ast), bytes.NewReader([]byte(cfg)), true)
+	paths := []string{}
+	for _, e := range r.Entries {
+		paths = append(paths, e.Path)
+	}
+	assert.Equal(t, []string{"/b/0/a", "/b/1/a", "/b/1/c~1~0"}, paths)
+}
+
 func TestGetFields(t *testing.T) {
 	// basic case
 	type Test1 struct {
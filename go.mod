@@ -3,12 +3,14 @@ module github.com/flatcar-linux/ignition
 go 1.16
 
 require (
+	filippo.io/age v1.0.0
 	github.com/ajeddeloh/go-json v0.0.0-20160803184958-73d058cf8437
 	github.com/aws/aws-sdk-go v1.8.39
 	github.com/coreos/go-semver v0.1.0
 	github.com/coreos/go-systemd v0.0.0-20181031085051-9002847aa142
 	github.com/go-ini/ini v1.25.4 // indirect
 	github.com/godbus/dbus v0.0.0-20181025153459-66d97aec3384 // indirect
+	github.com/google/go-cmp v0.5.8 // indirect
 	github.com/jmespath/go-jmespath v0.0.0-20160202185014-0b12d6b521d8 // indirect
 	github.com/pborman/uuid v0.0.0-20170612153648-e790cca94e6c
 	github.com/pin/tftp v2.1.0+incompatible
@@ -20,7 +22,9 @@ require (
 	github.com/vmware/vmw-guestinfo v0.0.0-20170707015358-25eff159a728 // indirect
 	github.com/vmware/vmw-ovflib v0.0.0-20170608004843-1f217b9dc714
 	go4.org v0.0.0-20160314031811-03efcb870d84
-	golang.org/x/net v0.0.0-20190320064053-1272bf9dcd53
+	golang.org/x/crypto v0.0.0-20211117183948-ae814b36b871 // indirect
+	golang.org/x/net v0.0.0-20211112202133-69e39bad7dc2
 	golang.org/x/text v0.3.7 // indirect
+	gopkg.in/go-jose/go-jose.v2 v2.6.3
 	gopkg.in/yaml.v2 v2.4.0 // indirect
 )
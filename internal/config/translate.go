@@ -35,15 +35,39 @@ func boolToPtr(b bool) *bool {
 }
 
 func Translate(old from.Config) types.Config {
+	translateHTTPHeaders := func(old from.HTTPHeaders) types.HTTPHeaders {
+		var res types.HTTPHeaders
+		for _, x := range old {
+			res = append(res, types.HTTPHeadersItem{
+				Name:  x.Name,
+				Value: x.Value,
+			})
+		}
+		return res
+	}
+	translateVerification := func(old from.Verification) types.Verification {
+		return types.Verification{
+			Hash:      old.Hash,
+			Signature: old.Signature,
+		}
+	}
+	translateDecryption := func(old *from.Decryption) *types.Decryption {
+		if old == nil {
+			return nil
+		}
+		return &types.Decryption{
+			Key: old.Key,
+		}
+	}
 	translateConfigReference := func(old *from.ConfigReference) *types.ConfigReference {
 		if old == nil {
 			return nil
 		}
 		return &types.ConfigReference{
-			Source: old.Source,
-			Verification: types.Verification{
-				Hash: old.Verification.Hash,
-			},
+			Decryption:   translateDecryption(old.Decryption),
+			HTTPHeaders:  translateHTTPHeaders(old.HTTPHeaders),
+			Source:       old.Source,
+			Verification: translateVerification(old.Verification),
 		}
 	}
 	translateConfigReferenceSlice := func(old []from.ConfigReference) []types.ConfigReference {
@@ -53,15 +77,20 @@ func Translate(old from.Config) types.Config {
 		}
 		return res
 	}
+	translateCaReference := func(old *from.CaReference) *types.CaReference {
+		if old == nil {
+			return nil
+		}
+		return &types.CaReference{
+			HTTPHeaders:  translateHTTPHeaders(old.HTTPHeaders),
+			Source:       old.Source,
+			Verification: translateVerification(old.Verification),
+		}
+	}
 	translateCertificateAuthoritySlice := func(old []from.CaReference) []types.CaReference {
 		var res []types.CaReference
 		for _, x := range old {
-			res = append(res, types.CaReference{
-				Source: x.Source,
-				Verification: types.Verification{
-					Hash: x.Verification.Hash,
-				},
-			})
+			res = append(res, *translateCaReference(&x))
 		}
 		return res
 	}
@@ -72,6 +101,13 @@ func Translate(old from.Config) types.Config {
 		}
 		return res
 	}
+	translateTrustedKeysSlice := func(old []from.TrustedKeysItem) []types.TrustedKeysItem {
+		var res []types.TrustedKeysItem
+		for _, x := range old {
+			res = append(res, types.TrustedKeysItem(x))
+		}
+		return res
+	}
 	translateNetworkdDropinSlice := func(old []from.NetworkdDropin) []types.NetworkdDropin {
 		var res []types.NetworkdDropin
 		for _, x := range old {
@@ -208,9 +244,11 @@ func Translate(old from.Config) types.Config {
 		var res []types.Partition
 		for _, x := range old {
 			res = append(res, types.Partition{
+				DosType:            x.DosType,
 				GUID:               x.GUID,
 				Label:              x.Label,
 				Number:             x.Number,
+				Resize:             x.Resize,
 				Size:               x.Size,
 				SizeMiB:            x.SizeMiB,
 				Start:              x.Start,
@@ -222,13 +260,29 @@ func Translate(old from.Config) types.Config {
 		}
 		return res
 	}
+	translateDiskSelector := func(old *from.DiskSelector) *types.DiskSelector {
+		if old == nil {
+			return nil
+		}
+		return &types.DiskSelector{
+			LargestUnused: old.LargestUnused,
+			MaxSizeMiB:    old.MaxSizeMiB,
+			MinSizeMiB:    old.MinSizeMiB,
+			Model:         old.Model,
+			Path:          old.Path,
+			Rotational:    old.Rotational,
+			Serial:        old.Serial,
+		}
+	}
 	translateDiskSlice := func(old []from.Disk) []types.Disk {
 		var res []types.Disk
 		for _, x := range old {
 			res = append(res, types.Disk{
-				Device:     x.Device,
-				Partitions: translatePartitionSlice(x.Partitions),
-				WipeTable:  x.WipeTable,
+				Device:         x.Device,
+				PartitionTable: x.PartitionTable,
+				Partitions:     translatePartitionSlice(x.Partitions),
+				Selector:       translateDiskSelector(x.Selector),
+				WipeTable:      x.WipeTable,
 			})
 		}
 		return res
@@ -240,11 +294,11 @@ func Translate(old from.Config) types.Config {
 				Node: translateNode(x.Node),
 				FileEmbedded1: types.FileEmbedded1{
 					Contents: types.FileContents{
-						Compression: x.Contents.Compression,
-						Source:      x.Contents.Source,
-						Verification: types.Verification{
-							Hash: x.Contents.Verification.Hash,
-						},
+						Compression:  x.Contents.Compression,
+						Decryption:   translateDecryption(x.Contents.Decryption),
+						HTTPHeaders:  translateHTTPHeaders(x.Contents.HTTPHeaders),
+						Source:       x.Contents.Source,
+						Verification: translateVerification(x.Contents.Verification),
 					},
 					Mode:   x.Mode,
 					Append: x.Append,
@@ -341,6 +395,76 @@ func Translate(old from.Config) types.Config {
 		}
 		return res
 	}
+	translateLuksOptionSlice := func(old []from.LuksOption) []types.LuksOption {
+		var res []types.LuksOption
+		for _, x := range old {
+			res = append(res, types.LuksOption(x))
+		}
+		return res
+	}
+	translateLuksSlice := func(old []from.Luks) []types.Luks {
+		var res []types.Luks
+		for _, x := range old {
+			res = append(res, types.Luks{
+				Cipher: x.Cipher,
+				Device: x.Device,
+				KeyFile: types.KeyFile{
+					HTTPHeaders:  translateHTTPHeaders(x.KeyFile.HTTPHeaders),
+					Source:       x.KeyFile.Source,
+					Verification: translateVerification(x.KeyFile.Verification),
+				},
+				Label:      x.Label,
+				Name:       x.Name,
+				Options:    translateLuksOptionSlice(x.Options),
+				UUID:       x.UUID,
+				WipeVolume: x.WipeVolume,
+			})
+		}
+		return res
+	}
+	translateLogicalVolumeOptionSlice := func(old []from.LogicalVolumeOption) []types.LogicalVolumeOption {
+		var res []types.LogicalVolumeOption
+		for _, x := range old {
+			res = append(res, types.LogicalVolumeOption(x))
+		}
+		return res
+	}
+	translateLogicalVolumeSlice := func(old []from.LogicalVolume) []types.LogicalVolume {
+		var res []types.LogicalVolume
+		for _, x := range old {
+			res = append(res, types.LogicalVolume{
+				Name:              x.Name,
+				Options:           translateLogicalVolumeOptionSlice(x.Options),
+				PercentFree:       x.PercentFree,
+				Pool:              x.Pool,
+				SizeMiB:           x.SizeMiB,
+				Stripes:           x.Stripes,
+				ThinPool:          x.ThinPool,
+				WipeLogicalVolume: x.WipeLogicalVolume,
+			})
+		}
+		return res
+	}
+	translateVolumeGroupOptionSlice := func(old []from.VolumeGroupOption) []types.VolumeGroupOption {
+		var res []types.VolumeGroupOption
+		for _, x := range old {
+			res = append(res, types.VolumeGroupOption(x))
+		}
+		return res
+	}
+	translateVolumeGroupSlice := func(old []from.VolumeGroup) []types.VolumeGroup {
+		var res []types.VolumeGroup
+		for _, x := range old {
+			res = append(res, types.VolumeGroup{
+				Devices:         translateDeviceSlice(x.Devices),
+				LogicalVolumes:  translateLogicalVolumeSlice(x.LogicalVolumes),
+				Name:            x.Name,
+				Options:         translateVolumeGroupOptionSlice(x.Options),
+				WipeVolumeGroup: x.WipeVolumeGroup,
+			})
+		}
+		return res
+	}
 	translateSystemdDropinSlice := func(old []from.SystemdDropin) []types.SystemdDropin {
 		var res []types.SystemdDropin
 		for _, x := range old {
@@ -371,14 +495,23 @@ func Translate(old from.Config) types.Config {
 			Timeouts: types.Timeouts{
 				HTTPResponseHeaders: old.Ignition.Timeouts.HTTPResponseHeaders,
 				HTTPTotal:           old.Ignition.Timeouts.HTTPTotal,
+				RetryInitialBackoff: old.Ignition.Timeouts.RetryInitialBackoff,
+				RetryJitter:         old.Ignition.Timeouts.RetryJitter,
+				RetryMaxAttempts:    old.Ignition.Timeouts.RetryMaxAttempts,
+				RetryMaxBackoff:     old.Ignition.Timeouts.RetryMaxBackoff,
 			},
 			Config: types.IgnitionConfig{
 				Replace: translateConfigReference(old.Ignition.Config.Replace),
 				Append:  translateConfigReferenceSlice(old.Ignition.Config.Append),
 			},
 			Security: types.Security{
+				Signing: types.Signing{
+					TrustedKeys: translateTrustedKeysSlice(old.Ignition.Security.Signing.TrustedKeys),
+				},
 				TLS: types.TLS{
 					CertificateAuthorities: translateCertificateAuthoritySlice(old.Ignition.Security.TLS.CertificateAuthorities),
+					ClientCertificate:      translateCaReference(old.Ignition.Security.TLS.ClientCertificate),
+					ClientKey:              translateCaReference(old.Ignition.Security.TLS.ClientKey),
 				},
 			},
 			Proxy: types.Proxy{
@@ -400,7 +533,11 @@ func Translate(old from.Config) types.Config {
 			Files:       translateFileSlice(old.Storage.Files),
 			Filesystems: translateFilesystemSlice(old.Storage.Filesystems),
 			Links:       translateLinkSlice(old.Storage.Links),
-			Raid:        translateRaidSlice(old.Storage.Raid),
+			Luks:        translateLuksSlice(old.Storage.Luks),
+			Lvm: types.Lvm{
+				VolumeGroups: translateVolumeGroupSlice(old.Storage.Lvm.VolumeGroups),
+			},
+			Raid: translateRaidSlice(old.Storage.Raid),
 		},
 		Systemd: types.Systemd{
 			Units: translateSystemdUnitSlice(old.Systemd.Units),
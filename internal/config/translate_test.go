@@ -165,6 +165,112 @@ func TestTranslate(t *testing.T) {
 				},
 			}},
 		},
+		{
+			in: in{config: from.Config{
+				Ignition: from.Ignition{
+					Timeouts: from.Timeouts{
+						RetryInitialBackoff: intToPtr(200),
+						RetryJitter:         intToPtr(20),
+						RetryMaxAttempts:    intToPtr(5),
+						RetryMaxBackoff:     intToPtr(2000),
+					},
+				},
+			}},
+			out: out{config: types.Config{
+				Ignition: types.Ignition{
+					Version: types.MaxVersion.String(),
+					Timeouts: types.Timeouts{
+						RetryInitialBackoff: intToPtr(200),
+						RetryJitter:         intToPtr(20),
+						RetryMaxAttempts:    intToPtr(5),
+						RetryMaxBackoff:     intToPtr(2000),
+					},
+				},
+			}},
+		},
+		{
+			in: in{config: from.Config{
+				Ignition: from.Ignition{
+					Config: from.IgnitionConfig{
+						Append: []from.ConfigReference{
+							{
+								Source:      "https://example.com/config.ign",
+								HTTPHeaders: from.HTTPHeaders{{Name: "Authorization", Value: "Bearer token"}},
+							},
+							{
+								Source:     "data:,encrypted",
+								Decryption: &from.Decryption{Key: "ignition.key"},
+							},
+						},
+					},
+					Security: from.Security{
+						TLS: from.TLS{
+							CertificateAuthorities: []from.CaReference{
+								{
+									Source:      "https://example.com/ca.pem",
+									HTTPHeaders: from.HTTPHeaders{{Name: "X-Api-Key", Value: "key"}},
+								},
+							},
+						},
+					},
+				},
+				Storage: from.Storage{
+					Files: []from.File{
+						{
+							Node: from.Node{Filesystem: "root", Path: "/opt/file"},
+							FileEmbedded1: from.FileEmbedded1{
+								Contents: from.FileContents{
+									Source:      "https://example.com/file",
+									HTTPHeaders: from.HTTPHeaders{{Name: "Authorization", Value: "Basic dXNlcjpwYXNz"}},
+									Decryption:  &from.Decryption{Key: "oem:///ignition.key"},
+								},
+							},
+						},
+					},
+				},
+			}},
+			out: out{config: types.Config{
+				Ignition: types.Ignition{
+					Version: types.MaxVersion.String(),
+					Config: types.IgnitionConfig{
+						Append: []types.ConfigReference{
+							{
+								Source:      "https://example.com/config.ign",
+								HTTPHeaders: types.HTTPHeaders{{Name: "Authorization", Value: "Bearer token"}},
+							},
+							{
+								Source:     "data:,encrypted",
+								Decryption: &types.Decryption{Key: "ignition.key"},
+							},
+						},
+					},
+					Security: types.Security{
+						TLS: types.TLS{
+							CertificateAuthorities: []types.CaReference{
+								{
+									Source:      "https://example.com/ca.pem",
+									HTTPHeaders: types.HTTPHeaders{{Name: "X-Api-Key", Value: "key"}},
+								},
+							},
+						},
+					},
+				},
+				Storage: types.Storage{
+					Files: []types.File{
+						{
+							Node: types.Node{Filesystem: "root", Path: "/opt/file"},
+							FileEmbedded1: types.FileEmbedded1{
+								Contents: types.FileContents{
+									Source:      "https://example.com/file",
+									HTTPHeaders: types.HTTPHeaders{{Name: "Authorization", Value: "Basic dXNlcjpwYXNz"}},
+									Decryption:  &types.Decryption{Key: "oem:///ignition.key"},
+								},
+							},
+						},
+					},
+				},
+			}},
+		},
 		{
 			in: in{config: from.Config{
 				Ignition: from.Ignition{
@@ -194,6 +300,80 @@ func TestTranslate(t *testing.T) {
 				},
 			}},
 		},
+		{
+			in: in{config: from.Config{
+				Ignition: from.Ignition{
+					Security: from.Security{
+						TLS: from.TLS{
+							ClientCertificate: &from.CaReference{
+								Source: "oem:///client.pem",
+							},
+							ClientKey: &from.CaReference{
+								Source:      "https://example.com/client.key",
+								HTTPHeaders: from.HTTPHeaders{{Name: "X-Api-Key", Value: "key"}},
+							},
+						},
+					},
+				},
+			}},
+			out: out{config: types.Config{
+				Ignition: types.Ignition{
+					Version: types.MaxVersion.String(),
+					Security: types.Security{
+						TLS: types.TLS{
+							ClientCertificate: &types.CaReference{
+								Source: "oem:///client.pem",
+							},
+							ClientKey: &types.CaReference{
+								Source:      "https://example.com/client.key",
+								HTTPHeaders: types.HTTPHeaders{{Name: "X-Api-Key", Value: "key"}},
+							},
+						},
+					},
+				},
+			}},
+		},
+		{
+			in: in{config: from.Config{
+				Ignition: from.Ignition{
+					Config: from.IgnitionConfig{
+						Append: []from.ConfigReference{
+							{
+								Source: "https://example.com/config.ign",
+								Verification: from.Verification{
+									Signature: strToPtr("https://example.com/config.ign.sig"),
+								},
+							},
+						},
+					},
+					Security: from.Security{
+						Signing: from.Signing{
+							TrustedKeys: []from.TrustedKeysItem{"AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8="},
+						},
+					},
+				},
+			}},
+			out: out{config: types.Config{
+				Ignition: types.Ignition{
+					Version: types.MaxVersion.String(),
+					Config: types.IgnitionConfig{
+						Append: []types.ConfigReference{
+							{
+								Source: "https://example.com/config.ign",
+								Verification: types.Verification{
+									Signature: strToPtr("https://example.com/config.ign.sig"),
+								},
+							},
+						},
+					},
+					Security: types.Security{
+						Signing: types.Signing{
+							TrustedKeys: []types.TrustedKeysItem{"AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8="},
+						},
+					},
+				},
+			}},
+		},
 		{
 			in: in{config: from.Config{
 				Ignition: from.Ignition{
@@ -367,6 +547,7 @@ func TestTranslate(t *testing.T) {
 									TypeGUID:           "HI",
 									GUID:               "4F68BCE3-E8CD-4DB1-96E7-FBCAF984B709",
 									WipePartitionEntry: true,
+									Resize:             true,
 									ShouldExist:        util.BoolToPtr(true),
 								},
 								{
@@ -382,8 +563,26 @@ func TestTranslate(t *testing.T) {
 							},
 						},
 						{
-							Device:    "/dev/sdb",
-							WipeTable: true,
+							Device:         "/dev/sdb",
+							WipeTable:      true,
+							PartitionTable: util.StrToPtrStrict("dos"),
+							Partitions: []from.Partition{
+								{
+									Number:  1,
+									DosType: "83",
+								},
+							},
+						},
+						{
+							Selector: &from.DiskSelector{
+								LargestUnused: true,
+								MaxSizeMiB:    util.IntToPtr(200000),
+								MinSizeMiB:    util.IntToPtr(100000),
+								Model:         util.StrToPtrStrict("Samsung*"),
+								Path:          util.StrToPtrStrict("pci-*"),
+								Rotational:    util.BoolToPtr(false),
+								Serial:        util.StrToPtrStrict("S3Z*"),
+							},
 						},
 					},
 				},
@@ -404,6 +603,7 @@ func TestTranslate(t *testing.T) {
 									TypeGUID:           "HI",
 									GUID:               "4F68BCE3-E8CD-4DB1-96E7-FBCAF984B709",
 									WipePartitionEntry: true,
+									Resize:             true,
 									ShouldExist:        util.BoolToPtr(true),
 								},
 								{
@@ -419,8 +619,144 @@ func TestTranslate(t *testing.T) {
 							},
 						},
 						{
-							Device:    "/dev/sdb",
-							WipeTable: true,
+							Device:         "/dev/sdb",
+							WipeTable:      true,
+							PartitionTable: util.StrToPtrStrict("dos"),
+							Partitions: []types.Partition{
+								{
+									Number:  1,
+									DosType: "83",
+								},
+							},
+						},
+						{
+							Selector: &types.DiskSelector{
+								LargestUnused: true,
+								MaxSizeMiB:    util.IntToPtr(200000),
+								MinSizeMiB:    util.IntToPtr(100000),
+								Model:         util.StrToPtrStrict("Samsung*"),
+								Path:          util.StrToPtrStrict("pci-*"),
+								Rotational:    util.BoolToPtr(false),
+								Serial:        util.StrToPtrStrict("S3Z*"),
+							},
+						},
+					},
+				},
+			}},
+		},
+		{
+			in: in{config: from.Config{
+				Ignition: from.Ignition{Version: from.MaxVersion.String()},
+				Storage: from.Storage{
+					Luks: []from.Luks{
+						{
+							Name:   "data",
+							Device: "/dev/sdb",
+							KeyFile: from.KeyFile{
+								Source:      "https://example.com/data.key",
+								HTTPHeaders: from.HTTPHeaders{{Name: "X-Api-Key", Value: "key"}},
+								Verification: from.Verification{
+									Hash: strToPtr("sha256-0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"),
+								},
+							},
+							Cipher:     strToPtr("aes-xts-plain64"),
+							Label:      strToPtr("data"),
+							UUID:       strToPtr("6a5b3f2c-95d3-4bd7-8f1e-7d6c3b2a1f00"),
+							WipeVolume: true,
+							Options:    []from.LuksOption{"--pbkdf=pbkdf2"},
+						},
+					},
+				},
+			}},
+			out: out{config: types.Config{
+				Ignition: types.Ignition{Version: types.MaxVersion.String()},
+				Storage: types.Storage{
+					Luks: []types.Luks{
+						{
+							Name:   "data",
+							Device: "/dev/sdb",
+							KeyFile: types.KeyFile{
+								Source:      "https://example.com/data.key",
+								HTTPHeaders: types.HTTPHeaders{{Name: "X-Api-Key", Value: "key"}},
+								Verification: types.Verification{
+									Hash: strToPtr("sha256-0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"),
+								},
+							},
+							Cipher:     strToPtr("aes-xts-plain64"),
+							Label:      strToPtr("data"),
+							UUID:       strToPtr("6a5b3f2c-95d3-4bd7-8f1e-7d6c3b2a1f00"),
+							WipeVolume: true,
+							Options:    []types.LuksOption{"--pbkdf=pbkdf2"},
+						},
+					},
+				},
+			}},
+		},
+		{
+			in: in{config: from.Config{
+				Ignition: from.Ignition{Version: from.MaxVersion.String()},
+				Storage: from.Storage{
+					Lvm: from.Lvm{
+						VolumeGroups: []from.VolumeGroup{
+							{
+								Name:            "data",
+								Devices:         []from.Device{"/dev/sdb", "/dev/sdc"},
+								Options:         []from.VolumeGroupOption{"--physicalextentsize=8m"},
+								WipeVolumeGroup: true,
+								LogicalVolumes: []from.LogicalVolume{
+									{
+										Name:              "root",
+										SizeMiB:           intToPtr(1024),
+										Stripes:           intToPtr(2),
+										Options:           []from.LogicalVolumeOption{"--zero=y"},
+										WipeLogicalVolume: true,
+									},
+									{
+										Name:        "pool",
+										PercentFree: intToPtr(80),
+										ThinPool:    true,
+									},
+									{
+										Name:    "thin",
+										Pool:    strToPtr("pool"),
+										SizeMiB: intToPtr(4096),
+									},
+								},
+							},
+						},
+					},
+				},
+			}},
+			out: out{config: types.Config{
+				Ignition: types.Ignition{Version: types.MaxVersion.String()},
+				Storage: types.Storage{
+					Lvm: types.Lvm{
+						VolumeGroups: []types.VolumeGroup{
+							{
+								Name:            "data",
+								Devices:         []types.Device{"/dev/sdb", "/dev/sdc"},
+								Options:         []types.VolumeGroupOption{"--physicalextentsize=8m"},
+								WipeVolumeGroup: true,
+								LogicalVolumes: []types.LogicalVolume{
+									{
+										Name:              "root",
+										SizeMiB:           intToPtr(1024),
+										Stripes:           intToPtr(2),
+										Options:           []types.LogicalVolumeOption{"--zero=y"},
+										WipeLogicalVolume: true,
+									},
+									{
+										Name:        "pool",
+										PercentFree: intToPtr(80),
+										ThinPool:    true,
+									},
+									{
+										Name:    "thin",
+										Pool:    strToPtr("pool"),
+										SizeMiB: intToPtr(4096),
+									},
+								},
+							},
 						},
 					},
 				},
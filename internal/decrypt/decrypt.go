@@ -0,0 +1,144 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package decrypt decrypts resources encrypted to a private key, either as
+// age files (https://age-encryption.org/v1) with X25519 recipients or as JWEs
+// (RFC 7516) in compact serialization.
+package decrypt
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io"
+	"io/ioutil"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	jose "gopkg.in/go-jose/go-jose.v2"
+)
+
+var (
+	ErrUnknownEnvelope  = errors.New("resource is neither an age file nor a JWE")
+	ErrMalformed        = errors.New("malformed encrypted resource")
+	ErrNoKey            = errors.New("no usable private key found")
+	ErrDecryptionFailed = errors.New("failed to decrypt resource")
+	ErrUnsupportedAlg   = errors.New("unsupported JWE key management algorithm")
+)
+
+const ageIntro = "age-encryption.org/v1\n"
+
+// jweAlgorithms maps the supported JWE key management algorithms to whether
+// they take an RSA key rather than an EC key.
+var jweAlgorithms = map[string]bool{
+	string(jose.RSA_OAEP):     true,
+	string(jose.RSA_OAEP_256): true,
+	string(jose.ECDH_ES):      false,
+}
+
+// Decrypt decrypts envelope, which is an age file (binary or armored) or a
+// compact JWE, with the private keys in key. For age files, key holds age
+// identities ("AGE-SECRET-KEY-1..." lines), for JWEs a PEM encoded RSA or EC
+// private key.
+func Decrypt(envelope, key []byte) ([]byte, error) {
+	if armored := bytes.TrimSpace(envelope); bytes.HasPrefix(armored, []byte(armor.Header)) {
+		return decryptAge(armor.NewReader(bytes.NewReader(armored)), key)
+	}
+	if bytes.HasPrefix(envelope, []byte(ageIntro)) {
+		return decryptAge(bytes.NewReader(envelope), key)
+	}
+
+	if jwe := bytes.TrimSpace(envelope); bytes.Count(jwe, []byte(".")) == 4 {
+		priv, err := parsePrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		return decryptJWE(string(jwe), priv)
+	}
+
+	return nil, ErrUnknownEnvelope
+}
+
+// decryptAge decrypts the binary age file read from r with one of the
+// identities in key.
+func decryptAge(r io.Reader, key []byte) ([]byte, error) {
+	identities, err := age.ParseIdentities(bytes.NewReader(key))
+	if err != nil {
+		return nil, ErrNoKey
+	}
+	plaintext, err := age.Decrypt(r, identities...)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+	// the payload is authenticated chunk by chunk, so a truncated or
+	// tampered file only fails once it is read
+	b, err := ioutil.ReadAll(plaintext)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+	return b, nil
+}
+
+// parsePrivateKey returns the first RSA or EC private key in the PEM encoded
+// key.
+func parsePrivateKey(key []byte) (interface{}, error) {
+	for {
+		block, rest := pem.Decode(key)
+		if block == nil {
+			return nil, ErrNoKey
+		}
+		key = rest
+		switch block.Type {
+		case "RSA PRIVATE KEY":
+			return x509.ParsePKCS1PrivateKey(block.Bytes)
+		case "EC PRIVATE KEY":
+			return x509.ParseECPrivateKey(block.Bytes)
+		case "PRIVATE KEY":
+			return x509.ParsePKCS8PrivateKey(block.Bytes)
+		}
+	}
+}
+
+// decryptJWE decrypts the compact JWE with key. Compressed plaintexts are
+// limited to ten times the size of the ciphertext, or 250kB.
+func decryptJWE(jwe string, key interface{}) ([]byte, error) {
+	obj, err := jose.ParseEncrypted(jwe)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	isRSA, ok := jweAlgorithms[obj.Header.Algorithm]
+	if !ok {
+		return nil, ErrUnsupportedAlg
+	}
+	switch key.(type) {
+	case *rsa.PrivateKey:
+		if !isRSA {
+			return nil, ErrNoKey
+		}
+	case *ecdsa.PrivateKey:
+		if isRSA {
+			return nil, ErrNoKey
+		}
+	default:
+		return nil, ErrNoKey
+	}
+	plaintext, err := obj.Decrypt(key)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+	return plaintext, nil
+}
@@ -17,6 +17,7 @@ package distro
 import (
 	"fmt"
 	"os"
+	"strings"
 )
 
 // Distro-specific settings that can be overridden at link time with e.g.
@@ -33,19 +34,29 @@ var (
 	systemConfigDir = "/usr/lib/ignition"
 	// initramfs directory to check before retrieving file from OEM partition
 	oemLookasideDir = "/usr/share/oem"
+	// directory for the reports of stages which run before the root
+	// filesystem is mounted
+	runReportDir = "/run/ignition"
 
 	// Helper programs
 	chrootCmd     = "/usr/bin/chroot"
+	cryptsetupCmd = "/usr/sbin/cryptsetup"
 	groupaddCmd   = "/usr/sbin/groupadd"
 	idCmd         = "/usr/bin/id"
+	lvmCmd        = "/usr/sbin/lvm"
 	mdadmCmd      = "/usr/sbin/mdadm"
 	mountCmd      = "/usr/bin/mount"
+	sfdiskCmd     = "/usr/sbin/sfdisk"
 	sgdiskCmd     = "/usr/sbin/sgdisk"
 	udevadmCmd    = "/usr/bin/udevadm"
 	usermodCmd    = "/usr/sbin/usermod"
 	useraddCmd    = "/usr/sbin/useradd"
 	restoreconCmd = "/usr/sbin/restorecon"
 
+	// Decompression tools
+	xzCmd   = "/usr/bin/xz"
+	zstdCmd = "/usr/bin/zstd"
+
 	// Filesystem tools
 	btrfsMkfsCmd = "/usr/sbin/mkfs.btrfs"
 	ext4MkfsCmd  = "/usr/sbin/mkfs.ext4"
@@ -53,9 +64,20 @@ var (
 	vfatMkfsCmd  = "/usr/sbin/mkfs.vfat"
 	xfsMkfsCmd   = "/usr/sbin/mkfs.xfs"
 
+	// Filesystem grow tools
+	btrfsCmd     = "/usr/sbin/btrfs"
+	resize2fsCmd = "/usr/sbin/resize2fs"
+	xfsGrowfsCmd = "/usr/sbin/xfs_growfs"
+
+	// Comma separated, base64 encoded ed25519 public keys trusted to sign
+	// configs
+	signingKeys = ""
+
 	// Flags
 	selinuxRelabel  = "false"
 	blackboxTesting = "false"
+	// Write gpt partition tables with sgdisk instead of in-process
+	useSgdisk = "false"
 )
 
 func DiskByLabelDir() string    { return diskByLabelDir }
@@ -65,26 +87,42 @@ func OEMDevicePath() string     { return fromEnv("OEM_DEVICE", oemDevicePath) }
 func KernelCmdlinePath() string { return kernelCmdlinePath }
 func SystemConfigDir() string   { return fromEnv("SYSTEM_CONFIG_DIR", systemConfigDir) }
 func OEMLookasideDir() string   { return fromEnv("OEM_LOOKASIDE_DIR", oemLookasideDir) }
+func RunReportDir() string      { return fromEnv("RUN_REPORT_DIR", runReportDir) }
 
 func ChrootCmd() string     { return chrootCmd }
+func CryptsetupCmd() string { return cryptsetupCmd }
 func GroupaddCmd() string   { return groupaddCmd }
 func IdCmd() string         { return idCmd }
+func LvmCmd() string        { return lvmCmd }
 func MdadmCmd() string      { return mdadmCmd }
 func MountCmd() string      { return mountCmd }
+func SfdiskCmd() string     { return sfdiskCmd }
 func SgdiskCmd() string     { return sgdiskCmd }
 func UdevadmCmd() string    { return udevadmCmd }
 func UsermodCmd() string    { return usermodCmd }
 func UseraddCmd() string    { return useraddCmd }
 func RestoreconCmd() string { return restoreconCmd }
 
+func XzCmd() string   { return xzCmd }
+func ZstdCmd() string { return zstdCmd }
+
 func BtrfsMkfsCmd() string { return btrfsMkfsCmd }
 func Ext4MkfsCmd() string  { return ext4MkfsCmd }
 func SwapMkfsCmd() string  { return swapMkfsCmd }
 func VfatMkfsCmd() string  { return vfatMkfsCmd }
 func XfsMkfsCmd() string   { return xfsMkfsCmd }
 
+func BtrfsCmd() string     { return btrfsCmd }
+func Resize2fsCmd() string { return resize2fsCmd }
+func XfsGrowfsCmd() string { return xfsGrowfsCmd }
+
+func SigningKeys() []string {
+	return strings.FieldsFunc(signingKeys, func(r rune) bool { return r == ',' })
+}
+
 func SelinuxRelabel() bool  { return bakedStringToBool(selinuxRelabel) }
 func BlackboxTesting() bool { return bakedStringToBool(blackboxTesting) }
+func UseSgdisk() bool       { return bakedStringToBool(useSgdisk) }
 
 func fromEnv(nameSuffix, defaultValue string) string {
 	value := os.Getenv("IGNITION_" + nameSuffix)
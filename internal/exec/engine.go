@@ -15,13 +15,16 @@
 package exec
 
 import (
+	"crypto/ed25519"
 	"crypto/sha512"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/url"
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/flatcar-linux/ignition/config/shared/errors"
@@ -29,13 +32,16 @@ import (
 	"github.com/flatcar-linux/ignition/config/validate/report"
 	"github.com/flatcar-linux/ignition/internal/config"
 	"github.com/flatcar-linux/ignition/internal/config/types"
+	"github.com/flatcar-linux/ignition/internal/distro"
 	"github.com/flatcar-linux/ignition/internal/exec/stages"
 	"github.com/flatcar-linux/ignition/internal/log"
 	"github.com/flatcar-linux/ignition/internal/oem"
+	"github.com/flatcar-linux/ignition/internal/plan"
 	"github.com/flatcar-linux/ignition/internal/providers"
 	"github.com/flatcar-linux/ignition/internal/providers/cmdline"
 	"github.com/flatcar-linux/ignition/internal/providers/system"
 	"github.com/flatcar-linux/ignition/internal/resource"
+	"github.com/flatcar-linux/ignition/internal/summary"
 	"github.com/flatcar-linux/ignition/internal/util"
 )
 
@@ -51,15 +57,29 @@ type Engine struct {
 	Root         string
 	OEMConfig    oem.Config
 	Fetcher      *resource.Fetcher
+	// Plan, if set, puts the engine into dry-run mode. The config is
+	// acquired and rendered as usual, but every operation of the stage
+	// which would modify the system is recorded in Plan instead.
+	Plan *plan.Plan
+	// ReportDir is the directory to which the report of the stage is
+	// written. It defaults to summary.ReportDir.
+	ReportDir string
 }
 
 // Run executes the stage of the given name. It returns true if the stage
-// successfully ran and false if there were any errors.
-func (e Engine) Run(stageName string) error {
+// successfully ran and false if there were any errors. A report of what the
+// stage did is written to ReportDir.
+func (e Engine) Run(stageName string) (err error) {
 	if e.Fetcher == nil || e.Logger == nil {
 		fmt.Fprintf(os.Stderr, "engine incorrectly configured\n")
 		return errors.ErrEngineConfiguration
 	}
+	e.Logger.SetStage(stageName)
+	e.Logger.SetSummary(summary.New(stageName))
+	defer func() {
+		e.writeReport(stageName, err)
+	}()
+
 	baseConfig := types.Config{
 		Ignition: types.Ignition{Version: types.MaxVersion.String()},
 		Storage: types.Storage{
@@ -77,6 +97,11 @@ func (e Engine) Run(stageName string) error {
 		return err
 	}
 
+	if err := e.loadSigningKeys(systemBaseConfig); err != nil {
+		e.Logger.Crit("failed to load trusted signing keys: %v", err)
+		return err
+	}
+
 	cfg, err := e.acquireConfig()
 	switch err {
 	case nil:
@@ -97,20 +122,33 @@ func (e Engine) Run(stageName string) error {
 	defer e.Logger.PopPrefix()
 
 	fullConfig := config.Append(baseConfig, config.Append(systemBaseConfig, cfg))
-	if err = stages.Get(stageName).Create(e.Logger, e.Root, *e.Fetcher).Run(fullConfig); err != nil {
+	if e.Plan != nil {
+		e.Logger.SetPlan(e.Plan)
+		defer e.Logger.SetPlan(nil)
+	}
+	if err = e.runStage(stageName, fullConfig, os.Stderr); err != nil {
+		return err
+	}
+	e.Logger.Info("%s passed", stageName)
+	return nil
+}
+
+// runStage runs the stage of the given name on cfg. If it fails, the config
+// is dumped to stderr with its secrets redacted.
+func (e *Engine) runStage(stageName string, cfg types.Config, stderr io.Writer) error {
+	err := stages.Get(stageName).Create(e.Logger, e.Root, *e.Fetcher).Run(cfg)
+	if err != nil {
 		// e.Logger could be nil
-		fmt.Fprintf(os.Stderr, "%s failed", stageName)
-		tmp, jsonerr := json.MarshalIndent(fullConfig, "", "  ")
+		fmt.Fprintf(stderr, "%s failed", stageName)
+		tmp, jsonerr := json.MarshalIndent(redactConfig(cfg), "", "  ")
 		if jsonerr != nil {
 			// Nothing else to do with this error
-			fmt.Fprintf(os.Stderr, "Could not marshal full config: %v", err)
+			fmt.Fprintf(stderr, "Could not marshal full config: %v", err)
 		} else {
-			fmt.Fprintf(os.Stderr, "Full config:\n%s", string(tmp))
+			fmt.Fprintf(stderr, "Full config:\n%s", string(tmp))
 		}
-		return err
 	}
-	e.Logger.Info("%s passed", stageName)
-	return nil
+	return err
 }
 
 // acquireConfig returns the configuration, first checking a local cache
@@ -120,12 +158,15 @@ func (e *Engine) acquireConfig() (cfg types.Config, err error) {
 	// First try read the config @ e.ConfigCache.
 	b, err := ioutil.ReadFile(e.ConfigCache)
 	if err == nil {
+		hash := sha512.Sum512(b)
+		e.Logger.Summary().AddConfig(e.ConfigCache, hex.EncodeToString(hash[:]))
 		if err = json.Unmarshal(b, &cfg); err != nil {
 			e.Logger.Crit("failed to parse cached config: %v", err)
 		}
+		e.loadClientKey(cfg.Ignition.Security.TLS)
 		// Create an http client and fetcher with the timeouts from the cached
 		// config
-		err = e.Fetcher.UpdateHttpTimeoutsAndCAs(cfg.Ignition.Timeouts, cfg.Ignition.Security.TLS.CertificateAuthorities, cfg.Ignition.Proxy)
+		err = e.Fetcher.UpdateHttpTimeoutsAndCAs(cfg.Ignition.Timeouts, cfg.Ignition.Security.TLS, cfg.Ignition.Proxy)
 		if err != nil {
 			e.Logger.Crit("failed to update timeouts and CAs for fetcher: %v", err)
 			return
@@ -137,7 +178,7 @@ func (e *Engine) acquireConfig() (cfg types.Config, err error) {
 	// since we don't have a config with timeout values we can use
 	timeout := int(e.FetchTimeout.Seconds())
 	emptyProxy := types.Proxy{}
-	err = e.Fetcher.UpdateHttpTimeoutsAndCAs(types.Timeouts{HTTPTotal: &timeout}, nil, emptyProxy)
+	err = e.Fetcher.UpdateHttpTimeoutsAndCAs(types.Timeouts{HTTPTotal: &timeout}, types.TLS{}, emptyProxy)
 	if err != nil {
 		e.Logger.Crit("failed to update timeouts and CAs for fetcher: %v", err)
 		return
@@ -152,19 +193,22 @@ func (e *Engine) acquireConfig() (cfg types.Config, err error) {
 
 	// Update the http client to use the timeouts and CAs from the newly fetched
 	// config
-	err = e.Fetcher.UpdateHttpTimeoutsAndCAs(cfg.Ignition.Timeouts, cfg.Ignition.Security.TLS.CertificateAuthorities, cfg.Ignition.Proxy)
+	err = e.Fetcher.UpdateHttpTimeoutsAndCAs(cfg.Ignition.Timeouts, cfg.Ignition.Security.TLS, cfg.Ignition.Proxy)
 	if err != nil {
 		e.Logger.Crit("failed to update timeouts and CAs for fetcher: %v", err)
 		return
 	}
 
-	err = e.Fetcher.RewriteCAsWithDataUrls(cfg.Ignition.Security.TLS.CertificateAuthorities)
+	err = e.Fetcher.RewriteCAsWithDataUrls(&cfg.Ignition.Security.TLS)
 	if err != nil {
 		e.Logger.Crit("error handling CAs: %v", err)
 		return
 	}
 
-	// Populate the config cache.
+	// Populate the config cache, unless this is a dry run.
+	if e.Plan != nil {
+		return
+	}
 	b, err = json.Marshal(cfg)
 	if err != nil {
 		e.Logger.Crit("failed to marshal cached config: %v", err)
@@ -174,10 +218,122 @@ func (e *Engine) acquireConfig() (cfg types.Config, err error) {
 		e.Logger.Crit("failed to write cached config: %v", err)
 		return
 	}
+	if err = e.storeClientKey(cfg.Ignition.Security.TLS); err != nil {
+		e.Logger.Crit("failed to write cached client key: %v", err)
+		return
+	}
 
 	return
 }
 
+// ClientKeyCachePath returns the path of the file next to the config cache
+// at configCache in which the client key of the cached config is kept.
+func ClientKeyCachePath(configCache string) string {
+	return configCache + ".client-key"
+}
+
+// storeClientKey writes the client key referenced by tlsCfg, if any, to a
+// file only readable by root next to the config cache, so that later stages
+// can use it without fetching it again.
+func (e *Engine) storeClientKey(tlsCfg types.TLS) error {
+	key, err := e.Fetcher.ClientKey(tlsCfg)
+	if err != nil || key == nil {
+		return err
+	}
+	path := ClientKeyCachePath(e.ConfigCache)
+	// don't inherit the mode of an existing file
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(key); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// loadClientKey makes the fetcher use the client key stored by an earlier
+// stage. Without one, the key is fetched again from its reference.
+func (e *Engine) loadClientKey(tlsCfg types.TLS) {
+	if tlsCfg.ClientKey == nil {
+		return
+	}
+	key, err := ioutil.ReadFile(ClientKeyCachePath(e.ConfigCache))
+	if err != nil {
+		e.Logger.Info("no cached client key, fetching it again: %v", err)
+		return
+	}
+	if err := e.Fetcher.AddClientKey(*tlsCfg.ClientKey, key); err != nil {
+		e.Logger.Err("failed to use cached client key: %v", err)
+	}
+}
+
+// redactTLS returns tlsCfg with the sources and headers of all references
+// replaced, so that it can be logged without leaking the client key.
+func redactTLS(tlsCfg types.TLS) types.TLS {
+	redact := func(ref types.CaReference) types.CaReference {
+		ref.Source = "[redacted]"
+		ref.HTTPHeaders = nil
+		return ref
+	}
+	out := types.TLS{}
+	for _, ca := range tlsCfg.CertificateAuthorities {
+		out.CertificateAuthorities = append(out.CertificateAuthorities, redact(ca))
+	}
+	if tlsCfg.ClientCertificate != nil {
+		cert := redact(*tlsCfg.ClientCertificate)
+		out.ClientCertificate = &cert
+	}
+	if tlsCfg.ClientKey != nil {
+		key := redact(*tlsCfg.ClientKey)
+		out.ClientKey = &key
+	}
+	return out
+}
+
+// redactConfig returns a copy of cfg which is safe to print: the TLS
+// references are redacted with redactTLS and the values of all other HTTP
+// headers, which carry tokens and API keys, are replaced.
+func redactConfig(cfg types.Config) types.Config {
+	cfg.Ignition.Security.TLS = redactTLS(cfg.Ignition.Security.TLS)
+
+	cfg.Ignition.Config.Append = append([]types.ConfigReference(nil), cfg.Ignition.Config.Append...)
+	for i := range cfg.Ignition.Config.Append {
+		cfg.Ignition.Config.Append[i].HTTPHeaders = redactHeaders(cfg.Ignition.Config.Append[i].HTTPHeaders)
+	}
+	if cfg.Ignition.Config.Replace != nil {
+		replace := *cfg.Ignition.Config.Replace
+		replace.HTTPHeaders = redactHeaders(replace.HTTPHeaders)
+		cfg.Ignition.Config.Replace = &replace
+	}
+
+	cfg.Storage.Files = append([]types.File(nil), cfg.Storage.Files...)
+	for i := range cfg.Storage.Files {
+		cfg.Storage.Files[i].Contents.HTTPHeaders = redactHeaders(cfg.Storage.Files[i].Contents.HTTPHeaders)
+	}
+	cfg.Storage.Luks = append([]types.Luks(nil), cfg.Storage.Luks...)
+	for i := range cfg.Storage.Luks {
+		cfg.Storage.Luks[i].KeyFile.HTTPHeaders = redactHeaders(cfg.Storage.Luks[i].KeyFile.HTTPHeaders)
+	}
+	return cfg
+}
+
+// redactHeaders returns a copy of headers with the values replaced.
+func redactHeaders(headers types.HTTPHeaders) types.HTTPHeaders {
+	if headers == nil {
+		return nil
+	}
+	out := make(types.HTTPHeaders, len(headers))
+	for i, h := range headers {
+		out[i] = types.HTTPHeadersItem{Name: h.Name, Value: "[redacted]"}
+	}
+	return out
+}
+
 // fetchProviderConfig returns the externally-provided configuration. It first
 // checks to see if the command-line option is present. If so, it uses that
 // source for the configuration. If the command-line option is not present, it
@@ -210,7 +366,7 @@ func (e *Engine) fetchProviderConfig() (types.Config, error) {
 
 	// Replace the HTTP client in the fetcher to be configured with the
 	// timeouts of the config
-	err = e.Fetcher.UpdateHttpTimeoutsAndCAs(cfg.Ignition.Timeouts, cfg.Ignition.Security.TLS.CertificateAuthorities, cfg.Ignition.Proxy)
+	err = e.Fetcher.UpdateHttpTimeoutsAndCAs(cfg.Ignition.Timeouts, cfg.Ignition.Security.TLS, cfg.Ignition.Proxy)
 	if err != nil {
 		return types.Config{}, err
 	}
@@ -234,7 +390,7 @@ func (e *Engine) renderConfig(cfg types.Config) (types.Config, error) {
 
 		// Replace the HTTP client in the fetcher to be configured with the
 		// timeouts of the new config
-		err = e.Fetcher.UpdateHttpTimeoutsAndCAs(newCfg.Ignition.Timeouts, newCfg.Ignition.Security.TLS.CertificateAuthorities, newCfg.Ignition.Proxy)
+		err = e.Fetcher.UpdateHttpTimeoutsAndCAs(newCfg.Ignition.Timeouts, newCfg.Ignition.Security.TLS, newCfg.Ignition.Proxy)
 		if err != nil {
 			return types.Config{}, err
 		}
@@ -253,7 +409,7 @@ func (e *Engine) renderConfig(cfg types.Config) (types.Config, error) {
 		// been rendered, so we can use the new config's timeouts and CAs when
 		// fetching more configs.
 		cfgForFetcherSettings := config.Append(appendedCfg, newCfg)
-		err = e.Fetcher.UpdateHttpTimeoutsAndCAs(cfgForFetcherSettings.Ignition.Timeouts, cfgForFetcherSettings.Ignition.Security.TLS.CertificateAuthorities, cfgForFetcherSettings.Ignition.Proxy)
+		err = e.Fetcher.UpdateHttpTimeoutsAndCAs(cfgForFetcherSettings.Ignition.Timeouts, cfgForFetcherSettings.Ignition.Security.TLS, cfgForFetcherSettings.Ignition.Proxy)
 		if err != nil {
 			return types.Config{}, err
 		}
@@ -274,9 +430,27 @@ func (e *Engine) fetchReferencedConfig(cfgRef types.ConfigReference) (types.Conf
 	if err != nil {
 		return types.Config{}, err
 	}
-	rawCfg, err := e.Fetcher.FetchToBuffer(*u, resource.FetchOptions{
-		Headers: resource.ConfigHeaders,
-	})
+	opts := resource.FetchOptions{
+		Headers: resource.MergeHeaders(resource.ConfigHeaders, cfgRef.HTTPHeaders),
+	}
+	if cfgRef.Decryption != nil {
+		opts.DecryptionKey = cfgRef.Decryption.Key
+	}
+	// pass the expected sum along so the config can come from the download
+	// cache
+	opts.Hash, err = util.GetHasher(cfgRef.Verification)
+	if err != nil {
+		return types.Config{}, err
+	}
+	if opts.Hash != nil {
+		// explicitly ignoring the error here because the config should already
+		// be validated by this point
+		_, sum, _ := util.HashParts(cfgRef.Verification)
+		if opts.ExpectedSum, err = hex.DecodeString(sum); err != nil {
+			return types.Config{}, err
+		}
+	}
+	rawCfg, err := e.Fetcher.FetchToBuffer(*u, opts)
 	if err != nil {
 		return types.Config{}, err
 	}
@@ -292,6 +466,31 @@ func (e *Engine) fetchReferencedConfig(cfgRef types.ConfigReference) (types.Conf
 	if err := util.AssertValid(cfgRef.Verification, rawCfg); err != nil {
 		return types.Config{}, err
 	}
+	// the referencing config has been verified, if signing keys are trusted,
+	// so data urls and configs whose hash it pins are covered by its
+	// signature
+	blob, embedded, isEmbedded := resource.SplitSignedConfig(rawCfg)
+	if isEmbedded {
+		rawCfg = blob
+	}
+	if sig := cfgRef.Verification.Signature; sig != nil {
+		sigURL, err := url.Parse(*sig)
+		if err != nil {
+			return types.Config{}, err
+		}
+		if err := e.Fetcher.VerifySignature(rawCfg, *sigURL, opts.Headers); err != nil {
+			return types.Config{}, err
+		}
+	} else if isEmbedded {
+		if err := e.Fetcher.CheckSignature(rawCfg, embedded); err != nil {
+			return types.Config{}, err
+		}
+	} else if u.Scheme != "data" && opts.Hash == nil {
+		if err := e.Fetcher.VerifyConfigSignature(cfgRef.Source, rawCfg, opts.Headers); err != nil {
+			return types.Config{}, err
+		}
+	}
+	e.Logger.Summary().AddConfig(cfgRef.Source, hex.EncodeToString(hash[:]))
 
 	cfg, r, err := config.Parse(rawCfg)
 	e.logReport(r)
@@ -302,9 +501,32 @@ func (e *Engine) fetchReferencedConfig(cfgRef types.ConfigReference) (types.Conf
 	return cfg, nil
 }
 
+// loadSigningKeys sets up the fetcher with the keys trusted to sign configs.
+// Only keys baked into the distro or the system base config are trusted, a
+// config can't vouch for itself.
+func (e *Engine) loadSigningKeys(baseConfig types.Config) error {
+	encoded := distro.SigningKeys()
+	for _, key := range baseConfig.Ignition.Security.Signing.TrustedKeys {
+		encoded = append(encoded, string(key))
+	}
+	keys := make([]ed25519.PublicKey, 0, len(encoded))
+	for _, key := range encoded {
+		pub, err := util.ParseSigningKey(key)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, pub)
+	}
+	e.Fetcher.SigningKeys = keys
+	return nil
+}
+
 func (e Engine) logReport(r report.Report) {
 	for _, entry := range r.Entries {
 		entry.Highlight = "" // might contain secrets, don't log when Ignition runs
+		if entry.Kind == report.EntryWarning || entry.Kind == report.EntryDeprecated {
+			e.Logger.Summary().AddWarning(entry.String())
+		}
 		switch entry.Kind {
 		case report.EntryError:
 			e.Logger.Crit("%v", entry)
@@ -317,3 +539,24 @@ func (e Engine) logReport(r report.Report) {
 		}
 	}
 }
+
+// writeReport records the result of the stage in the summary and writes it to
+// the report directory, unless this is a dry run.
+func (e Engine) writeReport(stageName string, err error) {
+	if e.Plan != nil {
+		return
+	}
+	sum := e.Logger.Summary()
+	sum.Finish(err)
+
+	dir := e.ReportDir
+	if dir == "" {
+		dir = summary.ReportDir(e.Root, stageName)
+	}
+	path := filepath.Join(dir, summary.ReportName(stageName))
+	if err := sum.WriteFile(path); err != nil {
+		e.Logger.Err("failed to write report %q: %v", path, err)
+		return
+	}
+	e.Logger.Info("wrote report to %q", path)
+}
@@ -73,6 +73,8 @@ func (s stage) Run(config types.Config) error {
 	// filesystems is 1.
 	if len(config.Storage.Disks) == 0 &&
 		len(config.Storage.Raid) == 0 &&
+		len(config.Storage.Lvm.VolumeGroups) == 0 &&
+		len(config.Storage.Luks) == 0 &&
 		len(config.Storage.Filesystems) == 1 {
 		return nil
 	}
@@ -85,6 +87,14 @@ func (s stage) Run(config types.Config) error {
 		return fmt.Errorf("failed to create raids: %v", err)
 	}
 
+	if err := s.createLvm(config); err != nil {
+		return fmt.Errorf("failed to create lvm volumes: %v", err)
+	}
+
+	if err := s.createLuks(config); err != nil {
+		return fmt.Errorf("failed to create luks volumes: %v", err)
+	}
+
 	if err := s.createFilesystems(config); err != nil {
 		return fmt.Errorf("failed to create filesystems: %v", err)
 	}
@@ -126,6 +136,11 @@ func (s stage) Run(config types.Config) error {
 // waitOnDevices waits for the devices enumerated in devs as a logged operation
 // using ctxt for the logging and systemd unit identity.
 func (s stage) waitOnDevices(devs []string, ctxt string) error {
+	if s.DryRun() {
+		s.Logger.Info("dry run: not waiting for devices %v", devs)
+		return nil
+	}
+
 	if err := s.LogOp(
 		func() error { return systemd.WaitOnDevices(devs, ctxt) },
 		"waiting for devices %v", devs,
@@ -138,6 +153,11 @@ func (s stage) waitOnDevices(devs []string, ctxt string) error {
 
 // createDeviceAliases creates device aliases for every device in devs.
 func (s stage) createDeviceAliases(devs []string) error {
+	if s.DryRun() {
+		s.Logger.Info("dry run: not creating device aliases for %v", devs)
+		return nil
+	}
+
 	for _, dev := range devs {
 		target, err := util.CreateDeviceAlias(dev)
 		if err != nil {
@@ -0,0 +1,121 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package disks
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/flatcar-linux/ignition/internal/config"
+	"github.com/flatcar-linux/ignition/internal/exec/util"
+	"github.com/flatcar-linux/ignition/internal/log"
+	"github.com/flatcar-linux/ignition/internal/plan"
+	"github.com/flatcar-linux/ignition/internal/resource"
+)
+
+func TestDryRun(t *testing.T) {
+	image, err := ioutil.TempFile("", "ignition-disks-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(image.Name())
+	if err := image.Truncate(64 * 1024 * 1024); err != nil {
+		t.Fatal(err)
+	}
+	image.Close()
+	sum := func() [sha256.Size]byte {
+		b, err := ioutil.ReadFile(image.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sha256.Sum256(b)
+	}
+
+	cfg, _, err := config.Parse([]byte(fmt.Sprintf(`{
+		"ignition": {"version": "2.4.0-experimental"},
+		"storage": {
+			"disks": [{
+				"device": %q,
+				"wipeTable": true,
+				"partitions": [{"number": 1, "label": "DATA", "sizeMiB": 16}]
+			}],
+			"filesystems": [{"name": "data", "mount": {"device": "/dev/disk/by-partlabel/DATA", "format": "ext4", "wipeFilesystem": true}}]
+		}
+	}`, image.Name())))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before := sum()
+	logger := log.New(true)
+	defer logger.Close()
+	p := &plan.Plan{}
+	logger.SetPlan(p)
+	s := creator{}.Create(&logger, "/nonexistent", resource.Fetcher{Logger: &logger})
+	if err := s.Run(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if sum() != before {
+		t.Error("dry run modified the disk")
+	}
+	if _, err := os.Lstat(util.DeviceAlias(image.Name())); !os.IsNotExist(err) {
+		t.Errorf("dry run created a device alias: %v", err)
+	}
+
+	var text bytes.Buffer
+	if err := p.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"deleting 0 partitions and creating 1 partitions",
+		`creating "ext4" filesystem on "/run/ignition/dev_aliases/dev/disk/by-partlabel/DATA"`,
+		`mkfs.ext4" "-F"`,
+		`udevadm" "settle"`,
+	} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("text plan lacks %q:\n%s", want, text.String())
+		}
+	}
+
+	var doc struct {
+		Entries []plan.Entry `json:"entries"`
+	}
+	var js bytes.Buffer
+	if err := p.WriteJSON(&js); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(js.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Entries) != len(p.Entries()) || len(doc.Entries) == 0 {
+		t.Fatalf("JSON plan has %d entries, want %d", len(doc.Entries), len(p.Entries()))
+	}
+	mkfs := false
+	for _, e := range doc.Entries {
+		if len(e.Command) > 0 && strings.HasSuffix(e.Command[0], "mkfs.ext4") {
+			mkfs = true
+		}
+	}
+	if !mkfs {
+		t.Errorf("JSON plan lacks the mkfs command: %s", js.String())
+	}
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/flatcar-linux/ignition/internal/config/types"
 	"github.com/flatcar-linux/ignition/internal/distro"
 	"github.com/flatcar-linux/ignition/internal/exec/util"
+	"github.com/flatcar-linux/ignition/internal/summary"
 )
 
 var (
@@ -92,9 +93,13 @@ func (s stage) createFilesystems(config types.Config) error {
 }
 
 func (s stage) createFilesystem(fs types.Mount) error {
-	info, err := s.readFilesystemInfo(fs)
+	info, err := s.readFilesystemInfo(fs.Device)
 	if err != nil {
-		return err
+		if !s.DryRun() {
+			return err
+		}
+		s.Logger.Warning("assuming no filesystem on %q: %v", fs.Device, err)
+		info = filesystemInfo{}
 	}
 
 	if fs.Create != nil {
@@ -112,6 +117,7 @@ func (s stage) createFilesystem(fs types.Mount) error {
 			(fs.Label == nil || info.label == *fs.Label) &&
 			(fs.UUID == nil || canonicalizeFilesystemUUID(info.format, info.uuid) == canonicalizeFilesystemUUID(fs.Format, *fs.UUID)) {
 			s.Logger.Info("filesystem at %q is already correctly formatted. Skipping mkfs...", fs.Device)
+			s.Logger.Summary().Add(summary.Filesystem, string(fs.Device), summary.Skipped, info.format)
 			return nil
 		} else if info.format != "" {
 			s.Logger.Err("filesystem at %q is not of the correct type, label, or UUID (found %s, %q, %s) and a filesystem wipe was not requested", fs.Device, info.format, info.label, info.uuid)
@@ -186,6 +192,7 @@ func (s stage) createFilesystem(fs types.Mount) error {
 	); err != nil {
 		return fmt.Errorf("mkfs failed: %v", err)
 	}
+	s.Logger.Summary().Add(summary.Filesystem, string(fs.Device), summary.Created, fs.Format)
 
 	return nil
 }
@@ -214,27 +221,27 @@ type filesystemInfo struct {
 	label  string
 }
 
-func (s stage) readFilesystemInfo(fs types.Mount) (filesystemInfo, error) {
+func (s stage) readFilesystemInfo(device string) (filesystemInfo, error) {
 	res := filesystemInfo{}
 	err := s.Logger.LogOp(
 		func() error {
 			var err error
-			res.format, err = util.FilesystemType(fs.Device)
+			res.format, err = util.FilesystemType(device)
 			if err != nil {
 				return err
 			}
-			res.uuid, err = util.FilesystemUUID(fs.Device)
+			res.uuid, err = util.FilesystemUUID(device)
 			if err != nil {
 				return err
 			}
-			res.label, err = util.FilesystemLabel(fs.Device)
+			res.label, err = util.FilesystemLabel(device)
 			if err != nil {
 				return err
 			}
-			s.Logger.Info("found %s filesystem at %q with uuid %q and label %q", res.format, fs.Device, res.uuid, res.label)
+			s.Logger.Info("found %s filesystem at %q with uuid %q and label %q", res.format, device, res.uuid, res.label)
 			return nil
 		},
-		"determining filesystem type of %q", fs.Device,
+		"determining filesystem type of %q", device,
 	)
 
 	return res, err
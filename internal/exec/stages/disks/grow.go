@@ -0,0 +1,96 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package disks
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/flatcar-linux/ignition/internal/config/types"
+	"github.com/flatcar-linux/ignition/internal/distro"
+	"github.com/flatcar-linux/ignition/internal/summary"
+)
+
+// growFilesystem grows the filesystem on the partition part, which was just
+// grown, to fill the partition. Filesystems are grown while mounted, since
+// that's the only way for xfs and btrfs and spares ext4 a forced fsck.
+func (s stage) growFilesystem(part types.Partition) error {
+	dev := filepath.Join(distro.DiskByPartUUIDDir(), strings.ToLower(part.GUID))
+
+	// the partition's symlinks are recreated once udev has seen the new table
+	if _, err := s.Logger.LogCmd(
+		exec.Command(distro.UdevadmCmd(), "settle"),
+		"waiting for udev to settle",
+	); err != nil {
+		return fmt.Errorf("udevadm settle failed: %v", err)
+	}
+	if err := s.waitOnDevices([]string{dev}, "resize"); err != nil {
+		return err
+	}
+
+	info, err := s.readFilesystemInfo(dev)
+	if err != nil {
+		if !s.DryRun() {
+			return err
+		}
+		s.Logger.Warning("assuming no filesystem on %q: %v", dev, err)
+		return nil
+	}
+
+	var cmd *exec.Cmd
+	mnt, err := ioutil.TempDir("", "ignition-grow")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %v", err)
+	}
+	defer os.Remove(mnt)
+
+	switch info.format {
+	case "ext4":
+		cmd = exec.Command(distro.Resize2fsCmd(), dev)
+	case "xfs":
+		cmd = exec.Command(distro.XfsGrowfsCmd(), mnt)
+	case "btrfs":
+		cmd = exec.Command(distro.BtrfsCmd(), "filesystem", "resize", "max", mnt)
+	case "":
+		s.Logger.Info("no filesystem found on %q, nothing to grow", dev)
+		return nil
+	default:
+		s.Logger.Warning("not growing %s filesystem on %q: only ext4, xfs and btrfs can be grown", info.format, dev)
+		return nil
+	}
+
+	if err := s.Logger.LogChange(
+		func() error { return syscall.Mount(dev, mnt, info.format, 0, "") },
+		"mounting %q at %q", dev, mnt,
+	); err != nil {
+		return err
+	}
+	defer s.Logger.LogChange(
+		func() error { return syscall.Unmount(mnt, 0) },
+		"unmounting %q at %q", dev, mnt,
+	)
+
+	if _, err := s.Logger.LogCmd(cmd, "growing %s filesystem on %q", info.format, dev); err != nil {
+		return fmt.Errorf("growing filesystem failed: %v", err)
+	}
+	s.Logger.Summary().Add(summary.Filesystem, dev, summary.Modified, info.format)
+
+	return nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package disks
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/flatcar-linux/ignition/internal/config/types"
+	"github.com/flatcar-linux/ignition/internal/distro"
+	"github.com/flatcar-linux/ignition/internal/exec/util"
+	"github.com/flatcar-linux/ignition/internal/summary"
+)
+
+var (
+	ErrBadVolume = errors.New("volume is not a matching LUKS volume")
+)
+
+// createLuks creates and opens the LUKS volumes described in
+// config.Storage.Luks, so that filesystems can be created on
+// /dev/mapper/<name>.
+func (s stage) createLuks(config types.Config) error {
+	if len(config.Storage.Luks) == 0 {
+		return nil
+	}
+	s.Logger.PushPrefix("createLuks")
+	defer s.Logger.PopPrefix()
+
+	devs := []string{}
+	for _, luks := range config.Storage.Luks {
+		devs = append(devs, luks.Device)
+	}
+
+	if err := s.waitOnDevicesAndCreateAliases(devs, "luks"); err != nil {
+		return err
+	}
+
+	for _, luks := range config.Storage.Luks {
+		if err := s.createLuksVolume(luks); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s stage) createLuksVolume(luks types.Luks) error {
+	key, err := s.FetchKeyFile(luks.KeyFile)
+	if err != nil {
+		s.Logger.Crit("failed to fetch key file for %q: %v", luks.Name, err)
+		return err
+	}
+
+	info, err := s.readFilesystemInfo(luks.Device)
+	if err != nil {
+		if !s.DryRun() {
+			return err
+		}
+		s.Logger.Warning("assuming no LUKS volume on %q: %v", luks.Device, err)
+		info = filesystemInfo{}
+	}
+
+	devAlias := util.DeviceAlias(luks.Device)
+	if !luks.WipeVolume && info.format != "" {
+		if info.format != "crypto_LUKS" ||
+			(luks.Label != nil && info.label != *luks.Label) ||
+			(luks.UUID != nil && *luks.UUID != "" && !strings.EqualFold(info.uuid, *luks.UUID)) {
+			s.Logger.Err("volume at %q is not of the correct type, label, or UUID (found %s, %q, %s) and a volume wipe was not requested", luks.Device, info.format, info.label, info.uuid)
+			return ErrBadVolume
+		}
+		s.Logger.Info("LUKS volume at %q already exists. Skipping luksFormat...", luks.Device)
+		s.Logger.Summary().Add(summary.Luks, luks.Name, summary.Skipped, luks.Device)
+	} else {
+		args := []string{"luksFormat", "--type", "luks2", "--batch-mode", "--key-file", "-"}
+		if luks.Cipher != nil {
+			args = append(args, "--cipher", *luks.Cipher)
+		}
+		if luks.Label != nil {
+			args = append(args, "--label", *luks.Label)
+		}
+		if luks.UUID != nil && *luks.UUID != "" {
+			args = append(args, "--uuid", *luks.UUID)
+		}
+		for _, o := range luks.Options {
+			args = append(args, string(o))
+		}
+		args = append(args, devAlias)
+
+		// the key is passed on stdin so it doesn't show up in logs or plans
+		cmd := exec.Command(distro.CryptsetupCmd(), args...)
+		cmd.Stdin = bytes.NewReader(key)
+		if _, err := s.Logger.LogCmd(cmd, "formatting LUKS volume %q on %q", luks.Name, devAlias); err != nil {
+			return fmt.Errorf("cryptsetup luksFormat failed: %v", err)
+		}
+		s.Logger.Summary().Add(summary.Luks, luks.Name, summary.Created, luks.Device)
+	}
+
+	if !s.DryRun() {
+		if _, err := os.Stat(filepath.Join("/dev/mapper", luks.Name)); err == nil {
+			s.Logger.Info("LUKS volume %q is already open", luks.Name)
+			return nil
+		}
+	}
+	cmd := exec.Command(distro.CryptsetupCmd(), "luksOpen", "--key-file", "-", devAlias, luks.Name)
+	cmd.Stdin = bytes.NewReader(key)
+	if _, err := s.Logger.LogCmd(cmd, "opening LUKS volume %q", luks.Name); err != nil {
+		return fmt.Errorf("cryptsetup luksOpen failed: %v", err)
+	}
+
+	return nil
+}
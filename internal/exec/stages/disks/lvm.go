@@ -0,0 +1,360 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package disks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/flatcar-linux/ignition/internal/config/types"
+	"github.com/flatcar-linux/ignition/internal/distro"
+	"github.com/flatcar-linux/ignition/internal/exec/util"
+	"github.com/flatcar-linux/ignition/internal/summary"
+)
+
+var (
+	ErrBadVolumeGroup   = errors.New("volume group does not consist of the configured devices")
+	ErrBadLogicalVolume = errors.New("logical volume does not match its configuration")
+)
+
+// logicalVolumeInfo is what lvs reports about an existing logical volume.
+type logicalVolumeInfo struct {
+	sizeMiB       float64
+	extentSizeMiB float64
+	stripes       int
+	segtype       string
+	pool          string
+}
+
+// createLvm creates the volume groups and logical volumes described in
+// config.Storage.Lvm, so that filesystems can be created on
+// /dev/<volume group>/<logical volume>.
+func (s stage) createLvm(config types.Config) error {
+	if len(config.Storage.Lvm.VolumeGroups) == 0 {
+		return nil
+	}
+	s.Logger.PushPrefix("createLvm")
+	defer s.Logger.PopPrefix()
+
+	devs := []string{}
+	for _, vg := range config.Storage.Lvm.VolumeGroups {
+		for _, dev := range vg.Devices {
+			devs = append(devs, string(dev))
+		}
+	}
+
+	if err := s.waitOnDevicesAndCreateAliases(devs, "lvm"); err != nil {
+		return err
+	}
+
+	pvs, err := s.readPhysicalVolumes()
+	if err != nil {
+		if !s.DryRun() {
+			return err
+		}
+		s.Logger.Warning("assuming no existing volume groups: %v", err)
+		pvs = map[string][]string{}
+	}
+
+	for _, vg := range config.Storage.Lvm.VolumeGroups {
+		if err := s.createVolumeGroup(vg, pvs[vg.Name]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// createVolumeGroup creates vg, unless a volume group of the same name already
+// consists of exactly the configured devices. existing lists the physical
+// volumes the volume group currently has.
+func (s stage) createVolumeGroup(vg types.VolumeGroup, existing []string) error {
+	devAliases := []string{}
+	for _, dev := range vg.Devices {
+		devAliases = append(devAliases, util.DeviceAlias(string(dev)))
+	}
+
+	exists := len(existing) > 0
+	if exists && !sameDevices(devAliases, existing) {
+		if !vg.WipeVolumeGroup {
+			s.Logger.Err("volume group %q consists of %v instead of %v and a wipe was not requested", vg.Name, existing, vg.Devices)
+			return ErrBadVolumeGroup
+		}
+		if _, err := s.Logger.LogCmd(
+			exec.Command(distro.LvmCmd(), "vgremove", "--yes", "--force", vg.Name),
+			"removing volume group %q", vg.Name,
+		); err != nil {
+			return fmt.Errorf("vgremove failed: %v", err)
+		}
+		exists = false
+	}
+
+	lvs := map[string]logicalVolumeInfo{}
+	if exists {
+		s.Logger.Info("volume group %q already exists. Skipping vgcreate...", vg.Name)
+		s.Logger.Summary().Add(summary.Lvm, vg.Name, summary.Skipped, strings.Join(existing, ","))
+
+		// the initramfs doesn't necessarily activate existing volume
+		// groups, and their logical volumes must be active to be used
+		if _, err := s.Logger.LogCmd(
+			exec.Command(distro.LvmCmd(), "vgchange", "--activate", "y", vg.Name),
+			"activating volume group %q", vg.Name,
+		); err != nil {
+			return fmt.Errorf("vgchange failed: %v", err)
+		}
+
+		var err error
+		lvs, err = s.readLogicalVolumes(vg.Name)
+		if err != nil {
+			return err
+		}
+	} else {
+		// without --yes, vgcreate refuses to overwrite existing signatures
+		args := []string{"vgcreate"}
+		if vg.WipeVolumeGroup {
+			args = append(args, "--yes")
+		}
+		for _, o := range vg.Options {
+			args = append(args, string(o))
+		}
+		args = append(args, vg.Name)
+		args = append(args, devAliases...)
+
+		if _, err := s.Logger.LogCmd(
+			exec.Command(distro.LvmCmd(), args...),
+			"creating volume group %q", vg.Name,
+		); err != nil {
+			return fmt.Errorf("vgcreate failed: %v", err)
+		}
+		s.Logger.Summary().Add(summary.Lvm, vg.Name, summary.Created, "")
+	}
+
+	for _, lv := range vg.LogicalVolumes {
+		info, ok := lvs[lv.Name]
+		var infoPtr *logicalVolumeInfo
+		if ok {
+			infoPtr = &info
+		}
+		if err := s.createLogicalVolume(vg.Name, lv, infoPtr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// createLogicalVolume creates lv in the volume group vgName. info describes
+// the existing logical volume of the same name, if any.
+func (s stage) createLogicalVolume(vgName string, lv types.LogicalVolume, info *logicalVolumeInfo) error {
+	fullName := vgName + "/" + lv.Name
+	if info != nil {
+		if logicalVolumeMatches(lv, *info) {
+			s.Logger.Info("logical volume %q already exists. Skipping lvcreate...", fullName)
+			s.Logger.Summary().Add(summary.Lvm, fullName, summary.Skipped, "")
+			return nil
+		}
+		if !lv.WipeLogicalVolume {
+			s.Logger.Err("logical volume %q is not of the correct type or size (found %s, %.2fMiB) and a wipe was not requested", fullName, info.segtype, info.sizeMiB)
+			return ErrBadLogicalVolume
+		}
+		if _, err := s.Logger.LogCmd(
+			exec.Command(distro.LvmCmd(), "lvremove", "--yes", "--force", fullName),
+			"removing logical volume %q", fullName,
+		); err != nil {
+			return fmt.Errorf("lvremove failed: %v", err)
+		}
+	}
+
+	// the new logical volume is ours, so existing signatures can be wiped
+	args := []string{"lvcreate", "--yes", "--name", lv.Name}
+	switch {
+	case lv.Pool != nil:
+		args = append(args, "--type", "thin", "--virtualsize", fmt.Sprintf("%dm", *lv.SizeMiB), "--thinpool", *lv.Pool)
+	case lv.SizeMiB != nil:
+		args = append(args, "--size", fmt.Sprintf("%dm", *lv.SizeMiB))
+	default:
+		args = append(args, "--extents", fmt.Sprintf("%d%%FREE", *lv.PercentFree))
+	}
+	if lv.ThinPool {
+		args = append(args, "--type", "thin-pool")
+	}
+	if lv.Stripes != nil {
+		args = append(args, "--stripes", strconv.Itoa(*lv.Stripes))
+	}
+	for _, o := range lv.Options {
+		args = append(args, string(o))
+	}
+	args = append(args, vgName)
+
+	if _, err := s.Logger.LogCmd(
+		exec.Command(distro.LvmCmd(), args...),
+		"creating logical volume %q", fullName,
+	); err != nil {
+		return fmt.Errorf("lvcreate failed: %v", err)
+	}
+	s.Logger.Summary().Add(summary.Lvm, fullName, summary.Created, "")
+
+	return nil
+}
+
+// logicalVolumeMatches reports whether the existing logical volume described
+// by info satisfies lv. Sizes given as a percentage of free space can't be
+// checked after the fact and always match.
+func logicalVolumeMatches(lv types.LogicalVolume, info logicalVolumeInfo) bool {
+	switch {
+	case lv.ThinPool:
+		if info.segtype != "thin-pool" {
+			return false
+		}
+	case lv.Pool != nil:
+		if info.segtype != "thin" || info.pool != *lv.Pool {
+			return false
+		}
+	default:
+		if info.segtype != "linear" && info.segtype != "striped" {
+			return false
+		}
+		if info.stripes != 1 && lv.Stripes == nil {
+			return false
+		}
+		if lv.Stripes != nil && info.stripes != *lv.Stripes {
+			return false
+		}
+	}
+	if lv.SizeMiB != nil {
+		// lvcreate rounds sizes up to a whole number of extents
+		want := float64(*lv.SizeMiB)
+		if info.sizeMiB < want || info.sizeMiB >= want+info.extentSizeMiB {
+			return false
+		}
+	}
+	return true
+}
+
+// sameDevices reports whether the two lists name the same set of block
+// devices, following symlinks where possible.
+func sameDevices(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	set := map[string]bool{}
+	for _, dev := range a {
+		set[resolveDevice(dev)] = true
+	}
+	for _, dev := range b {
+		if !set[resolveDevice(dev)] {
+			return false
+		}
+	}
+	return true
+}
+
+func resolveDevice(dev string) string {
+	if res, err := filepath.EvalSymlinks(dev); err == nil {
+		return res
+	}
+	return dev
+}
+
+// readPhysicalVolumes returns the physical volumes of all volume groups,
+// keyed by volume group name.
+func (s stage) readPhysicalVolumes() (map[string][]string, error) {
+	rows, err := s.lvmReport("pvs", "pv", "pv_name,vg_name")
+	if err != nil {
+		return nil, err
+	}
+	res := map[string][]string{}
+	for _, row := range rows {
+		if row["vg_name"] == "" {
+			continue
+		}
+		res[row["vg_name"]] = append(res[row["vg_name"]], row["pv_name"])
+	}
+	return res, nil
+}
+
+// readLogicalVolumes returns the logical volumes of the volume group vgName,
+// keyed by logical volume name.
+func (s stage) readLogicalVolumes(vgName string) (map[string]logicalVolumeInfo, error) {
+	rows, err := s.lvmReport("lvs", "lv", "lv_name,lv_size,vg_extent_size,stripes,segtype,pool_lv", "--segments", "--units", "m", "--nosuffix", vgName)
+	if err != nil {
+		return nil, err
+	}
+	return parseLogicalVolumes(vgName, rows)
+}
+
+// parseLogicalVolumes merges the rows of an lvs report with one row per
+// segment into the logical volumes they belong to. Logical volumes whose
+// segments differ in type or stripe count are given the segment type
+// "mixed", which matches no configuration.
+func parseLogicalVolumes(vgName string, rows []map[string]string) (map[string]logicalVolumeInfo, error) {
+	res := map[string]logicalVolumeInfo{}
+	for _, row := range rows {
+		var err error
+		info := logicalVolumeInfo{
+			segtype: row["segtype"],
+			pool:    strings.Trim(row["pool_lv"], "[]"),
+		}
+		if info.sizeMiB, err = strconv.ParseFloat(row["lv_size"], 64); err != nil {
+			return nil, fmt.Errorf("bad size for logical volume %q: %v", row["lv_name"], err)
+		}
+		if info.extentSizeMiB, err = strconv.ParseFloat(row["vg_extent_size"], 64); err != nil {
+			return nil, fmt.Errorf("bad extent size for volume group %q: %v", vgName, err)
+		}
+		if info.stripes, err = strconv.Atoi(row["stripes"]); err != nil {
+			return nil, fmt.Errorf("bad stripe count for logical volume %q: %v", row["lv_name"], err)
+		}
+		if prev, ok := res[row["lv_name"]]; ok && (prev.segtype != info.segtype || prev.stripes != info.stripes) {
+			info.segtype = "mixed"
+		}
+		res[row["lv_name"]] = info
+	}
+	return res, nil
+}
+
+// lvmReport runs the lvm reporting command cmd with the given fields and
+// returns the rows of the report of the given kind.
+func (s stage) lvmReport(cmd, kind, fields string, args ...string) ([]map[string]string, error) {
+	var out []byte
+	err := s.Logger.LogOp(
+		func() error {
+			var err error
+			args = append([]string{cmd, "--reportformat", "json", "--options", fields}, args...)
+			out, err = exec.Command(distro.LvmCmd(), args...).Output()
+			return err
+		},
+		"reading %s report", cmd,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %v", cmd, err)
+	}
+
+	var report struct {
+		Report []map[string][]map[string]string `json:"report"`
+	}
+	if err := json.Unmarshal(out, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse %s report: %v", cmd, err)
+	}
+	rows := []map[string]string{}
+	for _, r := range report.Report {
+		rows = append(rows, r[kind]...)
+	}
+	return rows, nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package disks
+
+import (
+	"testing"
+
+	"github.com/flatcar-linux/ignition/config/util"
+	"github.com/flatcar-linux/ignition/internal/config/types"
+)
+
+func TestParseLogicalVolumes(t *testing.T) {
+	segment := func(name, size, stripes, segtype string) map[string]string {
+		return map[string]string{
+			"lv_name":        name,
+			"lv_size":        size,
+			"vg_extent_size": "4.00",
+			"stripes":        stripes,
+			"segtype":        segtype,
+			"pool_lv":        "",
+		}
+	}
+	// lvs --segments reports one row per segment
+	rows := []map[string]string{
+		segment("extended", "1024.00", "1", "linear"),
+		segment("extended", "1024.00", "1", "linear"),
+		segment("striped", "512.00", "2", "striped"),
+		segment("mixed", "1024.00", "2", "striped"),
+		segment("mixed", "1024.00", "1", "linear"),
+	}
+	lvs, err := parseLogicalVolumes("vg", rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		lv    types.LogicalVolume
+		match bool
+	}{
+		{lv: types.LogicalVolume{Name: "extended", SizeMiB: util.IntToPtr(1024)}, match: true},
+		{lv: types.LogicalVolume{Name: "extended", SizeMiB: util.IntToPtr(2048)}},
+		{lv: types.LogicalVolume{Name: "striped", SizeMiB: util.IntToPtr(512), Stripes: util.IntToPtr(2)}, match: true},
+		{lv: types.LogicalVolume{Name: "striped", SizeMiB: util.IntToPtr(512)}},
+		{lv: types.LogicalVolume{Name: "mixed", SizeMiB: util.IntToPtr(1024)}},
+		{lv: types.LogicalVolume{Name: "mixed", SizeMiB: util.IntToPtr(1024), Stripes: util.IntToPtr(2)}},
+	}
+	for i, test := range tests {
+		if match := logicalVolumeMatches(test.lv, lvs[test.lv.Name]); match != test.match {
+			t.Errorf("#%d: %q: want match %v, got %v", i, test.lv.Name, test.match, match)
+		}
+	}
+
+	if _, err := parseLogicalVolumes("vg", []map[string]string{segment("bad", "1024.00", "x", "linear")}); err == nil {
+		t.Error("parsed a bad stripe count")
+	}
+}
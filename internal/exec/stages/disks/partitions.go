@@ -27,14 +27,85 @@ import (
 	"strings"
 
 	"github.com/flatcar-linux/ignition/internal/config/types"
+	"github.com/flatcar-linux/ignition/internal/distro"
 	"github.com/flatcar-linux/ignition/internal/exec/util"
+	"github.com/flatcar-linux/ignition/internal/gpt"
+	"github.com/flatcar-linux/ignition/internal/sfdisk"
 	"github.com/flatcar-linux/ignition/internal/sgdisk"
+	"github.com/flatcar-linux/ignition/internal/summary"
 )
 
 var (
 	ErrBadSgdiskOutput = errors.New("sgdisk had unexpected output")
+	ErrBadSfdiskOutput = errors.New("sfdisk had unexpected output")
 )
 
+// partitioner is a partition table backend. gpt tables are written in-process, or with sgdisk if the
+// distro prefers it, and dos tables with sfdisk.
+type partitioner interface {
+	CreatePartition(p types.Partition)
+	DeletePartition(num int)
+	Info(num int)
+	WipeTable(wipe bool)
+	Commit() error
+	// resolve returns the start and size the partitions numbered partitionNumbers would have after the
+	// operation, without committing it. The partitions must have been passed to Info.
+	resolve(partitionNumbers []int) (map[int]sgdiskOutput, error)
+}
+
+type gptPartitioner struct{ *gpt.Operation }
+type sgdiskPartitioner struct{ *sgdisk.Operation }
+type sfdiskPartitioner struct{ *sfdisk.Operation }
+
+func (p gptPartitioner) resolve(partitionNumbers []int) (map[int]sgdiskOutput, error) {
+	parts, err := p.Pretend()
+	if err != nil {
+		return nil, err
+	}
+	output := map[int]sgdiskOutput{}
+	for _, num := range partitionNumbers {
+		part, ok := parts[num]
+		if !ok {
+			return nil, fmt.Errorf("partition %d was not inspected", num)
+		}
+		output[num] = sgdiskOutput{start: *part.Start, size: *part.Size}
+	}
+	return output, nil
+}
+
+func (p sgdiskPartitioner) resolve(partitionNumbers []int) (map[int]sgdiskOutput, error) {
+	output, err := p.Pretend()
+	if err != nil {
+		return nil, err
+	}
+	return parseSgdiskPretend(output, partitionNumbers)
+}
+
+func (p sfdiskPartitioner) resolve(partitionNumbers []int) (map[int]sgdiskOutput, error) {
+	output, err := p.Pretend()
+	if err != nil {
+		return nil, err
+	}
+	return parseSfdiskPretend(output, partitionNumbers)
+}
+
+// isDos returns whether dev is to have a dos partition table.
+func isDos(dev types.Disk) bool {
+	return dev.PartitionTable != nil && *dev.PartitionTable == "dos"
+}
+
+// beginPartitioning begins an operation on devAlias with the backend for dev's partition table type.
+func (s stage) beginPartitioning(dev types.Disk, devAlias string) partitioner {
+	switch {
+	case isDos(dev):
+		return sfdiskPartitioner{sfdisk.Begin(s.Logger, devAlias)}
+	case distro.UseSgdisk():
+		return sgdiskPartitioner{sgdisk.Begin(s.Logger, devAlias)}
+	default:
+		return gptPartitioner{gpt.Begin(s.Logger, devAlias)}
+	}
+}
+
 // createPartitions creates the partitions described in config.Storage.Disks.
 func (s stage) createPartitions(config types.Config) error {
 	if len(config.Storage.Disks) == 0 {
@@ -43,8 +114,13 @@ func (s stage) createPartitions(config types.Config) error {
 	s.Logger.PushPrefix("createPartitions")
 	defer s.Logger.PopPrefix()
 
+	disks, err := s.resolveDiskSelectors(config.Storage.Disks)
+	if err != nil {
+		return err
+	}
+
 	devs := []string{}
-	for _, disk := range config.Storage.Disks {
+	for _, disk := range disks {
 		devs = append(devs, string(disk.Device))
 	}
 
@@ -52,7 +128,7 @@ func (s stage) createPartitions(config types.Config) error {
 		return err
 	}
 
-	for _, dev := range config.Storage.Disks {
+	for _, dev := range disks {
 		devAlias := util.DeviceAlias(string(dev.Device))
 
 		err := s.Logger.LogOp(func() error {
@@ -88,30 +164,52 @@ func partitionMatches(existing, spec types.Partition) error {
 	if spec.Label != nil && *spec.Label != *existing.Label {
 		return fmt.Errorf("label did not match (specified %q, got %q)", *spec.Label, *existing.Label)
 	}
+	if spec.DosType != "" && !dosTypesEqual(spec.DosType, existing.DosType) {
+		return fmt.Errorf("dos type did not match (specified %q, got %q)", spec.DosType, existing.DosType)
+	}
 	return nil
 }
 
-// partitionShouldBeInspected returns if the partition has zeroes that need to be resolved to sectors.
+// dosTypesEqual compares two hexadecimal dos partition types, e.g. "c" and "0C".
+func dosTypesEqual(a, b string) bool {
+	x, errA := strconv.ParseUint(a, 16, 8)
+	y, errB := strconv.ParseUint(b, 16, 8)
+	return errA == nil && errB == nil && x == y
+}
+
+// partitionShouldBeInspected returns if the partition has zeroes that need to be resolved to sectors
+// or is to be resized.
 func partitionShouldBeInspected(part types.Partition) bool {
 	if part.Number == 0 {
 		return false
 	}
-	return (part.Start != nil && *part.Start == 0) ||
+	return part.Resize ||
+		(part.Start != nil && *part.Start == 0) ||
 		(part.StartMiB != nil && *part.StartMiB == 0) ||
 		(part.Size != nil && *part.Size == 0) ||
 		(part.SizeMiB != nil && *part.SizeMiB == 0)
 }
 
 // getRealStartAndSize returns a map of partition numbers to a struct that contains what their real start
-// and end sector should be. It runs sgdisk --pretend to determine what the partitions would look like if
-// everything specified were to be (re)created.
+// and end sector should be. It pretends to run the partitioning operation to determine what the partitions
+// would look like if everything specified were to be (re)created.
 func (s stage) getRealStartAndSize(dev types.Disk, devAlias string, existanceMap map[int]types.Partition) ([]types.Partition, error) {
-	op := sgdisk.Begin(s.Logger, devAlias)
+	op := s.beginPartitioning(dev, devAlias)
 	for _, part := range dev.Partitions {
 		info, exists := existanceMap[part.Number]
 		if exists {
 			// delete all existing partitions
 			op.DeletePartition(part.Number)
+			if part.Resize {
+				// grow in place: keep the start, and unless given, fill the available space
+				if part.Start == nil && part.StartMiB == nil {
+					part.Start = info.Start
+				}
+				if part.Size == nil && part.SizeMiB == nil {
+					zero := 0
+					part.Size = &zero
+				}
+			}
 			if part.Start == nil && part.StartMiB == nil && !part.WipePartitionEntry {
 				// don't care means keep the same if we can't wipe, otherwise stick it at start 0
 				part.StartMiB = nil
@@ -138,12 +236,7 @@ func (s stage) getRealStartAndSize(dev types.Disk, devAlias string, existanceMap
 		}
 	}
 
-	output, err := op.Pretend()
-	if err != nil {
-		return nil, err
-	}
-
-	realDimensions, err := parseSgdiskPretend(output, partitionsToInspect)
+	realDimensions, err := op.resolve(partitionsToInspect)
 	if err != nil {
 		return nil, err
 	}
@@ -151,7 +244,14 @@ func (s stage) getRealStartAndSize(dev types.Disk, devAlias string, existanceMap
 	result := []types.Partition{}
 	for _, part := range dev.Partitions {
 		if dims, ok := realDimensions[part.Number]; ok {
-			if part.Start != nil {
+			if part.Resize {
+				// compare the resolved size with the existing one
+				if part.StartMiB == nil {
+					part.Start = &dims.start
+				}
+				part.SizeMiB = nil
+				part.Size = &dims.size
+			} else if part.Start != nil {
 				part.StartMiB = nil
 				part.Start = &dims.start
 			}
@@ -252,6 +352,57 @@ func parseSgdiskPretend(sgdiskOut string, partitionNumbers []int) (map[int]sgdis
 	return output, nil
 }
 
+// parseSfdiskPretend parses the output of running sfdisk --no-act, which lists the resulting table after a
+// "New situation:" line, and returns the start and size of the partitions numbered partitionNumbers.
+func parseSfdiskPretend(sfdiskOut string, partitionNumbers []int) (map[int]sgdiskOutput, error) {
+	if len(partitionNumbers) == 0 {
+		return nil, nil
+	}
+
+	all := map[int]sgdiskOutput{}
+	newSituation := false
+	inTable := false
+	for _, line := range strings.Split(sfdiskOut, "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case strings.TrimSpace(line) == "New situation:":
+			newSituation = true
+		case newSituation && len(fields) > 0 && fields[0] == "Device":
+			inTable = true
+		case inTable && len(fields) == 0:
+			inTable = false
+		case inTable && strings.HasPrefix(fields[0], "/"):
+			// Device Boot Start End Sectors Size Id Type, where Boot is "*" or empty
+			i := 1
+			if len(fields) > 1 && fields[1] == "*" {
+				i = 2
+			}
+			if len(fields) < i+2 {
+				return nil, ErrBadSfdiskOutput
+			}
+			start, err := strconv.Atoi(fields[i])
+			if err != nil {
+				return nil, ErrBadSfdiskOutput
+			}
+			end, err := strconv.Atoi(fields[i+1])
+			if err != nil {
+				return nil, ErrBadSfdiskOutput
+			}
+			all[sfdisk.PartitionNumber(fields[0])] = sgdiskOutput{start: start, size: 1 + end - start}
+		}
+	}
+
+	output := map[int]sgdiskOutput{}
+	for _, num := range partitionNumbers {
+		dims, ok := all[num]
+		if !ok {
+			return nil, ErrBadSfdiskOutput
+		}
+		output[num] = dims
+	}
+	return output, nil
+}
+
 // partitionShouldExist returns whether a bool is indicating if a partition should exist or not.
 // nil (unspecified in json) is treated the same as true.
 func partitionShouldExist(part types.Partition) bool {
@@ -259,11 +410,26 @@ func partitionShouldExist(part types.Partition) bool {
 }
 
 // getPartitionMap returns a map of partitions on device, indexed by partition number
-func (s stage) getPartitionMap(device string) (map[int]types.Partition, error) {
+func (s stage) getPartitionMap(dev types.Disk, device string) (map[int]types.Partition, error) {
 	parts := []types.Partition{}
 	err := s.Logger.LogOp(
 		func() error {
-			p, err := util.DumpPartitionTable(device)
+			if isDos(dev) {
+				table, err := sfdisk.DumpPartitionTable(device)
+				if err != nil {
+					return err
+				}
+				if table.Label != "" && table.Label != "dos" {
+					return fmt.Errorf("%v: found a %s partition table and wipeTable is false", sfdisk.ErrNotDos, table.Label)
+				}
+				parts = table.Partitions
+				return nil
+			}
+			dump := gpt.DumpPartitionTable
+			if distro.UseSgdisk() {
+				dump = util.DumpPartitionTable
+			}
+			p, err := dump(device)
 			if err != nil {
 				return err
 			}
@@ -301,7 +467,7 @@ func (p PartitionList) Swap(i, j int) {
 // partitionDisk partitions devAlias according to the spec given by dev
 func (s stage) partitionDisk(dev types.Disk, devAlias string) error {
 	if dev.WipeTable {
-		op := sgdisk.Begin(s.Logger, devAlias)
+		op := s.beginPartitioning(dev, devAlias)
 		s.Logger.Info("wiping partition table requested on %q", devAlias)
 		op.WipeTable(true)
 		op.Commit()
@@ -310,20 +476,38 @@ func (s stage) partitionDisk(dev types.Disk, devAlias string) error {
 	// Ensure all partitions with number 0 are last
 	sort.Stable(PartitionList(dev.Partitions))
 
-	op := sgdisk.Begin(s.Logger, devAlias)
+	op := s.beginPartitioning(dev, devAlias)
 
-	originalParts, err := s.getPartitionMap(devAlias)
+	originalParts, err := s.getPartitionMap(dev, devAlias)
 	if err != nil {
-		return err
+		if !s.DryRun() {
+			return err
+		}
+		s.Logger.Warning("assuming %q has no partitions: %v", devAlias, err)
+		originalParts = map[int]types.Partition{}
+	}
+	if dev.WipeTable && s.DryRun() {
+		// the table was not actually wiped above
+		originalParts = map[int]types.Partition{}
 	}
 
 	// get a list of parititions that have size and start 0 replaced with the real sizes
 	// that would be used if all specified partitions were to be created anew.
 	resolvedPartitions, err := s.getRealStartAndSize(dev, devAlias, originalParts)
 	if err != nil {
-		return err
+		if !s.DryRun() {
+			return err
+		}
+		s.Logger.Warning("unable to resolve partition dimensions on %q: %v", devAlias, err)
+		resolvedPartitions = dev.Partitions
 	}
 
+	type result struct {
+		part   types.Partition
+		action summary.Action
+	}
+	var results []result
+	var resized []types.Partition
 	for _, part := range resolvedPartitions {
 		shouldExist := partitionShouldExist(part)
 		info, exists := originalParts[part.Number]
@@ -333,24 +517,38 @@ func (s stage) partitionDisk(dev types.Disk, devAlias string) error {
 		}
 		matches := exists && matchErr == nil
 
+		if exists && shouldExist && !matches && partitionShouldGrow(info, part) {
+			s.Logger.Info("partition %d found with correct specifications except size, growing it from %d to %d sectors", part.Number, *info.Size, *part.Size)
+			grown := partitionToGrow(info, part)
+			op.DeletePartition(part.Number)
+			op.CreatePartition(grown)
+			results = append(results, result{grown, summary.Modified})
+			resized = append(resized, grown)
+			continue
+		}
+
 		// This is a translation of the matrix in the operator notes.
 		switch {
 		case !exists && !shouldExist:
 			s.Logger.Info("partition %d specified as nonexistant and no partition was found. Success.", part.Number)
 		case !exists && shouldExist:
 			op.CreatePartition(part)
+			results = append(results, result{part, summary.Created})
 		case exists && !shouldExist && !part.WipePartitionEntry:
 			return fmt.Errorf("partition %d exists but is specified as nonexistant and wipePartitionEntry is false", part.Number)
 		case exists && !shouldExist && part.WipePartitionEntry:
 			op.DeletePartition(part.Number)
+			results = append(results, result{part, summary.Deleted})
 		case exists && shouldExist && matches:
 			s.Logger.Info("partition %d found with correct specifications", part.Number)
+			results = append(results, result{part, summary.Skipped})
 		case exists && shouldExist && !part.WipePartitionEntry && !matches:
 			return fmt.Errorf("Partition %d didn't match: %v", part.Number, matchErr)
 		case exists && shouldExist && part.WipePartitionEntry && !matches:
 			s.Logger.Info("partition %d did not meet specifications, wiping partition entry and recreating", part.Number)
 			op.DeletePartition(part.Number)
 			op.CreatePartition(part)
+			results = append(results, result{part, summary.Created})
 		default:
 			// unfortunatey, golang doesn't check that all cases are handled exhaustively
 			return fmt.Errorf("Unreachable code reached when processing partition %d. golang--", part.Number)
@@ -360,5 +558,51 @@ func (s stage) partitionDisk(dev types.Disk, devAlias string) error {
 	if err := op.Commit(); err != nil {
 		return fmt.Errorf("commit failure: %v", err)
 	}
+	for _, r := range results {
+		detail := ""
+		if r.part.Label != nil {
+			detail = fmt.Sprintf("label %q", *r.part.Label)
+		}
+		s.Logger.Summary().Add(summary.Partition, fmt.Sprintf("%s partition %d", dev.Device, r.part.Number), r.action, detail)
+	}
+
+	for _, part := range resized {
+		if err := s.growFilesystem(part); err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+// partitionShouldGrow returns whether spec asks for the existing partition to be grown, i.e. resize is set, the
+// resolved size is larger than the existing one and the partition matches the spec otherwise. Shrinking is never
+// done in place since it would cut off the filesystem.
+func partitionShouldGrow(existing, spec types.Partition) bool {
+	if !spec.Resize || spec.Size == nil || *spec.Size <= *existing.Size {
+		return false
+	}
+	unsized := spec
+	unsized.Size = existing.Size
+	return partitionMatches(existing, unsized) == nil
+}
+
+// partitionToGrow returns the partition to recreate in place of existing. Everything spec leaves unset is copied
+// from existing, so that only the size changes.
+func partitionToGrow(existing, spec types.Partition) types.Partition {
+	grown := spec
+	grown.StartMiB = nil
+	grown.Start = existing.Start
+	if grown.Label == nil {
+		grown.Label = existing.Label
+	}
+	if grown.TypeGUID == "" {
+		grown.TypeGUID = existing.TypeGUID
+	}
+	if grown.GUID == "" {
+		grown.GUID = existing.GUID
+	}
+	if grown.DosType == "" {
+		grown.DosType = existing.DosType
+	}
+	return grown
+}
@@ -0,0 +1,76 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package disks
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSfdiskPretend(t *testing.T) {
+	out := `Checking that no-one is using this disk right now ... OK
+
+Disk /run/ignition/dev_aliases/dev/loop0: 1 GiB, 1073741824 bytes, 2097152 sectors
+Units: sectors of 1 * 512 = 512 bytes
+Sector size (logical/physical): 512 bytes / 512 bytes
+I/O size (minimum/optimal): 512 bytes / 512 bytes
+Disklabel type: dos
+Disk identifier: 0x5b6e8b4c
+
+Old situation:
+
+Device                                 Boot Start   End Sectors Size Id Type
+/run/ignition/dev_aliases/dev/loop0p1        2048 67583   65536  32M 83 Linux
+
+>>> Script header accepted.
+>>> Script header accepted.
+>>> Script header accepted.
+>>> Created a new DOS disklabel with disk identifier 0x5b6e8b4c.
+/run/ignition/dev_aliases/dev/loop0p1: Created a new partition 1 of type 'Linux' and of size 32 MiB.
+/run/ignition/dev_aliases/dev/loop0p2: Created a new partition 2 of type 'Extended' and of size 991 MiB.
+/run/ignition/dev_aliases/dev/loop0p5: Created a new partition 5 of type 'Linux' and of size 990 MiB.
+/run/ignition/dev_aliases/dev/loop0p6: Done.
+
+New situation:
+Disklabel type: dos
+Disk identifier: 0x5b6e8b4c
+
+Device                                 Boot Start     End Sectors  Size Id Type
+/run/ignition/dev_aliases/dev/loop0p1  *     2048   67583   65536   32M 83 Linux
+/run/ignition/dev_aliases/dev/loop0p2       67584 2097151 2029568  991M  5 Extended
+/run/ignition/dev_aliases/dev/loop0p5       69632 2097151 2027520  990M 83 Linux
+
+The partition table is unchanged (--no-act).
+`
+
+	dims, err := parseSfdiskPretend(out, []int{1, 5})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[int]sgdiskOutput{
+		1: {start: 2048, size: 65536},
+		5: {start: 69632, size: 2027520},
+	}
+	if !reflect.DeepEqual(dims, expected) {
+		t.Errorf("bad dimensions: want %v, got %v", expected, dims)
+	}
+
+	if _, err := parseSfdiskPretend(out, []int{3}); err != ErrBadSfdiskOutput {
+		t.Errorf("expected %v for a missing partition, got %v", ErrBadSfdiskOutput, err)
+	}
+	if dims, err := parseSfdiskPretend(out, nil); dims != nil || err != nil {
+		t.Errorf("expected nothing without partitions to inspect, got %v, %v", dims, err)
+	}
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/flatcar-linux/ignition/internal/config/types"
 	"github.com/flatcar-linux/ignition/internal/distro"
 	"github.com/flatcar-linux/ignition/internal/exec/util"
+	"github.com/flatcar-linux/ignition/internal/summary"
 )
 
 func (s stage) createRaids(config types.Config) error {
@@ -74,6 +75,7 @@ func (s stage) createRaids(config types.Config) error {
 		); err != nil {
 			return fmt.Errorf("mdadm failed: %v", err)
 		}
+		s.Logger.Summary().Add(summary.Raid, md.Name, summary.Created, fmt.Sprintf("level %s", md.Level))
 	}
 
 	return nil
@@ -0,0 +1,293 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package disks
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/flatcar-linux/ignition/internal/config/types"
+	"github.com/flatcar-linux/ignition/internal/distro"
+)
+
+const (
+	sysBlockDir   = "/sys/block"
+	diskByPathDir = "/dev/disk/by-path"
+	udevDataDir   = "/run/udev/data"
+)
+
+var (
+	ErrNoDiskMatched  = errors.New("no disk matches the selector")
+	ErrAmbiguousDisks = errors.New("more than one disk matches the selector")
+)
+
+// blockDevice describes a whole disk as seen in sysfs.
+type blockDevice struct {
+	name       string
+	sizeMiB    int
+	rotational bool
+	model      string
+	serial     string
+	paths      []string
+	// unused is true if the disk has neither partitions nor holders
+	unused bool
+}
+
+// resolveDiskSelectors returns disks with the devices of all disks given
+// by a selector filled in. Disks are never selected twice, and disks given
+// by device are never selected.
+func (s stage) resolveDiskSelectors(disks []types.Disk) ([]types.Disk, error) {
+	needed := false
+	for _, disk := range disks {
+		needed = needed || disk.Selector != nil
+	}
+	if !needed {
+		return disks, nil
+	}
+
+	// by-path links and serials come from udev, so let it catch up first
+	if _, err := s.Logger.LogCmd(
+		exec.Command(distro.UdevadmCmd(), "settle"),
+		"waiting for udev to settle",
+	); err != nil {
+		return nil, fmt.Errorf("udevadm settle failed: %v", err)
+	}
+
+	var devs []blockDevice
+	if err := s.Logger.LogOp(func() error {
+		var err error
+		devs, err = readBlockDevices(sysBlockDir, diskByPathDir, udevDataDir)
+		return err
+	}, "reading disk properties from sysfs"); err != nil {
+		return nil, err
+	}
+
+	claimed := map[string]bool{}
+	for _, disk := range disks {
+		if disk.Selector != nil {
+			continue
+		}
+		if dev, err := filepath.EvalSymlinks(disk.Device); err == nil {
+			claimed[filepath.Base(dev)] = true
+		}
+	}
+
+	res := []types.Disk{}
+	for i, disk := range disks {
+		if disk.Selector != nil {
+			dev, err := selectDisk(devs, *disk.Selector, claimed)
+			if err != nil {
+				return nil, fmt.Errorf("disk %d: %v", i, err)
+			}
+			claimed[dev.name] = true
+			disk.Device = filepath.Join("/dev", dev.name)
+			s.Logger.Info("disk %d: selected %q (%d MiB, model %q, serial %q)", i, disk.Device, dev.sizeMiB, dev.model, dev.serial)
+		}
+		res = append(res, disk)
+	}
+	return res, nil
+}
+
+// selectDisk returns the one unclaimed disk in devs matching sel or, if
+// largestUnused is set, the largest unused one.
+func selectDisk(devs []blockDevice, sel types.DiskSelector, claimed map[string]bool) (blockDevice, error) {
+	candidates := []blockDevice{}
+	for _, dev := range devs {
+		if !claimed[dev.name] && dev.matches(sel) {
+			candidates = append(candidates, dev)
+		}
+	}
+	if len(candidates) == 0 {
+		return blockDevice{}, ErrNoDiskMatched
+	}
+	if sel.LargestUnused {
+		// sort.SliceStable keeps the sysfs (i.e. name) order among equally large disks
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].sizeMiB > candidates[j].sizeMiB
+		})
+		return candidates[0], nil
+	}
+	if len(candidates) > 1 {
+		names := []string{}
+		for _, dev := range candidates {
+			names = append(names, dev.name)
+		}
+		return blockDevice{}, fmt.Errorf("%v: %s", ErrAmbiguousDisks, strings.Join(names, ", "))
+	}
+	return candidates[0], nil
+}
+
+// matches reports whether the disk satisfies all criteria of sel.
+func (d blockDevice) matches(sel types.DiskSelector) bool {
+	if sel.MinSizeMiB != nil && d.sizeMiB < *sel.MinSizeMiB {
+		return false
+	}
+	if sel.MaxSizeMiB != nil && d.sizeMiB > *sel.MaxSizeMiB {
+		return false
+	}
+	if sel.Rotational != nil && d.rotational != *sel.Rotational {
+		return false
+	}
+	if sel.Model != nil && !globMatches(*sel.Model, d.model) {
+		return false
+	}
+	if sel.Serial != nil && !globMatches(*sel.Serial, d.serial) {
+		return false
+	}
+	if sel.Path != nil {
+		found := false
+		for _, p := range d.paths {
+			found = found || globMatches(*sel.Path, p)
+		}
+		if !found {
+			return false
+		}
+	}
+	if sel.LargestUnused && !d.unused {
+		return false
+	}
+	return true
+}
+
+func globMatches(pattern, s string) bool {
+	// patterns are validated with the config
+	ok, _ := filepath.Match(pattern, s)
+	return ok
+}
+
+// readBlockDevices returns the whole disks listed in sysBlock which are
+// backed by a device, skipping loop, device mapper and md devices as well as
+// empty drives.
+func readBlockDevices(sysBlock, byPath, udevData string) ([]blockDevice, error) {
+	entries, err := ioutil.ReadDir(sysBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := map[string][]string{}
+	links, err := ioutil.ReadDir(byPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, link := range links {
+		target, err := os.Readlink(filepath.Join(byPath, link.Name()))
+		if err != nil {
+			continue
+		}
+		name := filepath.Base(target)
+		paths[name] = append(paths[name], link.Name())
+	}
+
+	devs := []blockDevice{}
+	for _, entry := range entries {
+		name := entry.Name()
+		dir := filepath.Join(sysBlock, name)
+		if _, err := os.Stat(filepath.Join(dir, "device")); err != nil {
+			continue
+		}
+		sectors, err := readSysfsInt(filepath.Join(dir, "size"))
+		if err != nil {
+			return nil, err
+		}
+		if sectors == 0 {
+			continue
+		}
+		// sysfs sizes are in 512 byte sectors regardless of the disk's sector size
+		dev := blockDevice{
+			name:    name,
+			sizeMiB: sectors / 2048,
+			model:   readSysfsString(filepath.Join(dir, "device", "model")),
+			serial:  readSysfsString(filepath.Join(dir, "device", "serial")),
+			paths:   paths[name],
+		}
+		if rotational, err := readSysfsInt(filepath.Join(dir, "queue", "rotational")); err == nil {
+			dev.rotational = rotational == 1
+		}
+		if dev.serial == "" {
+			// SCSI disks only expose their serial in VPD pages, which udev decodes for us
+			if majMin := readSysfsString(filepath.Join(dir, "dev")); majMin != "" {
+				dev.serial = readUdevProperty(filepath.Join(udevData, "b"+majMin), "ID_SERIAL_SHORT")
+			}
+		}
+		dev.unused = isUnused(dir, name)
+		devs = append(devs, dev)
+	}
+	return devs, nil
+}
+
+// isUnused reports whether the disk at the sysfs directory dir has neither
+// partitions nor holders such as device mapper or md devices.
+func isUnused(dir, name string) bool {
+	holders, err := ioutil.ReadDir(filepath.Join(dir, "holders"))
+	if err != nil || len(holders) > 0 {
+		return false
+	}
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), name) {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, entry.Name(), "partition")); err == nil {
+			return false
+		}
+	}
+	return true
+}
+
+func readSysfsString(path string) string {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+func readSysfsInt(path string) (int, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(b)))
+}
+
+// readUdevProperty returns the value of the property key from the udev
+// database entry at path, or "" if it isn't set.
+func readUdevProperty(path, key string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	prefix := "E:" + key + "="
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, prefix) {
+			return strings.TrimPrefix(line, prefix)
+		}
+	}
+	return ""
+}
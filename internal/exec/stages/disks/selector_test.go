@@ -0,0 +1,143 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package disks
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/flatcar-linux/ignition/internal/config/types"
+)
+
+// writeFakeDisk creates the sysfs entries readBlockDevices looks at.
+func writeFakeDisk(t *testing.T, sysBlock, name string, files map[string]string) {
+	for path, contents := range files {
+		path = filepath.Join(sysBlock, name, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(contents+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(sysBlock, name, "holders"), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSelectDisk(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ignition-selector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sysBlock := filepath.Join(dir, "block")
+	byPath := filepath.Join(dir, "by-path")
+	udevData := filepath.Join(dir, "udev")
+
+	// 1 TiB spinning disk with a partition
+	writeFakeDisk(t, sysBlock, "sda", map[string]string{
+		"size":             "2147483648",
+		"dev":              "8:0",
+		"queue/rotational": "1",
+		"device/model":     "ST1000DM010     ",
+		"sda1/partition":   "1",
+	})
+	// 512 GiB SSD, serial only known to udev
+	writeFakeDisk(t, sysBlock, "sdb", map[string]string{
+		"size":             "1073741824",
+		"dev":              "8:16",
+		"queue/rotational": "0",
+		"device/model":     "Samsung SSD 860",
+	})
+	// 256 GiB NVMe drive
+	writeFakeDisk(t, sysBlock, "nvme0n1", map[string]string{
+		"size":             "536870912",
+		"dev":              "259:0",
+		"queue/rotational": "0",
+		"device/model":     "Samsung SSD 970 EVO",
+		"device/serial":    "S4EVNF0M",
+	})
+	// loop devices aren't backed by a device
+	writeFakeDisk(t, sysBlock, "loop0", map[string]string{
+		"size": "2048",
+	})
+	if err := os.MkdirAll(filepath.Join(udevData), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(udevData, "b8:16"), []byte("S:disk/by-id/ata-x\nE:ID_SERIAL=Samsung_SSD_860_S3Z9NB0K\nE:ID_SERIAL_SHORT=S3Z9NB0K\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(byPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("../../sdb", filepath.Join(byPath, "pci-0000:00:1f.2-ata-2")); err != nil {
+		t.Fatal(err)
+	}
+
+	devs, err := readBlockDevices(sysBlock, byPath, udevData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devs) != 3 {
+		t.Fatalf("expected 3 disks, got %+v", devs)
+	}
+
+	intToPtr := func(i int) *int { return &i }
+	strToPtr := func(s string) *string { return &s }
+	boolToPtr := func(b bool) *bool { return &b }
+
+	tests := []struct {
+		sel     types.DiskSelector
+		claimed []string
+		out     string
+		err     bool
+	}{
+		{sel: types.DiskSelector{Rotational: boolToPtr(true)}, out: "sda"},
+		{sel: types.DiskSelector{Rotational: boolToPtr(false)}, err: true},
+		{sel: types.DiskSelector{Rotational: boolToPtr(false), MinSizeMiB: intToPtr(300000)}, out: "sdb"},
+		{sel: types.DiskSelector{MaxSizeMiB: intToPtr(262144)}, out: "nvme0n1"},
+		{sel: types.DiskSelector{Model: strToPtr("ST1000*")}, out: "sda"},
+		{sel: types.DiskSelector{Serial: strToPtr("S3Z9*")}, out: "sdb"},
+		{sel: types.DiskSelector{Serial: strToPtr("S4EV*")}, out: "nvme0n1"},
+		{sel: types.DiskSelector{Path: strToPtr("pci-*-ata-2")}, out: "sdb"},
+		{sel: types.DiskSelector{Model: strToPtr("WDC*")}, err: true},
+		{sel: types.DiskSelector{LargestUnused: true}, out: "sdb"},
+		{sel: types.DiskSelector{LargestUnused: true}, claimed: []string{"sdb"}, out: "nvme0n1"},
+		{sel: types.DiskSelector{LargestUnused: true}, claimed: []string{"sdb", "nvme0n1"}, err: true},
+		{sel: types.DiskSelector{Rotational: boolToPtr(false)}, claimed: []string{"sdb"}, out: "nvme0n1"},
+	}
+
+	for i, test := range tests {
+		claimed := map[string]bool{}
+		for _, name := range test.claimed {
+			claimed[name] = true
+		}
+		dev, err := selectDisk(devs, test.sel, claimed)
+		if test.err {
+			if err == nil {
+				t.Errorf("#%d: expected an error, got %q", i, dev.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+		} else if dev.name != test.out {
+			t.Errorf("#%d: selected %q instead of %q", i, dev.name, test.out)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package files
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/flatcar-linux/ignition/internal/config"
+	"github.com/flatcar-linux/ignition/internal/log"
+	"github.com/flatcar-linux/ignition/internal/plan"
+	"github.com/flatcar-linux/ignition/internal/resource"
+)
+
+// snapshot returns the paths, modes and contents of everything under root.
+func snapshot(t *testing.T, root string) map[string]string {
+	res := map[string]string{}
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		entry := info.Mode().String()
+		if info.Mode().IsRegular() {
+			contents, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			entry += " " + string(contents)
+		}
+		res[path] = entry
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func TestDryRun(t *testing.T) {
+	root, err := ioutil.TempDir("", "ignition-files-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	if err := os.MkdirAll(filepath.Join(root, "etc"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "etc/hostname"), []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, _, err := config.Parse([]byte(fmt.Sprintf(`{
+		"ignition": {"version": "2.4.0-experimental"},
+		"storage": {
+			"filesystems": [{"name": "root", "path": %q}],
+			"files": [
+				{"filesystem": "root", "path": "/etc/hostname", "mode": 420, "contents": {"source": "data:,new%%0A"}},
+				{"filesystem": "root", "path": "/etc/motd", "append": true, "contents": {"source": "data:,hello"}}
+			],
+			"directories": [{"filesystem": "root", "path": "/var/lib/app", "mode": 448}],
+			"links": [{"filesystem": "root", "path": "/etc/localtime", "target": "/usr/share/zoneinfo/UTC"}]
+		},
+		"systemd": {"units": [{"name": "app.service", "enabled": true, "contents": "[Service]\nExecStart=/bin/true\n"}]},
+		"passwd": {"users": [{"name": "app"}]}
+	}`, root)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before := snapshot(t, root)
+	logger := log.New(true)
+	defer logger.Close()
+	p := &plan.Plan{}
+	logger.SetPlan(p)
+	s := creator{}.Create(&logger, root, resource.Fetcher{Logger: &logger})
+	if err := s.Run(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if after := snapshot(t, root); !equalSnapshots(before, after) {
+		t.Errorf("dry run modified the target:\nbefore %v\nafter  %v", before, after)
+	}
+
+	var text bytes.Buffer
+	if err := p.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`creating or modifying user "app"`,
+		`write 4 bytes to "` + filepath.Join(root, "etc/hostname") + `" (mode 0644`,
+		`append 5 bytes to "` + filepath.Join(root, "etc/motd") + `"`,
+		`creating directory "/var/lib/app"`,
+		`writing link "/etc/localtime" -> "/usr/share/zoneinfo/UTC"`,
+		`write 30 bytes to "` + filepath.Join(root, "etc/systemd/system/app.service") + `"`,
+		`enabling unit "app.service"`,
+	} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("text plan lacks %q:\n%s", want, text.String())
+		}
+	}
+
+	var doc struct {
+		Entries []plan.Entry `json:"entries"`
+	}
+	var js bytes.Buffer
+	if err := p.WriteJSON(&js); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(js.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Entries) != len(p.Entries()) || len(doc.Entries) == 0 {
+		t.Fatalf("JSON plan has %d entries, want %d", len(doc.Entries), len(p.Entries()))
+	}
+	if useradd := doc.Entries[0].Command; len(useradd) == 0 || filepath.Base(useradd[0]) != "useradd" {
+		t.Errorf("JSON plan lacks the useradd command: %+v", doc.Entries[0])
+	}
+	for _, e := range doc.Entries {
+		if e.Description == "" || len(e.Context) == 0 {
+			t.Errorf("bad JSON plan entry %+v", e)
+		}
+	}
+}
+
+func equalSnapshots(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, entry := range a {
+		if b[path] != entry {
+			return false
+		}
+	}
+	return true
+}
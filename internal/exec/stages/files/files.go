@@ -17,7 +17,9 @@ package files
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/flatcar-linux/ignition/internal/config/types"
@@ -26,6 +28,7 @@ import (
 	"github.com/flatcar-linux/ignition/internal/exec/util"
 	"github.com/flatcar-linux/ignition/internal/log"
 	"github.com/flatcar-linux/ignition/internal/resource"
+	"github.com/flatcar-linux/ignition/internal/summary"
 )
 
 const (
@@ -83,6 +86,8 @@ func (s stage) Run(config types.Config) error {
 		return fmt.Errorf("failed to create units: %v", err)
 	}
 
+	s.copyReports(distro.RunReportDir())
+
 	// add systemd unit to relabel files
 	if err := s.addRelabelUnit(config); err != nil {
 		return fmt.Errorf("failed to add relabel unit: %v", err)
@@ -91,6 +96,42 @@ func (s stage) Run(config types.Config) error {
 	return nil
 }
 
+// copyReports copies the reports of the stages which ran before the root
+// filesystem was mounted from dir to the report directory under the root.
+// Failures are only logged since the reports are not part of the config.
+func (s *stage) copyReports(dir string) {
+	reports, err := filepath.Glob(filepath.Join(dir, summary.ReportName("*")))
+	if err != nil || len(reports) == 0 {
+		return
+	}
+	destDir, err := s.JoinPath(summary.RootReportDir)
+	if err != nil {
+		s.Logger.Err("failed to copy reports: %v", err)
+		return
+	}
+	for _, report := range reports {
+		report := report
+		dest := filepath.Join(destDir, filepath.Base(report))
+		if err := s.Logger.LogChange(func() error {
+			data, err := ioutil.ReadFile(report)
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(destDir, 0755); err != nil {
+				return err
+			}
+			if err := ioutil.WriteFile(dest, data, 0600); err != nil {
+				return err
+			}
+			return os.Chmod(dest, 0600)
+		}, "copying report %q to %q", report, dest); err != nil {
+			s.Logger.Err("failed to copy report %q: %v", report, err)
+			continue
+		}
+		s.relabel(filepath.Join("/", summary.RootReportDir, filepath.Base(report)))
+	}
+}
+
 // checkRelabeling determines whether relabeling is supported/requested so that
 // we only collect filenames if we need to.
 func (s *stage) checkRelabeling() error {
@@ -156,7 +197,10 @@ RemainAfterExit=yes`,
 		return err
 	}
 
-	if err := s.EnableRuntimeUnit(unit, "sysinit.target"); err != nil {
+	if err := s.Logger.LogChange(
+		func() error { return s.EnableRuntimeUnit(unit, "sysinit.target") },
+		"enabling runtime unit %q", unit.Name,
+	); err != nil {
 		return err
 	}
 
@@ -165,13 +209,15 @@ RemainAfterExit=yes`,
 	if err != nil {
 		return err
 	}
-	f, err := os.Create(etcRelabelPath)
-	if err != nil {
+	return s.Logger.LogChange(func() error {
+		f, err := os.Create(etcRelabelPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		// yes, apparently the final \0 is needed
+		_, err = f.WriteString(strings.Join(s.toRelabel, "\000") + "\000")
 		return err
-	}
-	defer f.Close()
-
-	// yes, apparently the final \0 is needed
-	_, err = f.WriteString(strings.Join(s.toRelabel, "\000") + "\000")
-	return err
+	}, "writing relabel list %q", etcRelabelPath)
 }
@@ -15,6 +15,9 @@
 package files
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
 	"sort"
 	"testing"
@@ -145,3 +148,53 @@ func TestDirectorySort(t *testing.T) {
 		}
 	}
 }
+
+func TestCopyReports(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ignition-files-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	run := filepath.Join(dir, "run")
+	root := filepath.Join(dir, "sysroot")
+	if err := os.MkdirAll(run, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, contents := range map[string]string{
+		"report-fetch.json": "fetch",
+		"report-disks.json": "disks",
+		"other.json":        "other",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(run, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	logger := log.New(true)
+	defer logger.Close()
+	s := stage{Util: util.Util{DestDir: root, Logger: &logger}, toRelabel: []string{}}
+	s.copyReports(run)
+
+	for _, name := range []string{"report-fetch.json", "report-disks.json"} {
+		path := filepath.Join(root, "var/lib/ignition", name)
+		got, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+		} else if want := name[len("report-") : len(name)-len(".json")]; string(got) != want {
+			t.Errorf("%s: want %q, got %q", name, want, got)
+		}
+		if info, err := os.Stat(path); err == nil && info.Mode().Perm() != 0600 {
+			t.Errorf("%s: want mode 0600, got %v", name, info.Mode())
+		}
+	}
+	sort.Strings(s.toRelabel)
+	if want := []string{"/var/lib/ignition/report-disks.json", "/var/lib/ignition/report-fetch.json"}; !reflect.DeepEqual(s.toRelabel, want) {
+		t.Errorf("bad relabel list: want %q, got %q", want, s.toRelabel)
+	}
+	if _, err := os.Stat(filepath.Join(root, "var/lib/ignition/other.json")); !os.IsNotExist(err) {
+		t.Errorf("other.json: want not to exist, got %v", err)
+	}
+
+	// a missing directory is not an error
+	s.copyReports(filepath.Join(dir, "missing"))
+}
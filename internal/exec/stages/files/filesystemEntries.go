@@ -26,6 +26,7 @@ import (
 	"github.com/flatcar-linux/ignition/internal/config/types"
 	"github.com/flatcar-linux/ignition/internal/exec/util"
 	"github.com/flatcar-linux/ignition/internal/log"
+	"github.com/flatcar-linux/ignition/internal/summary"
 )
 
 // createFilesystemsEntries creates the files described in config.Storage.{Files,Directories}.
@@ -64,8 +65,11 @@ func (tmp fileEntry) getPath() string {
 
 func (tmp fileEntry) create(l *log.Logger, u util.Util) error {
 	f := types.File(tmp)
+	return writeFile(l, u, f, u.PrepareFetch(l, f))
+}
 
-	fetchOp := u.PrepareFetch(l, f)
+// writeFile writes f using fetchOp, which is nil if preparing it failed.
+func writeFile(l *log.Logger, u util.Util, f types.File, fetchOp *util.FetchOp) error {
 	if fetchOp == nil {
 		return fmt.Errorf("failed to resolve file %q", f.Path)
 	}
@@ -87,6 +91,11 @@ func (tmp fileEntry) create(l *log.Logger, u util.Util) error {
 	); err != nil {
 		return fmt.Errorf("failed to create file %q: %v", fetchOp.Path, err)
 	}
+	action := summary.Created
+	if f.Append {
+		action = summary.Modified
+	}
+	l.Summary().Add(summary.File, f.Path, action, "")
 
 	return nil
 }
@@ -100,7 +109,7 @@ func (tmp dirEntry) getPath() string {
 func (tmp dirEntry) create(l *log.Logger, u util.Util) error {
 	d := types.Directory(tmp)
 
-	err := l.LogOp(func() error {
+	err := l.LogChange(func() error {
 		path, err := u.JoinPath(string(d.Path))
 		if err != nil {
 			return err
@@ -152,6 +161,7 @@ func (tmp dirEntry) create(l *log.Logger, u util.Util) error {
 	if err != nil {
 		return fmt.Errorf("failed to create directory %q: %v", d.Path, err)
 	}
+	l.Summary().Add(summary.Directory, d.Path, summary.Created, "")
 
 	return nil
 }
@@ -165,7 +175,7 @@ func (tmp linkEntry) getPath() string {
 func (tmp linkEntry) create(l *log.Logger, u util.Util) error {
 	s := types.Link(tmp)
 
-	if err := l.LogOp(
+	if err := l.LogChange(
 		func() error {
 			err := u.DeletePathOnOverwrite(s.Node)
 			if err != nil {
@@ -177,6 +187,7 @@ func (tmp linkEntry) create(l *log.Logger, u util.Util) error {
 	); err != nil {
 		return fmt.Errorf("failed to create link %q: %v", s.Path, err)
 	}
+	l.Summary().Add(summary.Link, s.Path, summary.Created, s.Target)
 
 	return nil
 }
@@ -249,6 +260,11 @@ func (s stage) mapEntriesToFilesystems(config types.Config) (map[types.Filesyste
 }
 
 func (s *stage) mountAuto(dev, mnt string) error {
+	if s.Logger.DryRun() {
+		s.Logger.LogPlanned("mounting %q at %q", dev, mnt)
+		return nil
+	}
+
 	var err error
 	// try to mount all possible formats from config/v2_*/types/filesystem.go (without "swap")
 	formats := []string{"ext4", "btrfs", "xfs", "vfat"}
@@ -282,7 +298,7 @@ func (s *stage) createEntries(fs types.Filesystem, files []filesystemEntry) erro
 		if err := s.mountAuto(dev, mnt); err != nil {
 			return err
 		}
-		defer s.Logger.LogOp(
+		defer s.Logger.LogChange(
 			func() error { return syscall.Unmount(mnt, 0) },
 			"unmounting %q at %q", dev, mnt,
 		)
@@ -298,7 +314,13 @@ func (s *stage) createEntries(fs types.Filesystem, files []filesystemEntry) erro
 		Logger:  s.Logger,
 	}
 
-	for _, e := range files {
+	p := s.prefetchFiles(u, files)
+	defer p.close()
+
+	for i, e := range files {
+		if pf := p.wait(i); pf != nil {
+			e = pf
+		}
 		path := e.getPath()
 		// only relabel things on the root filesystem
 		if fs.Name == "root" && s.relabeling() {
@@ -326,6 +348,7 @@ func (s *stage) createEntries(fs types.Filesystem, files []filesystemEntry) erro
 		if err := e.create(s.Logger, u); err != nil {
 			return err
 		}
+		p.release(i)
 	}
 	return nil
 }
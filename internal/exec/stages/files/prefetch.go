@@ -0,0 +1,182 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package files
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+
+	"github.com/flatcar-linux/ignition/internal/config/types"
+	"github.com/flatcar-linux/ignition/internal/exec/util"
+	"github.com/flatcar-linux/ignition/internal/log"
+)
+
+// prefetcher fetches the contents of remote files in the background while
+// createEntries works through its entries. Entries are still created one at a
+// time and in order; only the fetches overlap. Each prefetched file holds one
+// of a bounded number of slots from the start of its fetch until it has been
+// written, which limits both the number of parallel fetches and the number of
+// fetched files waiting on disk.
+type prefetcher struct {
+	files map[int]*prefetchedFile
+	dir   string
+	slots chan struct{}
+	stop  chan struct{}
+}
+
+// prefetchedFile is a filesystemEntry for a file whose contents are fetched
+// by a prefetcher.
+type prefetchedFile struct {
+	file types.File
+	op   *util.FetchOp
+	done chan struct{}
+}
+
+func (pf *prefetchedFile) getPath() string {
+	return pf.file.Path
+}
+
+func (pf *prefetchedFile) create(l *log.Logger, u util.Util) error {
+	return writeFile(l, u, pf.file, pf.op)
+}
+
+// prefetchable returns whether the contents of f are worth fetching in the
+// background. data URLs are already in memory and oem URLs may need to mount
+// the OEM partition, which can't be done in parallel.
+func prefetchable(f types.File) bool {
+	uri, err := url.Parse(f.Contents.Source)
+	if err != nil {
+		return false
+	}
+	switch uri.Scheme {
+	case "http", "https", "tftp", "s3", "gs":
+		return true
+	default:
+		return false
+	}
+}
+
+// prefetchFiles starts fetching the remote files among entries. It returns
+// nil, which is safe to use, if there is nothing to prefetch or parallel
+// fetching is disabled.
+func (s *stage) prefetchFiles(u util.Util, entries []filesystemEntry) *prefetcher {
+	limit := u.Fetcher.Concurrency
+	if limit < 2 || s.Logger.DryRun() {
+		return nil
+	}
+
+	p := &prefetcher{
+		files: map[int]*prefetchedFile{},
+		slots: make(chan struct{}, limit),
+		stop:  make(chan struct{}),
+	}
+	var order []int
+	for i, e := range entries {
+		f, ok := e.(fileEntry)
+		if !ok || !prefetchable(types.File(f)) {
+			continue
+		}
+		p.files[i] = &prefetchedFile{
+			file: types.File(f),
+			done: make(chan struct{}),
+		}
+		order = append(order, i)
+	}
+	if len(order) == 0 {
+		return nil
+	}
+
+	// The contents are fetched onto the target rather than into the
+	// initramfs's memory, so they can be renamed into place.
+	var err error
+	if p.dir, err = ioutil.TempDir(u.DestDir, ".ignition-prefetch"); err != nil {
+		s.Logger.Info("not fetching files in parallel: %v", err)
+		return nil
+	}
+
+	// The stage keeps pushing and popping prefixes on its logger while the
+	// fetches run, so they log through a snapshot of it instead.
+	l := s.Logger.Copy()
+	go func() {
+		for _, i := range order {
+			select {
+			case p.slots <- struct{}{}:
+				go p.fetch(u, l, p.files[i])
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+	return p
+}
+
+// fetch prepares and fetches pf. If the fetch fails, pf is left to be fetched
+// again when it is created so that the failure is reported in order.
+func (p *prefetcher) fetch(u util.Util, l *log.Logger, pf *prefetchedFile) {
+	defer close(pf.done)
+
+	l = l.Copy()
+	u.Logger = l
+	u.Fetcher.SetLogger(l)
+
+	pf.op = u.PrepareFetch(l, pf.file)
+	if pf.op == nil {
+		return
+	}
+	if err := u.Prefetch(pf.op, p.dir); err != nil {
+		l.Info("prefetching file %q failed, will fetch it again: %v", pf.file.Path, err)
+	}
+}
+
+// wait waits for the fetch of entry i to finish and returns it, or returns
+// nil if entry i isn't being prefetched.
+func (p *prefetcher) wait(i int) filesystemEntry {
+	if p == nil {
+		return nil
+	}
+	pf, ok := p.files[i]
+	if !ok {
+		return nil
+	}
+	<-pf.done
+	return pf
+}
+
+// release removes the fetched contents of entry i, which must have been
+// waited for, and frees its slot.
+func (p *prefetcher) release(i int) {
+	if p == nil {
+		return
+	}
+	pf, ok := p.files[i]
+	if !ok {
+		return
+	}
+	if pf.op != nil && pf.op.Prefetched != "" {
+		os.Remove(pf.op.Prefetched)
+	}
+	<-p.slots
+}
+
+// close stops starting new fetches and removes all fetched contents, including
+// those of fetches which are still running.
+func (p *prefetcher) close() {
+	if p == nil {
+		return
+	}
+	close(p.stop)
+	os.RemoveAll(p.dir)
+}
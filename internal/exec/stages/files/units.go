@@ -15,11 +15,13 @@
 package files
 
 import (
+	"fmt"
 	"path/filepath"
 
 	"github.com/flatcar-linux/ignition/internal/config/types"
 	"github.com/flatcar-linux/ignition/internal/distro"
 	"github.com/flatcar-linux/ignition/internal/exec/util"
+	"github.com/flatcar-linux/ignition/internal/summary"
 )
 
 // createUnits creates the units listed under systemd.units and networkd.units.
@@ -31,39 +33,43 @@ func (s *stage) createUnits(config types.Config) error {
 		}
 		if unit.Enable {
 			s.Logger.Warning("the enable field has been deprecated in favor of enabled")
-			if err := s.Logger.LogOp(
+			if err := s.Logger.LogChange(
 				func() error { return s.EnableUnit(unit) },
 				"enabling unit %q", unit.Name,
 			); err != nil {
 				return err
 			}
+			s.Logger.Summary().Add(summary.Unit, unit.Name, summary.Enabled, "")
 			enabledOneUnit = true
 		}
 		if unit.Enabled != nil {
 			if *unit.Enabled {
-				if err := s.Logger.LogOp(
+				if err := s.Logger.LogChange(
 					func() error { return s.EnableUnit(unit) },
 					"enabling unit %q", unit.Name,
 				); err != nil {
 					return err
 				}
+				s.Logger.Summary().Add(summary.Unit, unit.Name, summary.Enabled, "")
 			} else {
-				if err := s.Logger.LogOp(
+				if err := s.Logger.LogChange(
 					func() error { return s.DisableUnit(unit) },
 					"disabling unit %q", unit.Name,
 				); err != nil {
 					return err
 				}
+				s.Logger.Summary().Add(summary.Unit, unit.Name, summary.Disabled, "")
 			}
 			enabledOneUnit = true
 		}
 		if unit.Mask {
-			if err := s.Logger.LogOp(
+			if err := s.Logger.LogChange(
 				func() error { return s.MaskUnit(unit) },
 				"masking unit %q", unit.Name,
 			); err != nil {
 				return err
 			}
+			s.Logger.Summary().Add(summary.Unit, unit.Name, summary.Masked, "")
 		}
 	}
 	// and relabel the preset file itself if we enabled/disabled something
@@ -106,6 +112,7 @@ func (s *stage) writeSystemdUnit(unit types.Unit, runtime bool) error {
 			); err != nil {
 				return err
 			}
+			s.Logger.Summary().Add(summary.Unit, unit.Name, summary.Created, fmt.Sprintf("drop-in %q", dropin.Name))
 			if !relabeledDropinDir {
 				s.relabel(filepath.Dir("/" + f.Path))
 				relabeledDropinDir = true
@@ -127,6 +134,7 @@ func (s *stage) writeSystemdUnit(unit types.Unit, runtime bool) error {
 		); err != nil {
 			return err
 		}
+		s.Logger.Summary().Add(summary.Unit, unit.Name, summary.Created, "")
 		s.relabel("/" + f.Path)
 
 		return nil
@@ -154,6 +162,7 @@ func (s *stage) writeNetworkdUnit(unit types.Networkdunit) error {
 			); err != nil {
 				return err
 			}
+			s.Logger.Summary().Add(summary.Unit, unit.Name, summary.Created, fmt.Sprintf("drop-in %q", dropin.Name))
 			s.relabel("/" + f.Path)
 		}
 		if unit.Contents == "" {
@@ -171,6 +180,7 @@ func (s *stage) writeNetworkdUnit(unit types.Networkdunit) error {
 		); err != nil {
 			return err
 		}
+		s.Logger.Summary().Add(summary.Unit, unit.Name, summary.Created, "")
 		s.relabel("/" + f.Path)
 
 		return nil
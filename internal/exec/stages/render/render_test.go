@@ -0,0 +1,56 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/flatcar-linux/ignition/internal/config/types"
+	"github.com/flatcar-linux/ignition/internal/exec/util"
+	"github.com/flatcar-linux/ignition/internal/log"
+)
+
+func TestRun(t *testing.T) {
+	root := "/sysroot"
+	in := types.Config{
+		Ignition: types.Ignition{Version: types.MaxVersion.String()},
+		Storage: types.Storage{
+			Filesystems: []types.Filesystem{{Name: "root", Path: &root}},
+			Files: []types.File{{
+				Node:          types.Node{Filesystem: "root", Path: "/etc/hostname"},
+				FileEmbedded1: types.FileEmbedded1{Contents: types.FileContents{Source: "data:,example"}},
+			}},
+		},
+	}
+
+	logger := log.New(true)
+	defer logger.Close()
+	var buf bytes.Buffer
+	s := stage{Util: util.Util{Logger: &logger}, out: &buf}
+	if err := s.Run(in); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var out types.Config
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("output isn't a config: %v", err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("expected %+v, got %+v", in, out)
+	}
+}
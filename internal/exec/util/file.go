@@ -16,6 +16,7 @@ package util
 
 import (
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"hash"
 	"io"
@@ -46,6 +47,12 @@ type FetchOp struct {
 	Overwrite    *bool
 	Append       bool
 	Node         types.Node
+
+	// Prefetched is the path of a file already holding the verified contents
+	// of Url (see Prefetch). If set, PerformFetch renames it into place, or
+	// copies it if it is on another filesystem, instead of fetching Url
+	// again.
+	Prefetched string
 }
 
 // newHashedReader returns a new ReadCloser that also writes to the provided hash.
@@ -88,7 +95,7 @@ func (u Util) PrepareFetch(l *log.Logger, f types.File) *FetchOp {
 		}
 	}
 
-	return &FetchOp{
+	op := &FetchOp{
 		Path:      f.Path,
 		Hash:      hasher,
 		Node:      f.Node,
@@ -97,11 +104,37 @@ func (u Util) PrepareFetch(l *log.Logger, f types.File) *FetchOp {
 		Overwrite: f.Overwrite,
 		Append:    f.Append,
 		FetchOptions: resource.FetchOptions{
+			Headers:     resource.MergeHeaders(nil, f.Contents.HTTPHeaders),
 			Hash:        hasher,
 			Compression: f.Contents.Compression,
 			ExpectedSum: expectedSum,
 		},
 	}
+	if f.Contents.Decryption != nil {
+		op.FetchOptions.DecryptionKey = f.Contents.Decryption.Key
+	}
+	return op
+}
+
+// FetchKeyFile fetches the key file k into memory, verifying it against its
+// expected hash.
+func (u Util) FetchKeyFile(k types.KeyFile) ([]byte, error) {
+	// explicitly ignoring the error here because the config should already be
+	// validated by this point
+	uri, _ := url.Parse(k.Source)
+
+	key, err := u.Fetcher.FetchToBuffer(*uri, resource.FetchOptions{
+		Headers: resource.MergeHeaders(nil, k.HTTPHeaders),
+	})
+	if err != nil {
+		return nil, err
+	}
+	// verified here rather than by the fetcher, which would store keys with
+	// an expected sum in the download cache
+	if err := util.AssertValid(k.Verification, key); err != nil {
+		return nil, err
+	}
+	return key, nil
 }
 
 func (u Util) WriteLink(s types.Link) error {
@@ -163,6 +196,9 @@ func (u Util) PerformFetch(f *FetchOp) error {
 			return fmt.Errorf("error creating %q: something else exists at that path", f.Path)
 		}
 	}
+	if u.DryRun() {
+		return u.planFetch(f, path)
+	}
 	if f.Overwrite == nil && !f.Append {
 		// For files, overwrite defaults to true if append is false. If
 		// overwrite wasn't specified, delete the path.
@@ -176,6 +212,13 @@ func (u Util) PerformFetch(f *FetchOp) error {
 		return err
 	}
 
+	if f.Prefetched != "" && !f.Append {
+		err := u.renamePrefetched(f, path)
+		if err == nil || !errors.Is(err, syscall.EXDEV) {
+			return err
+		}
+	}
+
 	// Create a temporary file in the same directory to ensure it's on the same filesystem
 	var tmp *os.File
 	if tmp, err = ioutil.TempFile(filepath.Dir(path), "tmp"); err != nil {
@@ -187,7 +230,7 @@ func (u Util) PerformFetch(f *FetchOp) error {
 	// but that's ok (we wanted to keep the file in that case).
 	defer os.Remove(tmp.Name())
 
-	err = u.Fetcher.Fetch(f.Url, tmp, f.FetchOptions)
+	err = u.fetch(f, tmp)
 	if err != nil {
 		u.Crit("Error fetching file %q: %v", f.Path, err)
 		return err
@@ -242,33 +285,134 @@ func (u Util) PerformFetch(f *FetchOp) error {
 		// by using syscall.Fchown() and syscall.Fchmod()
 
 		// Ensure the ownership and mode are as requested (since WriteFile can be affected by sticky bit)
-
-		mode := os.FileMode(0)
-		if f.Mode != nil {
-			mode = os.FileMode(*f.Mode)
-		}
-
-		uid, gid, err := u.ResolveNodeUidAndGid(f.Node, 0, 0)
-		if err != nil {
+		if err = u.setOwnerAndMode(f, tmp.Name()); err != nil {
 			return err
 		}
 
-		if err = os.Chown(tmp.Name(), uid, gid); err != nil {
+		if err = os.Rename(tmp.Name(), path); err != nil {
 			return err
 		}
+	}
 
-		if err = os.Chmod(tmp.Name(), mode); err != nil {
-			return err
-		}
+	return nil
+}
 
-		if err = os.Rename(tmp.Name(), path); err != nil {
-			return err
-		}
+// renamePrefetched moves the prefetched contents of f to path with the
+// requested owner and mode. It fails with EXDEV if they are on another
+// filesystem.
+func (u Util) renamePrefetched(f *FetchOp, path string) error {
+	if err := u.setOwnerAndMode(f, f.Prefetched); err != nil {
+		return err
+	}
+	return os.Rename(f.Prefetched, path)
+}
+
+// setOwnerAndMode sets the owner and mode of the file name, which holds the
+// contents of f, to those requested for f.
+func (u Util) setOwnerAndMode(f *FetchOp, name string) error {
+	mode := os.FileMode(0)
+	if f.Mode != nil {
+		mode = os.FileMode(*f.Mode)
 	}
 
+	uid, gid, err := u.ResolveNodeUidAndGid(f.Node, 0, 0)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Chown(name, uid, gid); err != nil {
+		return err
+	}
+	return os.Chmod(name, mode)
+}
+
+// Prefetch fetches and verifies the contents of f into a temporary file in dir,
+// which should be on the filesystem the file is created on, and sets
+// f.Prefetched so a later PerformFetch can use them. The caller must remove
+// f.Prefetched once it is no longer needed. On failure, f is left
+// unchanged so PerformFetch fetches (and reports the failure) as usual.
+func (u Util) Prefetch(f *FetchOp, dir string) error {
+	tmp, err := ioutil.TempFile(dir, "prefetch")
+	if err != nil {
+		return err
+	}
+	defer tmp.Close()
+
+	if err := u.Fetcher.Fetch(f.Url, tmp, f.FetchOptions); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	f.Prefetched = tmp.Name()
+	return nil
+}
+
+// fetch writes the contents of f to dest, copying them from f.Prefetched if
+// they were already fetched.
+func (u Util) fetch(f *FetchOp, dest *os.File) error {
+	if f.Prefetched == "" {
+		return u.Fetcher.Fetch(f.Url, dest, f.FetchOptions)
+	}
+	src, err := os.Open(f.Prefetched)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	_, err = io.Copy(dest, src)
+	return err
+}
+
+// planFetch fetches and verifies the contents of f into a scratch file and
+// records the write in the plan without touching path.
+func (u Util) planFetch(f *FetchOp, path string) error {
+	tmp, err := ioutil.TempFile("", "ignition-dry-run")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if err := u.fetch(f, tmp); err != nil {
+		u.Crit("Error fetching file %q: %v", f.Path, err)
+		return err
+	}
+	info, err := tmp.Stat()
+	if err != nil {
+		return err
+	}
+
+	mode := os.FileMode(0)
+	if f.Mode != nil {
+		mode = os.FileMode(*f.Mode)
+	}
+	action := "write"
+	if f.Append {
+		action = "append"
+	}
+	u.LogPlanned("%s %d bytes to %q (mode %#o%s)", action, info.Size(), path, mode, describeOwner(f.Node))
 	return nil
 }
 
+// describeOwner returns a description of the owner requested by n, suitable
+// for appending to a plan entry.
+func describeOwner(n types.Node) string {
+	var s string
+	if n.User != nil {
+		if n.User.ID != nil {
+			s += fmt.Sprintf(", user %d", *n.User.ID)
+		} else if n.User.Name != "" {
+			s += fmt.Sprintf(", user %q", n.User.Name)
+		}
+	}
+	if n.Group != nil {
+		if n.Group.ID != nil {
+			s += fmt.Sprintf(", group %d", *n.Group.ID)
+		} else if n.Group.Name != "" {
+			s += fmt.Sprintf(", group %q", n.Group.Name)
+		}
+	}
+	return s
+}
+
 // MkdirForFile helper creates the directory components of path.
 func MkdirForFile(path string) error {
 	return os.MkdirAll(filepath.Dir(path), DefaultDirectoryPermissions)
@@ -367,5 +511,11 @@ func (u Util) DeletePathOnOverwrite(n types.Node) error {
 	if err != nil {
 		return err
 	}
+	if u.DryRun() {
+		if _, err := os.Lstat(path); err == nil {
+			u.LogPlanned("removing %q", path)
+		}
+		return nil
+	}
 	return os.RemoveAll(path)
 }
@@ -0,0 +1,82 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/flatcar-linux/ignition/internal/config/types"
+	"github.com/flatcar-linux/ignition/internal/log"
+)
+
+func TestPerformFetchPrefetched(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ignition-file-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	prefetchDir, err := ioutil.TempDir(dir, ".ignition-prefetch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefetched := filepath.Join(prefetchDir, "prefetch")
+	if err := ioutil.WriteFile(prefetched, []byte("contents"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.Stat(prefetched)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger := log.New(true)
+	defer logger.Close()
+	u := Util{DestDir: dir, Logger: &logger}
+	mode := 0640
+	uid, gid := os.Getuid(), os.Getgid()
+	err = u.PerformFetch(&FetchOp{
+		Path: "/etc/secret",
+		Mode: &mode,
+		Node: types.Node{
+			User:  &types.NodeUser{ID: &uid},
+			Group: &types.NodeGroup{ID: &gid},
+		},
+		Prefetched: prefetched,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "etc/secret")
+	after, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !os.SameFile(before, after) {
+		t.Error("prefetched contents were copied rather than renamed")
+	}
+	if after.Mode().Perm() != 0640 {
+		t.Errorf("bad mode: want %o, got %o", 0640, after.Mode().Perm())
+	}
+	if contents, err := ioutil.ReadFile(path); err != nil || string(contents) != "contents" {
+		t.Errorf("bad contents: %q, %v", contents, err)
+	}
+	if _, err := os.Stat(prefetched); !os.IsNotExist(err) {
+		t.Errorf("prefetched file still exists: %v", err)
+	}
+}
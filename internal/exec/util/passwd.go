@@ -25,6 +25,7 @@ import (
 	"github.com/flatcar-linux/ignition/internal/config/types"
 	"github.com/flatcar-linux/ignition/internal/distro"
 	"github.com/flatcar-linux/ignition/internal/log"
+	"github.com/flatcar-linux/ignition/internal/summary"
 )
 
 // EnsureUser ensures that the user exists as described. If the user does not
@@ -33,7 +34,11 @@ import (
 func (u Util) EnsureUser(c types.PasswdUser) error {
 	exists, err := u.CheckIfUserExists(c)
 	if err != nil {
-		return err
+		if !u.DryRun() {
+			return err
+		}
+		// the target root might not be usable yet during a dry run
+		u.Warning("assuming user %q does not exist: %v", c.Name, err)
 	}
 	if c.Create != nil {
 		cu := c.Create
@@ -118,9 +123,16 @@ func (u Util) EnsureUser(c types.PasswdUser) error {
 
 	args = append(args, c.Name)
 
-	_, err = u.LogCmd(exec.Command(cmd, args...),
-		"creating or modifying user %q", c.Name)
-	return err
+	if _, err := u.LogCmd(exec.Command(cmd, args...),
+		"creating or modifying user %q", c.Name); err != nil {
+		return err
+	}
+	if exists {
+		u.Summary().Add(summary.User, c.Name, summary.Modified, "")
+	} else {
+		u.Summary().Add(summary.User, c.Name, summary.Created, "")
+	}
+	return nil
 }
 
 // golang--
@@ -166,7 +178,7 @@ func (u Util) AuthorizeSSHKeys(c types.PasswdUser) error {
 		return nil
 	}
 
-	return u.LogOp(func() error {
+	return u.LogChange(func() error {
 		usr, err := u.userLookup(c.Name)
 		if err != nil {
 			return fmt.Errorf("unable to lookup user %q", c.Name)
@@ -254,7 +266,10 @@ func (u Util) CreateGroup(g types.PasswdGroup) error {
 
 	args = append(args, g.Name)
 
-	_, err := u.LogCmd(exec.Command(distro.GroupaddCmd(), args...),
-		"adding group %q", g.Name)
-	return err
+	if _, err := u.LogCmd(exec.Command(distro.GroupaddCmd(), args...),
+		"adding group %q", g.Name); err != nil {
+		return err
+	}
+	u.Summary().Add(summary.Group, g.Name, summary.Created, "")
+	return nil
 }
@@ -0,0 +1,126 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gpt
+
+import (
+	"io"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"github.com/flatcar-linux/ignition/config/util"
+	"github.com/flatcar-linux/ignition/internal/config/types"
+)
+
+// These constants come from <linux/fs.h>.
+const (
+	BLKRRPART = 0x125f
+	BLKSSZGET = 0x1268
+)
+
+// defaultSectorSize is the sector size of disk images.
+const defaultSectorSize = 512
+
+// geometry returns the size in bytes and the logical sector size of f, which
+// is a block device or a disk image.
+func geometry(f *os.File) (int64, int, error) {
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, 0, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return 0, 0, err
+	}
+	if info.Mode()&os.ModeDevice == 0 {
+		return size, defaultSectorSize, nil
+	}
+
+	var sectorSize int32
+	if _, _, errno := syscall.Syscall(
+		syscall.SYS_IOCTL,
+		uintptr(f.Fd()),
+		uintptr(BLKSSZGET),
+		uintptr(unsafe.Pointer(&sectorSize)),
+	); errno != 0 {
+		return 0, 0, errno
+	}
+	return size, int(sectorSize), nil
+}
+
+// rereadPartitions asks the kernel to reread the partition table of f. This
+// fails with EBUSY if partitions of the disk are in use. Disk images are
+// skipped.
+func rereadPartitions(f *os.File) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeDevice == 0 {
+		return nil
+	}
+	if _, _, errno := syscall.Syscall(
+		syscall.SYS_IOCTL,
+		uintptr(f.Fd()),
+		uintptr(BLKRRPART),
+		uintptr(0),
+	); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// ReadTable reads the table of device, which is a block device or a disk image.
+func ReadTable(device string) (*Table, error) {
+	f, err := os.Open(device)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	size, sectorSize, err := geometry(f)
+	if err != nil {
+		return nil, err
+	}
+	return Read(f, size, sectorSize)
+}
+
+// DumpPartitionTable returns a list of all partitions on device (e.g. /dev/vda),
+// sorted by number. A disk without a partition table has no partitions.
+func DumpPartitionTable(device string) ([]types.Partition, error) {
+	t, err := ReadTable(device)
+	if err != nil {
+		return nil, err
+	}
+
+	output := []types.Partition{}
+	for _, p := range t.Partitions {
+		output = append(output, toConfig(p))
+	}
+	return output, nil
+}
+
+// toConfig converts p to a config partition.
+func toConfig(p Partition) types.Partition {
+	label := p.Label
+	return types.Partition{
+		Label:    &label,
+		GUID:     p.GUID,
+		TypeGUID: p.TypeGUID,
+		Number:   p.Number,
+		Start:    util.IntToPtr(int(p.Start)),
+		Size:     util.IntToPtr(int(p.Size)),
+	}
+}
@@ -0,0 +1,501 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gpt reads and writes GUID partition tables in-process, as laid out
+// in chapter 5 of the UEFI specification: a protective MBR, a primary header
+// and partition entry array at the start of the disk and their backup copies
+// at its end, each checked with a CRC32. Its Operation mirrors the Operation
+// API of the sgdisk package.
+package gpt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/pborman/uuid"
+)
+
+const (
+	mbrSize         = 512
+	bootCodeSize    = 440
+	mbrEntriesStart = 446
+	mbrEntrySize    = 16
+	protectiveType  = 0xee
+
+	headerSignature = "EFI PART"
+	headerRevision  = 0x00010000
+	headerSize      = 92
+	entrySize       = 128
+	defaultEntries  = 128
+	// maxEntries bounds the entry array read from untrusted headers
+	maxEntries = 4096
+	// labelLength is the length of the partition name in UTF-16 code units
+	labelLength = 36
+
+	// alignment is the alignment of new partitions in bytes, 1MiB as with sgdisk
+	alignment = 1024 * 1024
+
+	// DefaultTypeGUID is the type of partitions created without a type
+	// GUID, "Linux filesystem data" as with sgdisk.
+	DefaultTypeGUID = "0FC63DAF-8483-4772-8E79-3D69D8477DE4"
+)
+
+var (
+	ErrNotGpt       = errors.New("disk has a partition table other than gpt")
+	ErrCorruptTable = errors.New("primary and backup gpt are both corrupt")
+	ErrDiskTooSmall = errors.New("disk is too small for a gpt")
+	ErrTableFull    = errors.New("no free partition entries")
+)
+
+// Table is a GUID partition table.
+type Table struct {
+	SectorSize int
+	// Sectors is the size of the disk in logical sectors.
+	Sectors  int64
+	DiskGUID string
+	// Entries is the number of slots in the partition entry array, and
+	// thus the highest possible partition number.
+	Entries     int
+	FirstUsable int64
+	LastUsable  int64
+	// Partitions are the used entries, sorted by number.
+	Partitions []Partition
+
+	mbr [mbrSize]byte
+}
+
+// Partition is an entry of a Table. Start and Size are in logical sectors.
+type Partition struct {
+	Number     int
+	TypeGUID   string
+	GUID       string
+	Label      string
+	Start      int64
+	Size       int64
+	Attributes uint64
+}
+
+// End returns the last sector of p.
+func (p Partition) End() int64 {
+	return p.Start + p.Size - 1
+}
+
+// header is the part of a gpt header needed to locate and verify the
+// partition entry array.
+type header struct {
+	myLBA       int64
+	firstUsable int64
+	lastUsable  int64
+	diskGUID    string
+	entriesLBA  int64
+	entries     int
+	entrySize   int
+	entriesCRC  uint32
+}
+
+// New returns an empty table for a disk of size bytes.
+func New(size int64, sectorSize int) (*Table, error) {
+	t := &Table{
+		SectorSize: sectorSize,
+		Sectors:    size / int64(sectorSize),
+		DiskGUID:   strings.ToUpper(uuid.NewRandom().String()),
+		Entries:    defaultEntries,
+	}
+	t.FirstUsable = 2 + t.entriesSectors()
+	if err := t.relocate(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Read reads the table of a disk of size bytes from r. The primary table is
+// used unless it is corrupt, in which case the backup at the end of the disk
+// is. A disk without either is returned as an empty table, unless its MBR
+// has partitions, i.e. it has a dos partition table.
+//
+// The table is laid out for the disk's size: if the disk has grown since the
+// table was written, the backup is moved to the new end of the disk and the
+// space up to it becomes usable.
+func Read(r io.ReaderAt, size int64, sectorSize int) (*Table, error) {
+	var mbr [mbrSize]byte
+	if _, err := r.ReadAt(mbr[:], 0); err != nil {
+		return nil, err
+	}
+
+	sectors := size / int64(sectorSize)
+	h, entries, primaryErr := readHeader(r, 1, sectorSize)
+	if primaryErr != nil {
+		var backupErr error
+		h, entries, backupErr = readHeader(r, sectors-1, sectorSize)
+		if backupErr != nil {
+			if primaryErr == errNoHeader && backupErr == errNoHeader {
+				if mbrHasPartitions(mbr) {
+					return nil, ErrNotGpt
+				}
+				t, err := New(size, sectorSize)
+				if err != nil {
+					return nil, err
+				}
+				copy(t.mbr[:bootCodeSize], mbr[:bootCodeSize])
+				return t, nil
+			}
+			return nil, ErrCorruptTable
+		}
+	}
+
+	t := &Table{
+		SectorSize:  sectorSize,
+		Sectors:     sectors,
+		DiskGUID:    h.diskGUID,
+		Entries:     h.entries,
+		FirstUsable: h.firstUsable,
+		mbr:         mbr,
+	}
+	for i := 0; i < h.entries; i++ {
+		if p, ok := decodeEntry(entries[i*h.entrySize : i*h.entrySize+entrySize]); ok {
+			p.Number = i + 1
+			t.Partitions = append(t.Partitions, p)
+		}
+	}
+	if least := 2 + t.entriesSectors(); t.FirstUsable < least {
+		t.FirstUsable = least
+	}
+	if err := t.relocate(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+var errNoHeader = errors.New("no gpt header")
+
+// readHeader reads and verifies the header at lba and its partition entry array.
+func readHeader(r io.ReaderAt, lba int64, sectorSize int) (header, []byte, error) {
+	buf := make([]byte, sectorSize)
+	if lba < 1 {
+		return header{}, nil, errNoHeader
+	}
+	if _, err := r.ReadAt(buf, lba*int64(sectorSize)); err != nil {
+		return header{}, nil, errNoHeader
+	}
+	if string(buf[:8]) != headerSignature {
+		return header{}, nil, errNoHeader
+	}
+
+	le := binary.LittleEndian
+	size := int(le.Uint32(buf[12:16]))
+	if size < headerSize || size > sectorSize {
+		return header{}, nil, fmt.Errorf("bad gpt header size %d", size)
+	}
+	crc := le.Uint32(buf[16:20])
+	le.PutUint32(buf[16:20], 0)
+	if crc32.ChecksumIEEE(buf[:size]) != crc {
+		return header{}, nil, fmt.Errorf("bad gpt header checksum at sector %d", lba)
+	}
+
+	h := header{
+		myLBA:       int64(le.Uint64(buf[24:32])),
+		firstUsable: int64(le.Uint64(buf[40:48])),
+		lastUsable:  int64(le.Uint64(buf[48:56])),
+		diskGUID:    decodeGUID(buf[56:72]),
+		entriesLBA:  int64(le.Uint64(buf[72:80])),
+		entries:     int(le.Uint32(buf[80:84])),
+		entrySize:   int(le.Uint32(buf[84:88])),
+		entriesCRC:  le.Uint32(buf[88:92]),
+	}
+	if h.myLBA != lba {
+		return header{}, nil, fmt.Errorf("gpt header at sector %d claims to be at %d", lba, h.myLBA)
+	}
+	if h.entries < 1 || h.entries > maxEntries || h.entrySize < entrySize || h.entrySize%entrySize != 0 {
+		return header{}, nil, fmt.Errorf("bad gpt partition entry array of %d entries of %d bytes", h.entries, h.entrySize)
+	}
+
+	entries := make([]byte, h.entries*h.entrySize)
+	if _, err := r.ReadAt(entries, h.entriesLBA*int64(sectorSize)); err != nil {
+		return header{}, nil, fmt.Errorf("reading gpt partition entries: %v", err)
+	}
+	if crc32.ChecksumIEEE(entries) != h.entriesCRC {
+		return header{}, nil, fmt.Errorf("bad gpt partition entries checksum at sector %d", h.entriesLBA)
+	}
+	return h, entries, nil
+}
+
+// Write writes t, i.e. a protective MBR, the primary and the backup table, to w.
+// An existing protective or hybrid MBR is kept, otherwise a protective MBR is
+// written with the boot code of the existing one.
+func (t *Table) Write(w io.WriterAt) error {
+	if err := t.check(); err != nil {
+		return err
+	}
+
+	entries := make([]byte, t.entriesSectors()*int64(t.SectorSize))
+	for _, p := range t.Partitions {
+		if err := encodeEntry(entries[(p.Number-1)*entrySize:p.Number*entrySize], p); err != nil {
+			return err
+		}
+	}
+	entriesCRC := crc32.ChecksumIEEE(entries[:t.Entries*entrySize])
+
+	backupLBA := t.Sectors - 1
+	backupEntriesLBA := backupLBA - t.entriesSectors()
+	primary, err := t.encodeHeader(1, backupLBA, 2, entriesCRC)
+	if err != nil {
+		return err
+	}
+	backup, err := t.encodeHeader(backupLBA, 1, backupEntriesLBA, entriesCRC)
+	if err != nil {
+		return err
+	}
+
+	mbr := protectiveMBR(t.mbr, t.Sectors)
+	ss := int64(t.SectorSize)
+	for _, chunk := range []struct {
+		offset int64
+		data   []byte
+	}{
+		{0, mbr[:]},
+		{2 * ss, entries},
+		{ss, primary},
+		{backupEntriesLBA * ss, entries},
+		{backupLBA * ss, backup},
+	} {
+		if _, err := w.WriteAt(chunk.data, chunk.offset); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeHeader returns the sector of the header at lba.
+func (t *Table) encodeHeader(lba, alternateLBA, entriesLBA int64, entriesCRC uint32) ([]byte, error) {
+	guid, err := encodeGUID(t.DiskGUID)
+	if err != nil {
+		return nil, fmt.Errorf("disk GUID: %v", err)
+	}
+
+	le := binary.LittleEndian
+	buf := make([]byte, t.SectorSize)
+	copy(buf[0:8], headerSignature)
+	le.PutUint32(buf[8:12], headerRevision)
+	le.PutUint32(buf[12:16], headerSize)
+	le.PutUint64(buf[24:32], uint64(lba))
+	le.PutUint64(buf[32:40], uint64(alternateLBA))
+	le.PutUint64(buf[40:48], uint64(t.FirstUsable))
+	le.PutUint64(buf[48:56], uint64(t.LastUsable))
+	copy(buf[56:72], guid[:])
+	le.PutUint64(buf[72:80], uint64(entriesLBA))
+	le.PutUint32(buf[80:84], uint32(t.Entries))
+	le.PutUint32(buf[84:88], entrySize)
+	le.PutUint32(buf[88:92], entriesCRC)
+	le.PutUint32(buf[16:20], crc32.ChecksumIEEE(buf[:headerSize]))
+	return buf, nil
+}
+
+// entriesSectors returns the number of sectors taken by the partition entry array.
+func (t *Table) entriesSectors() int64 {
+	ss := int64(t.SectorSize)
+	return (int64(t.Entries)*entrySize + ss - 1) / ss
+}
+
+// relocate places the backup table at the end of the disk.
+func (t *Table) relocate() error {
+	t.LastUsable = t.Sectors - 2 - t.entriesSectors()
+	if t.LastUsable < t.FirstUsable {
+		return ErrDiskTooSmall
+	}
+	return nil
+}
+
+// check verifies that the partitions of t fit the disk and don't overlap.
+func (t *Table) check() error {
+	sort.Slice(t.Partitions, func(i, j int) bool { return t.Partitions[i].Number < t.Partitions[j].Number })
+	for i, p := range t.Partitions {
+		if p.Number < 1 || p.Number > t.Entries {
+			return fmt.Errorf("partition number %d is out of range 1-%d", p.Number, t.Entries)
+		}
+		if i > 0 && t.Partitions[i-1].Number == p.Number {
+			return fmt.Errorf("partition %d is defined twice", p.Number)
+		}
+		if p.Size < 1 || p.Start < t.FirstUsable || p.End() > t.LastUsable {
+			return fmt.Errorf("partition %d (sectors %d-%d) does not fit the disk (usable sectors %d-%d)", p.Number, p.Start, p.End(), t.FirstUsable, t.LastUsable)
+		}
+		for _, q := range t.Partitions[:i] {
+			if p.Start <= q.End() && q.Start <= p.End() {
+				return fmt.Errorf("partitions %d and %d overlap", q.Number, p.Number)
+			}
+		}
+	}
+	return nil
+}
+
+// Partition returns the partition numbered num.
+func (t *Table) Partition(num int) (Partition, bool) {
+	for _, p := range t.Partitions {
+		if p.Number == num {
+			return p, true
+		}
+	}
+	return Partition{}, false
+}
+
+func decodeEntry(b []byte) (Partition, bool) {
+	le := binary.LittleEndian
+	if bytes.Equal(b[0:16], make([]byte, 16)) {
+		return Partition{}, false
+	}
+	start := int64(le.Uint64(b[32:40]))
+	end := int64(le.Uint64(b[40:48]))
+
+	name := make([]uint16, 0, labelLength)
+	for i := 56; i < entrySize; i += 2 {
+		c := le.Uint16(b[i : i+2])
+		if c == 0 {
+			break
+		}
+		name = append(name, c)
+	}
+
+	return Partition{
+		TypeGUID:   decodeGUID(b[0:16]),
+		GUID:       decodeGUID(b[16:32]),
+		Start:      start,
+		Size:       end - start + 1,
+		Attributes: le.Uint64(b[48:56]),
+		Label:      string(utf16.Decode(name)),
+	}, true
+}
+
+func encodeEntry(b []byte, p Partition) error {
+	typeGUID, err := encodeGUID(p.TypeGUID)
+	if err != nil {
+		return fmt.Errorf("partition %d type GUID: %v", p.Number, err)
+	}
+	guid, err := encodeGUID(p.GUID)
+	if err != nil {
+		return fmt.Errorf("partition %d GUID: %v", p.Number, err)
+	}
+	name := utf16.Encode([]rune(p.Label))
+	if len(name) > labelLength {
+		return fmt.Errorf("partition %d label %q is longer than %d UTF-16 code units", p.Number, p.Label, labelLength)
+	}
+
+	le := binary.LittleEndian
+	copy(b[0:16], typeGUID[:])
+	copy(b[16:32], guid[:])
+	le.PutUint64(b[32:40], uint64(p.Start))
+	le.PutUint64(b[40:48], uint64(p.End()))
+	le.PutUint64(b[48:56], p.Attributes)
+	for i, c := range name {
+		le.PutUint16(b[56+2*i:58+2*i], c)
+	}
+	return nil
+}
+
+// encodeGUID converts a GUID in its textual form to its on-disk form, in
+// which the first three fields are little endian.
+func encodeGUID(s string) ([16]byte, error) {
+	var b [16]byte
+	u := uuid.Parse(s)
+	if u == nil {
+		return b, fmt.Errorf("invalid GUID %q", s)
+	}
+	copy(b[:], u)
+	b[0], b[1], b[2], b[3] = b[3], b[2], b[1], b[0]
+	b[4], b[5] = b[5], b[4]
+	b[6], b[7] = b[7], b[6]
+	return b, nil
+}
+
+// decodeGUID is the inverse of encodeGUID. GUIDs are returned in upper case.
+func decodeGUID(on []byte) string {
+	var b [16]byte
+	copy(b[:], on)
+	b[0], b[1], b[2], b[3] = b[3], b[2], b[1], b[0]
+	b[4], b[5] = b[5], b[4]
+	b[6], b[7] = b[7], b[6]
+	return strings.ToUpper(uuid.UUID(b[:]).String())
+}
+
+// mbrHasPartitions returns whether mbr is a valid MBR with partitions other
+// than a protective one.
+func mbrHasPartitions(mbr [mbrSize]byte) bool {
+	if mbr[510] != 0x55 || mbr[511] != 0xaa {
+		return false
+	}
+	for i := 0; i < 4; i++ {
+		typ := mbr[mbrEntriesStart+i*mbrEntrySize+4]
+		if typ != 0 && typ != protectiveType {
+			return true
+		}
+	}
+	return false
+}
+
+// protectiveMBR returns mbr if it already protects the gpt, i.e. it is a
+// protective or a hybrid MBR, with its protective entry resized to the disk
+// of the given number of sectors, and otherwise a protective MBR keeping the
+// boot code of mbr.
+func protectiveMBR(mbr [mbrSize]byte, sectors int64) [mbrSize]byte {
+	if mbr[510] == 0x55 && mbr[511] == 0xaa {
+		for i := 0; i < 4; i++ {
+			if mbr[mbrEntriesStart+i*mbrEntrySize+4] == protectiveType {
+				resizeProtective(mbr[mbrEntriesStart:mbrEntriesStart+4*mbrEntrySize], i, sectors)
+				return mbr
+			}
+		}
+	}
+
+	var out [mbrSize]byte
+	copy(out[:bootCodeSize], mbr[:bootCodeSize])
+	e := out[mbrEntriesStart : mbrEntriesStart+mbrEntrySize]
+	// CHS addresses are unused; start at 0/0/2 and end at the maximum
+	e[1], e[2], e[3] = 0x00, 0x02, 0x00
+	e[4] = protectiveType
+	e[5], e[6], e[7] = 0xff, 0xff, 0xff
+	binary.LittleEndian.PutUint32(e[8:12], 1)
+	binary.LittleEndian.PutUint32(e[12:16], uint32(mbrSectors(sectors)-1))
+	out[510], out[511] = 0x55, 0xaa
+	return out
+}
+
+// mbrSectors returns the number of sectors of a disk an MBR can address.
+func mbrSectors(sectors int64) int64 {
+	if sectors > 0xffffffff+1 {
+		return 0xffffffff + 1
+	}
+	return sectors
+}
+
+// resizeProtective extends the protective entry num of the MBR partition
+// entries to the end of the disk, like sgdisk does after a disk has grown.
+// In a hybrid MBR, an entry followed by hybrid partitions is left alone.
+func resizeProtective(entries []byte, num int, sectors int64) {
+	e := entries[num*mbrEntrySize : (num+1)*mbrEntrySize]
+	start := int64(binary.LittleEndian.Uint32(e[8:12]))
+	for i := 0; i < 4; i++ {
+		other := entries[i*mbrEntrySize : (i+1)*mbrEntrySize]
+		if i != num && other[4] != 0 && int64(binary.LittleEndian.Uint32(other[8:12])) >= start {
+			return
+		}
+	}
+	if end := mbrSectors(sectors); end > start {
+		binary.LittleEndian.PutUint32(e[12:16], uint32(end-start))
+	}
+}
@@ -0,0 +1,341 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gpt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/flatcar-linux/ignition/config/util"
+	"github.com/flatcar-linux/ignition/internal/config/types"
+	"github.com/flatcar-linux/ignition/internal/log"
+)
+
+const mib = 1024 * 1024
+
+// newImage returns the path of a sparse disk image of size bytes.
+func newImage(t *testing.T, size int64) string {
+	f, err := ioutil.TempFile("", "ignition-gpt-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := f.Truncate(size); err != nil {
+		t.Fatal(err)
+	}
+	return f.Name()
+}
+
+func TestGUID(t *testing.T) {
+	// the EFI system partition type as stored on disk
+	onDisk := []byte{0x28, 0x73, 0x2a, 0xc1, 0x1f, 0xf8, 0xd2, 0x11, 0xba, 0x4b, 0x00, 0xa0, 0xc9, 0x3e, 0xc9, 0x3b}
+	text := "C12A7328-F81F-11D2-BA4B-00A0C93EC93B"
+
+	if got := decodeGUID(onDisk); got != text {
+		t.Errorf("decodeGUID: want %q, got %q", text, got)
+	}
+	got, err := encodeGUID("c12a7328-f81f-11d2-ba4b-00a0c93ec93b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got[:], onDisk) {
+		t.Errorf("encodeGUID: want % x, got % x", onDisk, got)
+	}
+	if _, err := encodeGUID("not-a-guid"); err == nil {
+		t.Error("encodeGUID accepted an invalid GUID")
+	}
+}
+
+func TestOperation(t *testing.T) {
+	image := newImage(t, 64*mib)
+	defer os.Remove(image)
+	logger := log.New(true)
+	defer logger.Close()
+
+	op := Begin(&logger, image)
+	op.CreatePartition(types.Partition{
+		Number:   1,
+		SizeMiB:  util.IntToPtr(8),
+		Label:    util.StrToPtr("EFI-SYSTEM"),
+		TypeGUID: "c12a7328-f81f-11d2-ba4b-00a0c93ec93b",
+		GUID:     "2b7e8ea1-8fdd-4c51-9c68-2d7f2d8d5ef5",
+	})
+	op.CreatePartition(types.Partition{
+		Number: 3,
+		Start:  util.IntToPtr(20000),
+		Label:  util.StrToPtr("DATA"),
+	})
+	op.Info(3)
+	pretend, err := op.Pretend()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the start is aligned down to 1MiB, as the space before it is free, and the size fills the disk
+	sectors := int64(64 * mib / 512)
+	if start, size := *pretend[3].Start, *pretend[3].Size; start != 18432 || int64(size) != sectors-33-18432 {
+		t.Errorf("Pretend: want start %d size %d, got start %d size %d", 18432, sectors-33-18432, start, size)
+	}
+
+	if err := op.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	table, err := ReadTable(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.FirstUsable != 34 || table.LastUsable != sectors-34 || table.Entries != 128 {
+		t.Errorf("bad table layout: first usable %d, last usable %d, %d entries", table.FirstUsable, table.LastUsable, table.Entries)
+	}
+	want := []Partition{
+		{
+			Number:   1,
+			TypeGUID: "C12A7328-F81F-11D2-BA4B-00A0C93EC93B",
+			GUID:     "2B7E8EA1-8FDD-4C51-9C68-2D7F2D8D5EF5",
+			Label:    "EFI-SYSTEM",
+			Start:    2048,
+			Size:     8 * mib / 512,
+		},
+		{
+			Number:   3,
+			TypeGUID: DefaultTypeGUID,
+			GUID:     table.Partitions[1].GUID,
+			Label:    "DATA",
+			Start:    18432,
+			Size:     sectors - 33 - 18432,
+		},
+	}
+	if !reflect.DeepEqual(table.Partitions, want) {
+		t.Errorf("bad partitions:\nwant %+v\ngot  %+v", want, table.Partitions)
+	}
+
+	// replace partition 3 by a smaller one, leaving a gap for the next free number
+	op = Begin(&logger, image)
+	op.DeletePartition(3)
+	op.CreatePartition(types.Partition{Number: 0, SizeMiB: util.IntToPtr(1), Label: util.StrToPtr("second")})
+	op.CreatePartition(types.Partition{Number: 3, Start: util.IntToPtr(20480), SizeMiB: util.IntToPtr(16)})
+	if err := op.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	dump, err := DumpPartitionTable(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dump) != 3 || dump[1].Number != 2 || *dump[1].Start != 18432 || *dump[1].Label != "second" || *dump[2].Size != 16*mib/512 {
+		t.Errorf("bad partitions after the second operation: %+v", dump)
+	}
+
+	// overlapping and existing partitions are refused
+	op = Begin(&logger, image)
+	op.CreatePartition(types.Partition{Number: 4, Start: util.IntToPtr(4096), SizeMiB: util.IntToPtr(1)})
+	if err := op.Commit(); err == nil {
+		t.Error("created an overlapping partition")
+	}
+	op = Begin(&logger, image)
+	op.CreatePartition(types.Partition{Number: 1})
+	if err := op.Commit(); err == nil {
+		t.Error("created an existing partition")
+	}
+
+	op = Begin(&logger, image)
+	op.WipeTable(true)
+	if err := op.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if dump, err := DumpPartitionTable(image); err != nil || len(dump) != 0 {
+		t.Errorf("wiped table: want no partitions, got %+v, %v", dump, err)
+	}
+}
+
+func TestReadRecovery(t *testing.T) {
+	image := newImage(t, 32*mib)
+	defer os.Remove(image)
+
+	table, err := New(32*mib, 512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table.Partitions = []Partition{{Number: 1, TypeGUID: DefaultTypeGUID, GUID: "2B7E8EA1-8FDD-4C51-9C68-2D7F2D8D5EF5", Start: 2048, Size: 2048}}
+	f, err := os.OpenFile(image, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := table.Write(f); err != nil {
+		t.Fatal(err)
+	}
+
+	// a corrupt primary header is replaced by the backup
+	if _, err := f.WriteAt([]byte{0xff}, 512+56); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Read(f, 32*mib, 512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Partitions, table.Partitions) || got.DiskGUID != table.DiskGUID {
+		t.Errorf("backup: want %+v, got %+v", table.Partitions, got.Partitions)
+	}
+
+	// so is a corrupt primary entry array
+	if err := table.Write(f); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteAt([]byte{0xff}, 2*512+200); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(f, 32*mib, 512); err != nil {
+		t.Errorf("backup entries: %v", err)
+	}
+
+	// but not both
+	if _, err := f.WriteAt([]byte{0xff}, 32*mib-512+56); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(f, 32*mib, 512); err != ErrCorruptTable {
+		t.Errorf("both corrupt: want %v, got %v", ErrCorruptTable, err)
+	}
+
+	// a grown disk gets the backup at its new end
+	if err := table.Write(f); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Truncate(64 * mib); err != nil {
+		t.Fatal(err)
+	}
+	got, err = Read(f, 64*mib, 512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.LastUsable != 64*mib/512-34 {
+		t.Errorf("grown disk: want last usable sector %d, got %d", 64*mib/512-34, got.LastUsable)
+	}
+}
+
+// protectiveSize returns the size of the first protective entry in the MBR
+// of f, or -1 if there is none.
+func protectiveSize(t *testing.T, f *os.File) int64 {
+	var mbr [mbrSize]byte
+	if _, err := f.ReadAt(mbr[:], 0); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4; i++ {
+		e := mbr[mbrEntriesStart+i*mbrEntrySize : mbrEntriesStart+(i+1)*mbrEntrySize]
+		if e[4] == protectiveType {
+			return int64(binary.LittleEndian.Uint32(e[12:16]))
+		}
+	}
+	return -1
+}
+
+func TestWriteGrown(t *testing.T) {
+	image := newImage(t, 32*mib)
+	defer os.Remove(image)
+	f, err := os.OpenFile(image, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	table, err := New(32*mib, 512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Write(f); err != nil {
+		t.Fatal(err)
+	}
+	if got := protectiveSize(t, f); got != 32*mib/512-1 {
+		t.Fatalf("new table: want protective size %d, got %d", 32*mib/512-1, got)
+	}
+	if _, err := f.WriteAt(bytes.Repeat([]byte{0x90}, bootCodeSize), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// the protective entry is extended to the new end of the disk, keeping
+	// the boot code
+	if err := f.Truncate(64 * mib); err != nil {
+		t.Fatal(err)
+	}
+	table, err = Read(f, 64*mib, 512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Write(f); err != nil {
+		t.Fatal(err)
+	}
+	if got := protectiveSize(t, f); got != 64*mib/512-1 {
+		t.Errorf("grown disk: want protective size %d, got %d", 64*mib/512-1, got)
+	}
+	bootCode := make([]byte, bootCodeSize)
+	if _, err := f.ReadAt(bootCode, 0); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(bootCode, bytes.Repeat([]byte{0x90}, bootCodeSize)) {
+		t.Error("grown disk: boot code was not kept")
+	}
+
+	// a hybrid MBR's protective entry followed by a hybrid partition is kept
+	var hybrid [mbrSize]byte
+	e := hybrid[mbrEntriesStart : mbrEntriesStart+2*mbrEntrySize]
+	e[4] = protectiveType
+	binary.LittleEndian.PutUint32(e[8:12], 1)
+	binary.LittleEndian.PutUint32(e[12:16], 2047)
+	e[mbrEntrySize+4] = 0x83
+	binary.LittleEndian.PutUint32(e[mbrEntrySize+8:mbrEntrySize+12], 2048)
+	binary.LittleEndian.PutUint32(e[mbrEntrySize+12:mbrEntrySize+16], 2048)
+	hybrid[510], hybrid[511] = 0x55, 0xaa
+	if got := protectiveMBR(hybrid, 128*mib/512); got != hybrid {
+		t.Error("hybrid MBR was modified")
+	}
+}
+
+func TestReadMBR(t *testing.T) {
+	blank := make([]byte, 2*mib)
+	bootCode := bytes.Repeat([]byte{0x90}, bootCodeSize)
+	copy(blank, bootCode)
+
+	table, err := Read(bytes.NewReader(blank), 2*mib, 512)
+	if err != nil {
+		t.Fatalf("blank disk: %v", err)
+	}
+	if len(table.Partitions) != 0 {
+		t.Errorf("blank disk: want no partitions, got %+v", table.Partitions)
+	}
+	mbr := protectiveMBR(table.mbr, table.Sectors)
+	if !bytes.Equal(mbr[:bootCodeSize], bootCode) || mbr[mbrEntriesStart+4] != protectiveType || mbr[510] != 0x55 || mbr[511] != 0xaa {
+		t.Errorf("bad protective MBR % x", mbr)
+	}
+
+	// a dos partition table is not taken over
+	dos := append([]byte(nil), blank...)
+	dos[mbrEntriesStart+4] = 0x83
+	dos[510], dos[511] = 0x55, 0xaa
+	if _, err := Read(bytes.NewReader(dos), 2*mib, 512); err != ErrNotGpt {
+		t.Errorf("dos disk: want %v, got %v", ErrNotGpt, err)
+	}
+
+	// a hybrid MBR is kept
+	hybrid := dos[:mbrSize]
+	hybrid[mbrEntriesStart+mbrEntrySize+4] = protectiveType
+	var h [mbrSize]byte
+	copy(h[:], hybrid)
+	if got := protectiveMBR(h, 4096); got != h {
+		t.Error("hybrid MBR was replaced")
+	}
+}
@@ -0,0 +1,342 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gpt
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/flatcar-linux/ignition/internal/config/types"
+	"github.com/flatcar-linux/ignition/internal/log"
+
+	"github.com/pborman/uuid"
+)
+
+type Operation struct {
+	logger    *log.Logger
+	dev       string
+	wipe      bool
+	parts     []types.Partition
+	deletions []int
+	infos     []int
+}
+
+// Begin begins a gpt operation
+func Begin(logger *log.Logger, dev string) *Operation {
+	return &Operation{logger: logger, dev: dev}
+}
+
+// CreatePartition adds the supplied partition to the list of partitions to be created as part of an operation.
+func (op *Operation) CreatePartition(p types.Partition) {
+	op.parts = append(op.parts, p)
+}
+
+func (op *Operation) DeletePartition(num int) {
+	op.deletions = append(op.deletions, num)
+}
+
+// Info adds the partition numbered num to the partitions returned by Pretend.
+func (op *Operation) Info(num int) {
+	op.infos = append(op.infos, num)
+}
+
+// WipeTable toggles if the table is to be wiped first when commiting this operation.
+func (op *Operation) WipeTable(wipe bool) {
+	op.wipe = wipe
+}
+
+// Pretend is like Commit() but does not write the table. It returns the
+// partitions passed to Info as they would be after the operation, indexed
+// by number.
+func (op *Operation) Pretend() (map[int]types.Partition, error) {
+	f, err := os.Open(op.dev)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	t, err := op.table(f)
+	if err != nil {
+		return nil, err
+	}
+	if err := op.apply(t); err != nil {
+		return nil, err
+	}
+
+	output := map[int]types.Partition{}
+	for _, num := range op.infos {
+		p, ok := t.Partition(num)
+		if !ok {
+			return nil, fmt.Errorf("partition %d would not exist", num)
+		}
+		output[num] = toConfig(p)
+	}
+	return output, nil
+}
+
+// Commit commits an partitioning operation.
+func (op *Operation) Commit() error {
+	if !op.wipe && len(op.parts) == 0 && len(op.deletions) == 0 {
+		return nil
+	}
+	return op.logger.LogChange(op.commit, "deleting %d partitions and creating %d partitions on %q", len(op.deletions), len(op.parts), op.dev)
+}
+
+func (op *Operation) commit() error {
+	f, err := os.OpenFile(op.dev, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if op.wipe {
+		if err := zap(f); err != nil {
+			return fmt.Errorf("wiping partition table failed: %v", err)
+		}
+	}
+	if len(op.parts) != 0 || len(op.deletions) != 0 {
+		t, err := op.table(f)
+		if err != nil {
+			return err
+		}
+		if err := op.apply(t); err != nil {
+			return err
+		}
+		if err := t.Write(f); err != nil {
+			return fmt.Errorf("writing partition table failed: %v", err)
+		}
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+
+	if err := rereadPartitions(f); err != nil {
+		// as with sgdisk, the table is written regardless
+		op.logger.Warning("the kernel failed to reread the partition table of %q, the new table will be used after a reboot: %v", op.dev, err)
+	}
+	return nil
+}
+
+// table returns the table the operation starts from: an empty one if the
+// table is to be wiped, otherwise the one on f.
+func (op *Operation) table(f *os.File) (*Table, error) {
+	size, sectorSize, err := geometry(f)
+	if err != nil {
+		return nil, err
+	}
+	if op.wipe {
+		return New(size, sectorSize)
+	}
+	return Read(f, size, sectorSize)
+}
+
+// zap destroys the MBR and both gpts on f.
+func zap(f *os.File) error {
+	size, sectorSize, err := geometry(f)
+	if err != nil {
+		return err
+	}
+	// large enough for the MBR and a gpt with the default number of entries
+	zeroes := make([]byte, (2+defaultEntries*entrySize/sectorSize)*sectorSize)
+	if int64(len(zeroes)) > size {
+		zeroes = zeroes[:size]
+	}
+	if _, err := f.WriteAt(zeroes, 0); err != nil {
+		return err
+	}
+	_, err = f.WriteAt(zeroes, size-int64(len(zeroes)))
+	return err
+}
+
+// apply applies the deletions and then the creations of the operation to t.
+func (op *Operation) apply(t *Table) error {
+	for _, num := range op.deletions {
+		if _, ok := t.Partition(num); !ok {
+			return fmt.Errorf("cannot delete partition %d: it does not exist", num)
+		}
+		t.remove(num)
+	}
+
+	for _, part := range op.parts {
+		p, err := t.place(part)
+		if err != nil {
+			return err
+		}
+		t.Partitions = append(t.Partitions, p)
+		if err := t.check(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// place resolves the number, start and size of part in t as sgdisk does:
+// number 0 is the first free entry, start 0 the first sector of the largest
+// free block and size 0 the rest of the free block the partition starts in.
+// Starts are aligned to 1MiB if the space up to the aligned sector is free.
+func (t *Table) place(part types.Partition) (Partition, error) {
+	num := part.Number
+	if num == 0 {
+		num = t.firstFreeNumber()
+		if num == 0 {
+			return Partition{}, ErrTableFull
+		}
+	}
+	if _, ok := t.Partition(num); ok {
+		return Partition{}, fmt.Errorf("cannot create partition %d: it already exists", num)
+	}
+
+	start := t.sectors(part.Start, part.StartMiB)
+	if start == 0 {
+		start = t.firstInLargest()
+	}
+	start = t.align(start)
+	if !t.isFree(start, start) {
+		return Partition{}, fmt.Errorf("cannot create partition %d: sector %d is not free", num, start)
+	}
+
+	size := t.sectors(part.Size, part.SizeMiB)
+	if size == 0 {
+		size = t.lastInFree(start) - start + 1
+	}
+
+	p := Partition{
+		Number:   num,
+		TypeGUID: strings.ToUpper(part.TypeGUID),
+		GUID:     strings.ToUpper(part.GUID),
+		Start:    start,
+		Size:     size,
+	}
+	if p.TypeGUID == "" {
+		p.TypeGUID = DefaultTypeGUID
+	}
+	if p.GUID == "" {
+		p.GUID = strings.ToUpper(uuid.NewRandom().String())
+	}
+	if part.Label != nil {
+		p.Label = *part.Label
+	}
+	if !t.isFree(p.Start, p.End()) {
+		return Partition{}, fmt.Errorf("cannot create partition %d from sector %d to %d: the space is not free", num, p.Start, p.End())
+	}
+	return p, nil
+}
+
+// sectors converts a size in sectors or MiB to sectors; 0 if neither is set.
+func (t *Table) sectors(sectors, mib *int) int64 {
+	if sectors != nil {
+		return int64(*sectors)
+	}
+	if mib != nil {
+		return int64(*mib) * 1024 * 1024 / int64(t.SectorSize)
+	}
+	return 0
+}
+
+func (t *Table) remove(num int) {
+	for i, p := range t.Partitions {
+		if p.Number == num {
+			t.Partitions = append(t.Partitions[:i], t.Partitions[i+1:]...)
+			return
+		}
+	}
+}
+
+func (t *Table) firstFreeNumber() int {
+	for num := 1; num <= t.Entries; num++ {
+		if _, ok := t.Partition(num); !ok {
+			return num
+		}
+	}
+	return 0
+}
+
+// isFree returns whether the sectors from first to last are usable and not
+// part of a partition.
+func (t *Table) isFree(first, last int64) bool {
+	if first < t.FirstUsable || last > t.LastUsable {
+		return false
+	}
+	for _, p := range t.Partitions {
+		if first <= p.End() && p.Start <= last {
+			return false
+		}
+	}
+	return true
+}
+
+// freeBlocks returns the first and last sectors of the unpartitioned blocks of t.
+func (t *Table) freeBlocks() [][2]int64 {
+	byStart := append([]Partition(nil), t.Partitions...)
+	sort.Slice(byStart, func(i, j int) bool { return byStart[i].Start < byStart[j].Start })
+
+	blocks := [][2]int64{}
+	next := t.FirstUsable
+	for _, p := range byStart {
+		if p.Start > next {
+			blocks = append(blocks, [2]int64{next, p.Start - 1})
+		}
+		if p.End()+1 > next {
+			next = p.End() + 1
+		}
+	}
+	if next <= t.LastUsable {
+		blocks = append(blocks, [2]int64{next, t.LastUsable})
+	}
+	return blocks
+}
+
+// firstInLargest returns the first sector of the largest free block, or 0 if
+// the disk is full.
+func (t *Table) firstInLargest() int64 {
+	first, size := int64(0), int64(0)
+	for _, b := range t.freeBlocks() {
+		if b[1]-b[0]+1 > size {
+			first, size = b[0], b[1]-b[0]+1
+		}
+	}
+	return first
+}
+
+// lastInFree returns the last sector of the free block containing sector.
+func (t *Table) lastInFree(sector int64) int64 {
+	for _, b := range t.freeBlocks() {
+		if b[0] <= sector && sector <= b[1] {
+			return b[1]
+		}
+	}
+	return sector - 1
+}
+
+// align moves sector to the previous multiple of the alignment if the
+// sectors in between are free, or else to the next one if that is, like
+// sgdisk does.
+func (t *Table) align(sector int64) int64 {
+	step := int64(alignment / t.SectorSize)
+	if step <= 1 || sector%step == 0 {
+		return sector
+	}
+	earlier := sector / step * step
+	later := earlier + step
+	if t.isFree(earlier, sector-1) {
+		return earlier
+	}
+	if t.isFree(sector+1, later) {
+		return later
+	}
+	return sector
+}
@@ -0,0 +1,95 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+type EventKind string
+
+const (
+	EventMessage  EventKind = "message"
+	EventStarted  EventKind = "started"
+	EventFinished EventKind = "finished"
+	EventFailed   EventKind = "failed"
+	EventPlanned  EventKind = "planned"
+)
+
+// Event is a structured log record. Messages only carry the priority, stage,
+// prefix stack and message; the remaining fields describe operations logged
+// via LogOp and LogCmd.
+type Event struct {
+	Time       time.Time `json:"time"`
+	Kind       EventKind `json:"kind"`
+	Priority   string    `json:"priority"`
+	Stage      string    `json:"stage,omitempty"`
+	Prefix     []string  `json:"prefix,omitempty"`
+	Op         int       `json:"op,omitempty"`
+	Message    string    `json:"message"`
+	Command    []string  `json:"command,omitempty"`
+	ExitStatus *int      `json:"exitStatus,omitempty"`
+	DurationMS float64   `json:"durationMs,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// EventOps is implemented by LoggerOps which record structured events. If
+// the Logger's ops implement it, every message and operation is passed to
+// Event instead of the per-priority methods.
+type EventOps interface {
+	LoggerOps
+	Event(Event) error
+}
+
+// JSON writes every event as a single line of JSON.
+type JSON struct {
+	mu sync.Mutex
+	w  io.WriteCloser
+}
+
+// NewJSON returns JSON ops writing to w. w is closed when the ops are closed.
+func NewJSON(w io.WriteCloser) *JSON {
+	return &JSON{w: w}
+}
+
+func (j *JSON) Event(e Event) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	_, err = j.w.Write(b)
+	return err
+}
+
+func (j *JSON) message(priority, msg string) error {
+	return j.Event(Event{Time: time.Now(), Kind: EventMessage, Priority: priority, Message: msg})
+}
+
+func (j *JSON) Emerg(msg string) error   { return j.message("emerg", msg) }
+func (j *JSON) Alert(msg string) error   { return j.message("alert", msg) }
+func (j *JSON) Crit(msg string) error    { return j.message("crit", msg) }
+func (j *JSON) Err(msg string) error     { return j.message("err", msg) }
+func (j *JSON) Warning(msg string) error { return j.message("warning", msg) }
+func (j *JSON) Notice(msg string) error  { return j.message("notice", msg) }
+func (j *JSON) Info(msg string) error    { return j.message("info", msg) }
+func (j *JSON) Debug(msg string) error   { return j.message("debug", msg) }
+func (j *JSON) Close() error             { return j.w.Close() }
@@ -0,0 +1,165 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os/exec"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/flatcar-linux/ignition/internal/plan"
+)
+
+type nopCloser struct {
+	*bytes.Buffer
+}
+
+func (nopCloser) Close() error { return nil }
+
+func TestJSONEvents(t *testing.T) {
+	buf := nopCloser{&bytes.Buffer{}}
+	logger := NewWithOps(NewJSON(buf))
+	logger.SetStage("files")
+	logger.PushPrefix("createFiles")
+	logger.Info("hello %s", "world")
+	logger.LogOp(func() error { return nil }, "writing %q", "/etc/foo")
+	logger.LogOp(func() error { return errors.New("boom") }, "writing %q", "/etc/bar")
+
+	type out struct {
+		kind     EventKind
+		priority string
+		prefix   []string
+		op       int
+		message  string
+		err      string
+	}
+	expected := []out{
+		{kind: EventMessage, priority: "info", prefix: []string{"createFiles"}, message: "hello world"},
+		{kind: EventStarted, priority: "info", prefix: []string{"createFiles", "op(1)"}, op: 1, message: `writing "/etc/foo"`},
+		{kind: EventFinished, priority: "info", prefix: []string{"createFiles", "op(1)"}, op: 1, message: `writing "/etc/foo"`},
+		{kind: EventStarted, priority: "info", prefix: []string{"createFiles", "op(2)"}, op: 2, message: `writing "/etc/bar"`},
+		{kind: EventFailed, priority: "crit", prefix: []string{"createFiles", "op(2)"}, op: 2, message: `writing "/etc/bar"`, err: "boom"},
+	}
+
+	var got []out
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("invalid event %q: %v", scanner.Text(), err)
+		}
+		if e.Stage != "files" {
+			t.Errorf("bad stage in %q", scanner.Text())
+		}
+		got = append(got, out{kind: e.Kind, priority: e.Priority, prefix: e.Prefix, op: e.Op, message: e.Message, err: e.Error})
+	}
+	if !reflect.DeepEqual(expected, got) {
+		t.Errorf("bad events: want %+v, got %+v", expected, got)
+	}
+}
+
+func TestDryRunEvents(t *testing.T) {
+	buf := nopCloser{&bytes.Buffer{}}
+	logger := NewWithOps(NewJSON(buf))
+	p := &plan.Plan{}
+	logger.SetPlan(p)
+	logger.PushPrefix("createFiles")
+
+	ran := false
+	if err := logger.LogChange(func() error { ran = true; return nil }, "writing %q", "/etc/foo"); err != nil {
+		t.Fatal(err)
+	}
+	if status, err := logger.LogCmd(exec.Command("/nonexistent", "--flag"), "running %s", "command"); status != 0 || err != nil {
+		t.Errorf("LogCmd in dry-run mode: want 0, nil, got %d, %v", status, err)
+	}
+	logger.LogPlanned("mounting %q", "/dev/sda1")
+	if ran {
+		t.Error("LogChange ran its operation in dry-run mode")
+	}
+
+	expected := []plan.Entry{
+		{Context: []string{"createFiles", "op(1)"}, Description: `writing "/etc/foo"`},
+		{Context: []string{"createFiles", "op(2)"}, Description: "running command", Command: []string{"/nonexistent", "--flag"}},
+		{Context: []string{"createFiles", "op(3)"}, Description: `mounting "/dev/sda1"`},
+	}
+	if got := p.Entries(); !reflect.DeepEqual(expected, got) {
+		t.Errorf("bad plan: want %+v, got %+v", expected, got)
+	}
+
+	var got []Event
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("invalid event %q: %v", scanner.Text(), err)
+		}
+		got = append(got, e)
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("want %d events, got %+v", len(expected), got)
+	}
+	for i, e := range got {
+		if e.Kind != EventPlanned || e.Op != i+1 || e.Message != expected[i].Description || !reflect.DeepEqual(e.Command, expected[i].Command) {
+			t.Errorf("#%d: bad event %+v", i, e)
+		}
+	}
+}
+
+func TestConcurrentOps(t *testing.T) {
+	buf := nopCloser{&bytes.Buffer{}}
+	logger := NewWithOps(NewJSON(buf))
+	logger.LogOp(func() error { return nil }, "before copying")
+
+	const perCopy = 50
+	var wg sync.WaitGroup
+	for _, l := range []*Logger{logger.Copy(), logger.Copy()} {
+		wg.Add(1)
+		go func(l *Logger) {
+			defer wg.Done()
+			for i := 0; i < perCopy; i++ {
+				l.LogOp(func() error { return nil }, "fetching %d", i)
+			}
+		}(l)
+	}
+	wg.Wait()
+
+	// every op has one start and one finish event, and the copies don't
+	// reuse the numbers of each other or of the original
+	started := map[int]string{}
+	finished := map[int]string{}
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("invalid event %q: %v", scanner.Text(), err)
+		}
+		events := started
+		if e.Kind == EventFinished {
+			events = finished
+		}
+		if _, ok := events[e.Op]; ok {
+			t.Errorf("op %d has more than one %s event", e.Op, e.Kind)
+		}
+		events[e.Op] = e.Message
+	}
+	if len(started) != 2*perCopy+1 || !reflect.DeepEqual(started, finished) {
+		t.Errorf("bad ops: started %v, finished %v", started, finished)
+	}
+}
@@ -20,7 +20,12 @@ import (
 	"log/syslog"
 	"os/exec"
 	"strings"
+	"sync/atomic"
 	"syscall"
+	"time"
+
+	"github.com/flatcar-linux/ignition/internal/plan"
+	"github.com/flatcar-linux/ignition/internal/summary"
 )
 
 type LoggerOps interface {
@@ -37,16 +42,21 @@ type LoggerOps interface {
 
 // Logger implements a variadic flavor of log/syslog.Writer
 type Logger struct {
-	ops           LoggerOps
-	prefixStack   []string
-	opSequenceNum int
+	ops         LoggerOps
+	prefixStack []string
+	// opSequenceNum is shared by all copies of the Logger so that
+	// concurrent operations get distinct numbers.
+	opSequenceNum *int64
+	plan          *plan.Plan
+	stage         string
+	summary       *summary.Summary
 }
 
 // New creates a new logger.
 // If logToStdout is true, syslog is tried first. If syslog fails or logToStdout
 // is false Stdout is used.
 func New(logToStdout bool) Logger {
-	logger := Logger{}
+	logger := Logger{opSequenceNum: new(int64)}
 	if !logToStdout {
 		var err error
 		logger.ops, err = syslog.New(syslog.LOG_DEBUG, "ignition")
@@ -60,6 +70,11 @@ func New(logToStdout bool) Logger {
 	return logger
 }
 
+// NewWithOps creates a new logger which logs using the supplied ops.
+func NewWithOps(ops LoggerOps) Logger {
+	return Logger{ops: ops, opSequenceNum: new(int64)}
+}
+
 // Close closes the logger.
 func (l Logger) Close() {
 	l.ops.Close()
@@ -67,42 +82,66 @@ func (l Logger) Close() {
 
 // Emerg logs a message at emergency priority.
 func (l Logger) Emerg(format string, a ...interface{}) error {
-	return l.log(l.ops.Emerg, format, a...)
+	return l.log(l.ops.Emerg, "emerg", format, a...)
 }
 
 // Alert logs a message at alert priority.
 func (l Logger) Alert(format string, a ...interface{}) error {
-	return l.log(l.ops.Alert, format, a...)
+	return l.log(l.ops.Alert, "alert", format, a...)
 }
 
 // Crit logs a message at critical priority.
 func (l Logger) Crit(format string, a ...interface{}) error {
-	return l.log(l.ops.Crit, format, a...)
+	return l.log(l.ops.Crit, "crit", format, a...)
 }
 
 // Err logs a message at error priority.
 func (l Logger) Err(format string, a ...interface{}) error {
-	return l.log(l.ops.Err, format, a...)
+	return l.log(l.ops.Err, "err", format, a...)
 }
 
 // Warning logs a message at warning priority.
 func (l Logger) Warning(format string, a ...interface{}) error {
-	return l.log(l.ops.Warning, format, a...)
+	return l.log(l.ops.Warning, "warning", format, a...)
 }
 
 // Notice logs a message at notice priority.
 func (l Logger) Notice(format string, a ...interface{}) error {
-	return l.log(l.ops.Notice, format, a...)
+	return l.log(l.ops.Notice, "notice", format, a...)
 }
 
 // Info logs a message at info priority.
 func (l Logger) Info(format string, a ...interface{}) error {
-	return l.log(l.ops.Info, format, a...)
+	return l.log(l.ops.Info, "info", format, a...)
 }
 
 // Debug logs a message at debug priority.
 func (l Logger) Debug(format string, a ...interface{}) error {
-	return l.log(l.ops.Debug, format, a...)
+	return l.log(l.ops.Debug, "debug", format, a...)
+}
+
+// SetStage sets the name of the stage being run. It is included in structured
+// events (see EventOps).
+func (l *Logger) SetStage(name string) {
+	l.stage = name
+}
+
+// SetSummary sets the summary in which the stage records what it did.
+func (l *Logger) SetSummary(s *summary.Summary) {
+	l.summary = s
+}
+
+// Summary returns the summary set with SetSummary. It may be nil, which is
+// safe to record into.
+func (l Logger) Summary() *summary.Summary {
+	return l.summary
+}
+
+// Copy returns a copy of the Logger which can be used concurrently with the
+// original. Prefixes pushed onto one are not seen by the other.
+func (l Logger) Copy() *Logger {
+	l.prefixStack = append([]string(nil), l.prefixStack...)
+	return &l
 }
 
 // PushPrefix pushes the supplied message onto the Logger's prefix stack.
@@ -121,6 +160,25 @@ func (l *Logger) PopPrefix() {
 	l.prefixStack = l.prefixStack[:len(l.prefixStack)-1]
 }
 
+// SetPlan puts the Logger into dry-run mode: commands passed to LogCmd and
+// operations passed to LogChange are recorded in p instead of being run.
+// A nil plan restores normal operation.
+func (l *Logger) SetPlan(p *plan.Plan) {
+	l.plan = p
+}
+
+// DryRun returns true if mutating operations are being recorded in a plan
+// instead of being performed.
+func (l Logger) DryRun() bool {
+	return l.plan != nil
+}
+
+// LogPlanned records the supplied message in the plan and logs it. It must
+// only be called while the Logger is in dry-run mode.
+func (l *Logger) LogPlanned(format string, a ...interface{}) {
+	l.record(nil, format, a...)
+}
+
 // QuotedCmd returns a concatenated, quoted form of cmd's cmdline
 func QuotedCmd(cmd *exec.Cmd) string {
 	if len(cmd.Args) == 0 {
@@ -137,7 +195,18 @@ func QuotedCmd(cmd *exec.Cmd) string {
 
 // LogCmd runs and logs the supplied cmd as an operation with distinct start/finish/fail log messages uniformly combined with the supplied format string.
 // The exact command path and arguments being executed are also logged for debugging assistance.
+// It returns the exit status of the command, or -1 if it couldn't be run.
+// In dry-run mode the command is recorded in the plan instead and reported as successful.
 func (l *Logger) LogCmd(cmd *exec.Cmd, format string, a ...interface{}) (int, error) {
+	args := cmd.Args
+	if len(args) == 0 {
+		args = []string{cmd.Path}
+	}
+	if l.plan != nil {
+		l.record(args, format, a...)
+		return 0, nil
+	}
+
 	code := -1
 	f := func() error {
 		cmdLine := QuotedCmd(cmd)
@@ -153,47 +222,145 @@ func (l *Logger) LogCmd(cmd *exec.Cmd, format string, a ...interface{}) (int, er
 			}
 			return fmt.Errorf("%v: Cmd: %s Stdout: %q Stderr: %q", err, cmdLine, stdout.Bytes(), stderr.Bytes())
 		}
+		code = 0
 		return nil
 	}
-	err := l.LogOp(f, format, a...)
+	err := l.logOp(f, args, &code, format, a...)
 	return code, err
 }
 
 // LogOp calls and logs the supplied function as an operation with distinct start/finish/fail log messages uniformly combined with the supplied format string.
 func (l *Logger) LogOp(op func() error, format string, a ...interface{}) error {
-	l.opSequenceNum++
-	l.PushPrefix("op(%x)", l.opSequenceNum)
+	return l.logOp(op, nil, nil, format, a...)
+}
+
+// logOp implements LogOp. cmd and code, if set, describe the command run by
+// op and are only used for structured events.
+func (l *Logger) logOp(op func() error, cmd []string, code *int, format string, a ...interface{}) error {
+	num := l.nextOp()
+	l.PushPrefix("op(%x)", num)
 	defer l.PopPrefix()
 
-	l.logStart(format, a...)
-	if err := op(); err != nil {
-		l.logFail("%s: %v", fmt.Sprintf(format, a...), err)
+	ev := Event{
+		Op:      num,
+		Message: fmt.Sprintf(format, a...),
+		Command: cmd,
+	}
+	l.logStart(ev)
+	start := time.Now()
+	err := op()
+	ev.DurationMS = float64(time.Since(start)) / float64(time.Millisecond)
+	if code != nil && *code != -1 {
+		status := *code
+		ev.ExitStatus = &status
+	}
+	if err != nil {
+		ev.Error = err.Error()
+		l.logFail(ev)
 		return err
 	}
-	l.logFinish(format, a...)
+	l.logFinish(ev)
 	return nil
 }
 
+// nextOp returns the number of a new operation.
+func (l *Logger) nextOp() int {
+	if l.opSequenceNum == nil {
+		l.opSequenceNum = new(int64)
+	}
+	return int(atomic.AddInt64(l.opSequenceNum, 1))
+}
+
+// LogChange behaves like LogOp for operations which modify the system. In
+// dry-run mode op is not called and the operation is recorded in the plan instead.
+func (l *Logger) LogChange(op func() error, format string, a ...interface{}) error {
+	if l.plan != nil {
+		l.record(nil, format, a...)
+		return nil
+	}
+	return l.LogOp(op, format, a...)
+}
+
+// record adds an entry for the supplied command and message to the plan.
+func (l *Logger) record(cmd []string, format string, a ...interface{}) {
+	num := l.nextOp()
+	l.PushPrefix("op(%x)", num)
+	defer l.PopPrefix()
+
+	l.plan.Add(plan.Entry{
+		Context:     append([]string(nil), l.prefixStack...),
+		Description: fmt.Sprintf(format, a...),
+		Command:     cmd,
+	})
+	ev := Event{
+		Kind:    EventPlanned,
+		Op:      num,
+		Message: fmt.Sprintf(format, a...),
+		Command: cmd,
+	}
+	if !l.event("info", ev) {
+		l.Info("[planned]  %s", ev.Message)
+	}
+}
+
 // logStart logs the start of a multi-step/substantial/time-consuming operation.
-func (l Logger) logStart(format string, a ...interface{}) {
-	l.Info(fmt.Sprintf("[started]  %s", format), a...)
+func (l Logger) logStart(ev Event) {
+	ev.Kind = EventStarted
+	if !l.event("info", ev) {
+		l.Info("[started]  %s", ev.Message)
+	}
 }
 
 // logFail logs the failure of a multi-step/substantial/time-consuming operation.
-func (l Logger) logFail(format string, a ...interface{}) {
-	l.Crit(fmt.Sprintf("[failed]   %s", format), a...)
+func (l Logger) logFail(ev Event) {
+	ev.Kind = EventFailed
+	if !l.event("crit", ev) {
+		l.Crit("[failed]   %s: %s", ev.Message, ev.Error)
+	}
 }
 
 // logFinish logs the completion of a multi-step/substantial/time-consuming operation.
-func (l Logger) logFinish(format string, a ...interface{}) {
-	l.Info(fmt.Sprintf("[finished] %s", format), a...)
+func (l Logger) logFinish(ev Event) {
+	ev.Kind = EventFinished
+	if !l.event("info", ev) {
+		l.Info("[finished] %s", ev.Message)
+	}
 }
 
-// log logs a formatted message using the supplied logFunc.
-func (l Logger) log(logFunc func(string) error, format string, a ...interface{}) error {
+// log logs a formatted message using the supplied logFunc, or as an event of
+// the supplied priority if the ops record structured events.
+func (l Logger) log(logFunc func(string) error, priority string, format string, a ...interface{}) error {
+	if eo, ok := l.ops.(EventOps); ok {
+		return eo.Event(l.newEvent(priority, Event{
+			Kind:    EventMessage,
+			Message: fmt.Sprintf(format, a...),
+		}))
+	}
 	return logFunc(l.sprintf(format, a...))
 }
 
+// event passes ev to the ops if they record structured events. It returns
+// false if they don't.
+func (l Logger) event(priority string, ev Event) bool {
+	eo, ok := l.ops.(EventOps)
+	if !ok {
+		return false
+	}
+	eo.Event(l.newEvent(priority, ev))
+	return true
+}
+
+// newEvent fills in the fields of ev common to all events.
+func (l Logger) newEvent(priority string, ev Event) Event {
+	ev.Time = time.Now()
+	ev.Priority = priority
+	ev.Stage = l.stage
+	if len(l.prefixStack) > 0 {
+		ev.Prefix = append([]string(nil), l.prefixStack...)
+	}
+	return ev
+}
+
 // sprintf returns the current prefix stack, if any, concatenated with the supplied format string and args in expanded form.
 func (l Logger) sprintf(format string, a ...interface{}) string {
 	m := []string{}
@@ -25,31 +25,48 @@ import (
 	_ "github.com/flatcar-linux/ignition/internal/exec/stages/disks"
 	_ "github.com/flatcar-linux/ignition/internal/exec/stages/fetch"
 	_ "github.com/flatcar-linux/ignition/internal/exec/stages/files"
+	_ "github.com/flatcar-linux/ignition/internal/exec/stages/render"
 	"github.com/flatcar-linux/ignition/internal/log"
 	"github.com/flatcar-linux/ignition/internal/oem"
+	"github.com/flatcar-linux/ignition/internal/plan"
+	"github.com/flatcar-linux/ignition/internal/resource"
 	"github.com/flatcar-linux/ignition/internal/version"
 )
 
 func main() {
 	flags := struct {
-		clearCache   bool
-		configCache  string
-		fetchTimeout time.Duration
-		oem          oem.Name
-		root         string
-		stage        stages.Name
-		version      bool
-		logToStdout  bool
+		clearCache       bool
+		configCache      string
+		dryRun           bool
+		fetchCacheDir    string
+		fetchConcurrency int
+		fetchTimeout     time.Duration
+		logJSON          string
+		oem              oem.Name
+		root             string
+		s3MaxObjectSize  int64
+		stage            stages.Name
+		version          bool
+		logToStdout      bool
+		planJSON         string
+		reportDir        string
 	}{}
 
 	flag.BoolVar(&flags.clearCache, "clear-cache", false, "clear any cached config")
 	flag.StringVar(&flags.configCache, "config-cache", "/run/ignition.json", "where to cache the config")
+	flag.BoolVar(&flags.dryRun, "dry-run", false, "print the operations the stage would perform instead of performing them")
+	flag.StringVar(&flags.fetchCacheDir, "fetch-cache-dir", "", "directory of the download cache for resources with a verification hash (disabled if empty)")
+	flag.IntVar(&flags.fetchConcurrency, "fetch-concurrency", 4, "maximum number of files to fetch in parallel (1 to fetch them one at a time)")
 	flag.DurationVar(&flags.fetchTimeout, "fetch-timeout", exec.DefaultFetchTimeout, "initial duration for which to wait for config")
 	flag.Var(&flags.oem, "oem", fmt.Sprintf("current oem. %v", oem.Names()))
+	flag.StringVar(&flags.reportDir, "report-dir", "", "where to write the report of the stage (default: /run/ignition for the fetch and disks stages, var/lib/ignition under the root otherwise)")
 	flag.StringVar(&flags.root, "root", "/", "root of the filesystem")
+	flag.Int64Var(&flags.s3MaxObjectSize, "s3-max-object-size", 0, "maximum size in bytes of objects fetched from S3 (unlimited if 0)")
 	flag.Var(&flags.stage, "stage", fmt.Sprintf("execution stage. %v", stages.Names()))
 	flag.BoolVar(&flags.version, "version", false, "print the version and exit")
 	flag.BoolVar(&flags.logToStdout, "log-to-stdout", false, "log to stdout instead of the system log when set")
+	flag.StringVar(&flags.logJSON, "log-json", "", "log newline-delimited JSON events to this file (\"-\" for stdout) instead of the system log")
+	flag.StringVar(&flags.planJSON, "plan-json", "", "with --dry-run, also write the plan as JSON to this file")
 
 	flag.Parse()
 
@@ -68,16 +85,54 @@ func main() {
 		os.Exit(2)
 	}
 
-	logger := log.New(flags.logToStdout)
+	if flags.planJSON != "" && !flags.dryRun {
+		fmt.Fprint(os.Stderr, "'--plan-json' requires '--dry-run'\n")
+		os.Exit(2)
+	}
+
+	// the plan and the rendered config are printed to stdout
+	if flags.logJSON == "-" && flags.dryRun {
+		fmt.Fprint(os.Stderr, "'--log-json=-' can't be used with '--dry-run'\n")
+		os.Exit(2)
+	}
+	if flags.logJSON == "-" && flags.stage == "render" {
+		fmt.Fprint(os.Stderr, "'--log-json=-' can't be used with '--stage=render'\n")
+		os.Exit(2)
+	}
+
+	var logger log.Logger
+	switch flags.logJSON {
+	case "":
+		logger = log.New(flags.logToStdout)
+	case "-":
+		logger = log.NewWithOps(log.NewJSON(os.Stdout))
+	default:
+		f, err := os.OpenFile(flags.logJSON, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "couldn't open JSON log: %v\n", err)
+			os.Exit(2)
+		}
+		logger = log.NewWithOps(log.NewJSON(f))
+	}
 	defer logger.Close()
 
 	logger.Info(version.String)
 	logger.Info("Stage: %v", flags.stage)
 
-	if flags.clearCache {
+	// the render stage only prints the config, so it is run like a dry run
+	// without printing the (empty) plan
+	render := flags.stage == "render"
+
+	if flags.clearCache && (flags.dryRun || render) {
+		// don't touch the cache, just ignore it
+		flags.configCache = ""
+	} else if flags.clearCache {
 		if err := os.Remove(flags.configCache); err != nil {
 			logger.Err("unable to clear cache: %v", err)
 		}
+		if err := os.Remove(exec.ClientKeyCachePath(flags.configCache)); err != nil && !os.IsNotExist(err) {
+			logger.Err("unable to clear cached client key: %v", err)
+		}
 	}
 
 	oemConfig := oem.MustGet(flags.oem.String())
@@ -86,6 +141,17 @@ func main() {
 		logger.Crit("failed to generate fetcher: %s", err)
 		os.Exit(3)
 	}
+	// The options are loaded once, since the fetcher is copied for every
+	// stage and fetch. Only fetches from S3 need them, so those fail
+	// instead of the stage.
+	fetcher.S3, err = resource.LoadS3Options(&logger)
+	if err != nil {
+		logger.Warning("failed to load S3 options, fetching from S3 will fail: %v", err)
+		fetcher.S3 = resource.S3Options{Err: err}
+	}
+	fetcher.Concurrency = flags.fetchConcurrency
+	fetcher.CacheDir = flags.fetchCacheDir
+	fetcher.S3MaxObjectSize = flags.s3MaxObjectSize
 	engine := exec.Engine{
 		Root:         flags.root,
 		FetchTimeout: flags.fetchTimeout,
@@ -93,11 +159,25 @@ func main() {
 		ConfigCache:  flags.configCache,
 		OEMConfig:    oemConfig,
 		Fetcher:      &fetcher,
+		ReportDir:    flags.reportDir,
+	}
+	if flags.dryRun || render {
+		engine.Plan = &plan.Plan{}
 	}
 
 	err = engine.Run(flags.stage.String())
-	if statusErr := engine.OEMConfig.Status(flags.stage.String(), *engine.Fetcher, err); statusErr != nil {
-		logger.Err("POST Status error: %v", statusErr.Error())
+	switch {
+	case render:
+		// the stage already printed the config
+	case flags.dryRun:
+		if planErr := writePlan(engine.Plan, flags.planJSON); planErr != nil {
+			logger.Crit("failed to write plan: %v", planErr)
+			os.Exit(1)
+		}
+	default:
+		if statusErr := engine.OEMConfig.Status(flags.stage.String(), *engine.Fetcher, err); statusErr != nil {
+			logger.Err("POST Status error: %v", statusErr.Error())
+		}
 	}
 	if err != nil {
 		logger.Crit("Ignition failed: %v", err.Error())
@@ -105,3 +185,20 @@ func main() {
 	}
 	logger.Info("Ignition finished successfully")
 }
+
+// writePlan prints p to stdout and, if jsonPath is set, writes it as JSON to
+// jsonPath.
+func writePlan(p *plan.Plan, jsonPath string) error {
+	if err := p.WriteText(os.Stdout); err != nil {
+		return err
+	}
+	if jsonPath == "" {
+		return nil
+	}
+	f, err := os.Create(jsonPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return p.WriteJSON(f)
+}
@@ -80,8 +80,9 @@ func init() {
 		fetch: aliyun.FetchConfig,
 	})
 	configs.Register(Config{
-		name:  "azure",
-		fetch: azure.FetchConfig,
+		name:       "azure",
+		fetch:      azure.FetchConfig,
+		newFetcher: azure.NewFetcher,
 	})
 	configs.Register(Config{
 		name:  "cloudsigma",
@@ -113,8 +114,9 @@ func init() {
 		fetch: noop.FetchConfig,
 	})
 	configs.Register(Config{
-		name:  "gce",
-		fetch: gce.FetchConfig,
+		name:       "gce",
+		fetch:      gce.FetchConfig,
+		newFetcher: gce.NewFetcher,
 	})
 	configs.Register(Config{
 		name:  "hyperv",
@@ -0,0 +1,99 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The plan package records the mutating operations Ignition would have
+// performed when run in dry-run mode.
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Entry describes a single operation that was skipped.
+type Entry struct {
+	// Context is the logger prefix stack at the time the entry was recorded
+	// (e.g. the stage name and operation number).
+	Context     []string `json:"context,omitempty"`
+	Description string   `json:"description"`
+	// Command is the argv of the command that would have been run, if any.
+	Command []string `json:"command,omitempty"`
+}
+
+// Plan is an ordered list of entries. It is safe for concurrent use.
+type Plan struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// Add appends e to the plan.
+func (p *Plan) Add(e Entry) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.entries = append(p.entries, e)
+}
+
+// Entries returns a copy of the entries recorded so far.
+func (p *Plan) Entries() []Entry {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Entry(nil), p.entries...)
+}
+
+// WriteText writes a human-readable form of the plan to w.
+func (p *Plan) WriteText(w io.Writer) error {
+	entries := p.Entries()
+	if len(entries) == 0 {
+		_, err := fmt.Fprintln(w, "no changes planned")
+		return err
+	}
+	for i, e := range entries {
+		line := fmt.Sprintf("%3d. %s", i+1, e.Description)
+		if len(e.Context) > 0 {
+			line = fmt.Sprintf("%3d. %s: %s", i+1, strings.Join(e.Context, ": "), e.Description)
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+		if len(e.Command) > 0 {
+			var q []string
+			for _, s := range e.Command {
+				q = append(q, fmt.Sprintf("%q", s))
+			}
+			if _, err := fmt.Fprintf(w, "     $ %s\n", strings.Join(q, " ")); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes the plan to w as a JSON document of the form
+// {"entries": [...]}.
+func (p *Plan) WriteJSON(w io.Writer) error {
+	doc := struct {
+		Entries []Entry `json:"entries"`
+	}{
+		Entries: p.Entries(),
+	}
+	if doc.Entries == nil {
+		doc.Entries = []Entry{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
@@ -0,0 +1,77 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	p := &Plan{}
+	var text, js bytes.Buffer
+	if err := p.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.WriteJSON(&js); err != nil {
+		t.Fatal(err)
+	}
+	if text.String() != "no changes planned\n" {
+		t.Errorf("bad text for an empty plan: %q", text.String())
+	}
+	if js.String() != "{\n  \"entries\": []\n}\n" {
+		t.Errorf("bad JSON for an empty plan: %q", js.String())
+	}
+
+	p.Add(Entry{Context: []string{"disks", "op(1)"}, Description: "creating filesystem", Command: []string{"/usr/sbin/mkfs.ext4", "/dev/vda1"}})
+	p.Add(Entry{Description: "writing \"/etc/motd\""})
+	text.Reset()
+	js.Reset()
+	if err := p.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.WriteJSON(&js); err != nil {
+		t.Fatal(err)
+	}
+	wantText := `  1. disks: op(1): creating filesystem
+     $ "/usr/sbin/mkfs.ext4" "/dev/vda1"
+  2. writing "/etc/motd"
+`
+	if text.String() != wantText {
+		t.Errorf("bad text: want %q, got %q", wantText, text.String())
+	}
+	wantJSON := `{
+  "entries": [
+    {
+      "context": [
+        "disks",
+        "op(1)"
+      ],
+      "description": "creating filesystem",
+      "command": [
+        "/usr/sbin/mkfs.ext4",
+        "/dev/vda1"
+      ]
+    },
+    {
+      "description": "writing \"/etc/motd\""
+    }
+  ]
+}
+`
+	if js.String() != wantJSON {
+		t.Errorf("bad JSON: want %s, got %s", wantJSON, js.String())
+	}
+}
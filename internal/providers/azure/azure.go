@@ -80,7 +80,9 @@ func FetchFromOvfDevice(f *resource.Fetcher, ovfFsTypes []string) (types.Config,
 					if err != nil {
 						logger.Debug("failed to retrieve config from device %q: %v", dev, err)
 					} else {
-						return util.ParseConfig(logger, rawConfig)
+						// custom data is a single blob, so its signature
+						// can only be embedded in it
+						return util.ParseSignedConfig(f, dev+":"+configPath, rawConfig, nil)
 					}
 				}
 				checkedDevices[dev] = struct{}{}
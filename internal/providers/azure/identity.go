@@ -0,0 +1,65 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package azure
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/flatcar-linux/ignition/internal/log"
+	"github.com/flatcar-linux/ignition/internal/resource"
+)
+
+var (
+	// tokenUrl is the managed identity endpoint of the Azure Instance
+	// Metadata Service, requesting a token for Azure Storage.
+	tokenUrl = url.URL{
+		Scheme:   "http",
+		Host:     "169.254.169.254",
+		Path:     "metadata/identity/oauth2/token",
+		RawQuery: "api-version=2018-02-01&resource=https%3A%2F%2Fstorage.azure.com%2F",
+	}
+)
+
+func NewFetcher(l *log.Logger) (resource.Fetcher, error) {
+	return resource.Fetcher{
+		Logger:         l,
+		AzureBlobToken: fetchToken,
+	}, nil
+}
+
+// fetchToken returns an access token for Azure Storage from the managed
+// identity assigned to the VM.
+func fetchToken(f *resource.Fetcher) (string, error) {
+	data, err := f.FetchToBuffer(tokenUrl, resource.FetchOptions{
+		Headers: http.Header{"Metadata": []string{"true"}},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal(data, &token); err != nil {
+		return "", err
+	}
+	if token.AccessToken == "" {
+		return "", errors.New("metadata service returned an empty token")
+	}
+	return token.AccessToken, nil
+}
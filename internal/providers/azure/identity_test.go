@@ -0,0 +1,61 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package azure
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/flatcar-linux/ignition/internal/log"
+)
+
+func TestFetchToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata") != "true" {
+			http.Error(w, "missing Metadata header", http.StatusBadRequest)
+			return
+		}
+		if r.URL.Query().Get("resource") != "https://storage.azure.com/" {
+			http.Error(w, "unexpected resource", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"access_token": "token", "token_type": "Bearer"}`))
+	}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(u url.URL) { tokenUrl = u }(tokenUrl)
+	tokenUrl.Host = serverURL.Host
+
+	logger := log.New(true)
+	defer logger.Close()
+	f, err := NewFetcher(&logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := f.AzureBlobToken(&f)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if token != "token" {
+		t.Errorf("expected %q, got %q", "token", token)
+	}
+}
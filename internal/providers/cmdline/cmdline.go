@@ -54,7 +54,7 @@ func FetchConfig(f *resource.Fetcher) (types.Config, report.Report, error) {
 		return types.Config{}, report.Report{}, err
 	}
 
-	return util.ParseConfig(f.Logger, data)
+	return util.ParseVerifiedConfig(f, url.String(), data)
 }
 
 func readCmdline(logger *log.Logger) (*url.URL, error) {
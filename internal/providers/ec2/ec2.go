@@ -55,7 +55,7 @@ func FetchConfig(f *resource.Fetcher) (types.Config, report.Report, error) {
 	}
 	f.S3RegionHint = regionHint
 
-	return util.ParseConfig(f.Logger, data)
+	return util.ParseVerifiedConfig(f, userdataUrl.String(), data)
 }
 
 func NewFetcher(l *log.Logger) (resource.Fetcher, error) {
@@ -42,5 +42,5 @@ func FetchConfig(f *resource.Fetcher) (types.Config, report.Report, error) {
 		f.Logger.Err("couldn't read config %q: %v", filename, err)
 		return types.Config{}, report.Report{}, err
 	}
-	return util.ParseConfig(f.Logger, rawConfig)
+	return util.ParseVerifiedConfig(f, filename, rawConfig)
 }
@@ -18,10 +18,14 @@
 package gce
 
 import (
+	"encoding/json"
+	"errors"
+	"net/http"
 	"net/url"
 
 	"github.com/flatcar-linux/ignition/config/validate/report"
 	"github.com/flatcar-linux/ignition/internal/config/types"
+	"github.com/flatcar-linux/ignition/internal/log"
 	"github.com/flatcar-linux/ignition/internal/providers/util"
 	"github.com/flatcar-linux/ignition/internal/resource"
 )
@@ -32,6 +36,11 @@ var (
 		Host:   "metadata.google.internal",
 		Path:   "computeMetadata/v1/instance/attributes/user-data",
 	}
+	tokenUrl = url.URL{
+		Scheme: "http",
+		Host:   "metadata.google.internal",
+		Path:   "computeMetadata/v1/instance/service-accounts/default/token",
+	}
 	metadataHeaderKey = "Metadata-Flavor"
 	metadataHeaderVal = "Google"
 )
@@ -46,5 +55,34 @@ func FetchConfig(f *resource.Fetcher) (types.Config, report.Report, error) {
 		return types.Config{}, report.Report{}, err
 	}
 
-	return util.ParseConfig(f.Logger, data)
+	return util.ParseVerifiedConfig(f, userdataUrl.String(), data)
+}
+
+func NewFetcher(l *log.Logger) (resource.Fetcher, error) {
+	return resource.Fetcher{
+		Logger:   l,
+		GCSToken: fetchToken,
+	}, nil
+}
+
+// fetchToken returns an access token for the default service account of the
+// instance, which is used to fetch gs:// URLs.
+func fetchToken(f *resource.Fetcher) (string, error) {
+	data, err := f.FetchToBuffer(tokenUrl, resource.FetchOptions{
+		Headers: http.Header{metadataHeaderKey: []string{metadataHeaderVal}},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal(data, &token); err != nil {
+		return "", err
+	}
+	if token.AccessToken == "" {
+		return "", errors.New("metadata service returned an empty token")
+	}
+	return token.AccessToken, nil
 }
@@ -31,10 +31,10 @@ import (
 	"time"
 
 	"github.com/flatcar-linux/ignition/config/validate/report"
-	"github.com/flatcar-linux/ignition/internal/config"
 	"github.com/flatcar-linux/ignition/internal/config/types"
 	"github.com/flatcar-linux/ignition/internal/distro"
 	"github.com/flatcar-linux/ignition/internal/log"
+	"github.com/flatcar-linux/ignition/internal/providers/util"
 	"github.com/flatcar-linux/ignition/internal/resource"
 )
 
@@ -52,6 +52,7 @@ var (
 
 func FetchConfig(f *resource.Fetcher) (types.Config, report.Report, error) {
 	var data []byte
+	var source string
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 
 	dispatch := func(name string, fn func() ([]byte, error)) {
@@ -68,6 +69,7 @@ func FetchConfig(f *resource.Fetcher) (types.Config, report.Report, error) {
 		}
 
 		data = raw
+		source = name
 		cancel()
 	}
 
@@ -88,7 +90,7 @@ func FetchConfig(f *resource.Fetcher) (types.Config, report.Report, error) {
 		f.Logger.Info("neither config drive nor metadata service were available in time. Continuing without a config...")
 	}
 
-	return config.Parse(data)
+	return util.ParseVerifiedConfig(f, source, data)
 }
 
 func fileExists(path string) bool {
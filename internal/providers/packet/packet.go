@@ -63,7 +63,7 @@ func FetchConfig(f *resource.Fetcher) (types.Config, report.Report, error) {
 		return types.Config{}, report.Report{}, err
 	}
 
-	return util.ParseConfig(f.Logger, data)
+	return util.ParseVerifiedConfig(f, userdataUrl.String(), data)
 }
 
 // PostStatus posts a message that will show on the Packet Instance Timeline
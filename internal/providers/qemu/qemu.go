@@ -13,7 +13,8 @@
 // limitations under the License.
 
 // The QEMU provider fetches a local configuration from the firmware config
-// interface (opt/org.flatcar-linux/config).
+// interface (opt/org.flatcar-linux/config), and its detached signature, if
+// any, from the key next to it (opt/org.flatcar-linux/signature).
 
 package qemu
 
@@ -33,6 +34,11 @@ var (
 		"/sys/firmware/qemu_fw_cfg/by_name/opt/org.flatcar-linux/config/raw",
 		"/sys/firmware/qemu_fw_cfg/by_name/opt/com.coreos/config/raw",
 	}
+	// firmwareSignaturePaths holds the signature path for each config path
+	firmwareSignaturePaths = map[string]string{
+		"/sys/firmware/qemu_fw_cfg/by_name/opt/org.flatcar-linux/config/raw": "/sys/firmware/qemu_fw_cfg/by_name/opt/org.flatcar-linux/signature/raw",
+		"/sys/firmware/qemu_fw_cfg/by_name/opt/com.coreos/config/raw":        "/sys/firmware/qemu_fw_cfg/by_name/opt/com.coreos/signature/raw",
+	}
 )
 
 func FetchConfig(f *resource.Fetcher) (types.Config, report.Report, error) {
@@ -42,6 +48,7 @@ func FetchConfig(f *resource.Fetcher) (types.Config, report.Report, error) {
 	}
 
 	data := []byte{}
+	source := ""
 	for _, path := range firmwareConfigPaths {
 		data, err = ioutil.ReadFile(path)
 		if os.IsNotExist(err) {
@@ -50,9 +57,22 @@ func FetchConfig(f *resource.Fetcher) (types.Config, report.Report, error) {
 			f.Logger.Err("couldn't read QEMU firmware config %v: %v", path, err)
 			return types.Config{}, report.Report{}, err
 		} else {
+			source = path
 			break
 		}
 	}
 
-	return util.ParseConfig(f.Logger, data)
+	var signature []byte
+	if source != "" {
+		signature, err = ioutil.ReadFile(firmwareSignaturePaths[source])
+		if os.IsNotExist(err) {
+			f.Logger.Debug("QEMU firmware config has no signature")
+			signature = nil
+		} else if err != nil {
+			f.Logger.Err("couldn't read QEMU firmware config signature: %v", err)
+			return types.Config{}, report.Report{}, err
+		}
+	}
+
+	return util.ParseSignedConfig(f, source, data, signature)
 }
@@ -58,5 +58,5 @@ func fetchConfig(logger *log.Logger, filename string) (types.Config, report.Repo
 		logger.Err("couldn't read config %q: %v", path, err)
 		return types.Config{}, report.Report{}, err
 	}
-	return util.ParseConfig(logger, rawConfig)
+	return util.ParseConfig(logger, path, rawConfig)
 }
@@ -22,11 +22,55 @@ import (
 	"github.com/flatcar-linux/ignition/internal/config"
 	"github.com/flatcar-linux/ignition/internal/config/types"
 	"github.com/flatcar-linux/ignition/internal/log"
+	"github.com/flatcar-linux/ignition/internal/resource"
 )
 
-func ParseConfig(logger *log.Logger, rawConfig []byte) (types.Config, report.Report, error) {
+// ParseConfig parses the raw config read from source, recording it in the
+// logger's summary.
+func ParseConfig(logger *log.Logger, source string, rawConfig []byte) (types.Config, report.Report, error) {
 	hash := sha512.Sum512(rawConfig)
 	logger.Debug("parsing config with SHA512: %s", hex.EncodeToString(hash[:]))
+	if len(rawConfig) != 0 {
+		logger.Summary().AddConfig(source, hex.EncodeToString(hash[:]))
+	}
 
 	return config.Parse(rawConfig)
 }
+
+// ParseVerifiedConfig parses the raw config read from source like ParseConfig
+// and, if the fetcher trusts any signing keys, checks it against its
+// signature: the one embedded in front of the config if there is one, and
+// otherwise the detached signature next to source. Configs which aren't
+// Ignition configs are returned with their parse error, so they can still be
+// ignored in favor of the default config.
+func ParseVerifiedConfig(f *resource.Fetcher, source string, rawConfig []byte) (types.Config, report.Report, error) {
+	return parseVerifiedConfig(f, source, rawConfig, func(blob []byte) error {
+		return f.VerifyConfigSignature(source, blob, resource.ConfigHeaders)
+	})
+}
+
+// ParseSignedConfig is like ParseVerifiedConfig for providers which read the
+// detached signature of the config themselves, e.g. from a separate key next
+// to the config. signature is nil if there is none.
+func ParseSignedConfig(f *resource.Fetcher, source string, rawConfig, signature []byte) (types.Config, report.Report, error) {
+	return parseVerifiedConfig(f, source, rawConfig, func(blob []byte) error {
+		return f.CheckSignature(blob, signature)
+	})
+}
+
+func parseVerifiedConfig(f *resource.Fetcher, source string, rawConfig []byte, verify func([]byte) error) (types.Config, report.Report, error) {
+	if blob, signature, ok := resource.SplitSignedConfig(rawConfig); ok {
+		rawConfig = blob
+		verify = func(blob []byte) error {
+			return f.CheckSignature(blob, signature)
+		}
+	}
+	cfg, r, err := ParseConfig(f.Logger, source, rawConfig)
+	if err != nil {
+		return cfg, r, err
+	}
+	if err := verify(rawConfig); err != nil {
+		return types.Config{}, r, err
+	}
+	return cfg, r, nil
+}
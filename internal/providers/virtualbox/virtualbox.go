@@ -37,7 +37,8 @@ const (
 
 func FetchConfig(f *resource.Fetcher) (types.Config, report.Report, error) {
 	f.Logger.Debug("Attempting to read config drive")
-	rawConfig, err := ioutil.ReadFile(filepath.Join(distro.DiskByPartUUIDDir(), partUUID))
+	path := filepath.Join(distro.DiskByPartUUIDDir(), partUUID)
+	rawConfig, err := ioutil.ReadFile(path)
 	if os.IsNotExist(err) {
 		f.Logger.Info("Path to ignition config does not exist, assuming no config")
 		return types.Config{}, report.Report{}, errors.ErrEmpty
@@ -46,5 +47,5 @@ func FetchConfig(f *resource.Fetcher) (types.Config, report.Report, error) {
 		return types.Config{}, report.Report{}, err
 	}
 	trimmedConfig := bytes.TrimRight(rawConfig, "\x00")
-	return util.ParseConfig(f.Logger, trimmedConfig)
+	return util.ParseVerifiedConfig(f, path, trimmedConfig)
 }
@@ -37,15 +37,34 @@ func FetchConfig(f *resource.Fetcher) (types.Config, report.Report, error) {
 	}
 
 	config, err := fetchDataConfig(f)
-	if err == nil && len(config) == 0 {
-		config, err = fetchUrlConfig(f)
+	if err != nil {
+		return types.Config{}, report.Report{}, err
 	}
+	if len(config) != 0 {
+		f.Logger.Debug("config successfully fetched")
+		return util.ParseSignedConfig(f, "VMware guestinfo", config, fetchSignature(f))
+	}
+
+	config, source, err := fetchUrlConfig(f)
 	if err != nil {
 		return types.Config{}, report.Report{}, err
 	}
+	if source == "" {
+		source = "VMware guestinfo"
+	}
 
 	f.Logger.Debug("config successfully fetched")
-	return util.ParseConfig(f.Logger, config)
+	return util.ParseVerifiedConfig(f, source, config)
+}
+
+// fetchSignature returns the detached signature of the config in
+// guestinfo, or nil if there is none.
+func fetchSignature(f *resource.Fetcher) []byte {
+	signature, err := getVariable(f, "ignition.config.signature")
+	if err != nil || signature == "" {
+		return nil
+	}
+	return []byte(signature)
 }
 
 func fetchDataConfig(f *resource.Fetcher) ([]byte, error) {
@@ -80,14 +99,16 @@ func fetchDataConfig(f *resource.Fetcher) ([]byte, error) {
 	return decodedData, nil
 }
 
-func fetchUrlConfig(f *resource.Fetcher) ([]byte, error) {
+// fetchUrlConfig returns the config fetched from the URL in guestinfo and the
+// URL, or an empty config and source if there is none.
+func fetchUrlConfig(f *resource.Fetcher) ([]byte, string, error) {
 	rawUrl, err := getVariable(f, "ignition.config.url")
 	if err != nil || rawUrl == "" {
 		rawUrl, err = getVariable(f, "coreos.config.url")
 	}
 	if err != nil || rawUrl == "" {
 		f.Logger.Info("no config URL provided")
-		return []byte{}, nil
+		return []byte{}, "", nil
 	}
 
 	f.Logger.Debug("found url: %q", rawUrl)
@@ -95,20 +116,20 @@ func fetchUrlConfig(f *resource.Fetcher) ([]byte, error) {
 	url, err := url.Parse(rawUrl)
 	if err != nil {
 		f.Logger.Err("failed to parse url: %v", err)
-		return nil, err
+		return nil, "", err
 	}
 	if url == nil {
-		return []byte{}, nil
+		return []byte{}, "", nil
 	}
 
 	data, err := f.FetchToBuffer(*url, resource.FetchOptions{
 		Headers: resource.ConfigHeaders,
 	})
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	return data, nil
+	return data, rawUrl, nil
 }
 
 func getVariable(f *resource.Fetcher, key string) (string, error) {
@@ -43,5 +43,5 @@ func FetchConfig(f *resource.Fetcher) (types.Config, report.Report, error) {
 		return types.Config{}, report.Report{}, err
 	}
 
-	return util.ParseConfig(f.Logger, data)
+	return util.ParseVerifiedConfig(f, userdataUrl.String(), data)
 }
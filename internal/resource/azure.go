@@ -0,0 +1,59 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// azureBlobVersion is the Blob service version sent with authenticated
+	// requests. OAuth2 tokens require at least 2017-11-09.
+	azureBlobVersion = "2017-11-09"
+)
+
+var (
+	azureBlobHostSuffix = ".blob.core.windows.net"
+)
+
+// isAzureBlob returns whether u refers to Azure Blob Storage. Tokens are only
+// sent over https.
+func isAzureBlob(u url.URL) bool {
+	return u.Scheme == "https" && strings.HasSuffix(u.Hostname(), azureBlobHostSuffix)
+}
+
+// azureBlobHeaders returns headers with the Authorization and x-ms-version
+// headers needed to fetch private blobs added, if AzureBlobToken provides a
+// token. headers is not modified.
+func (f *Fetcher) azureBlobHeaders(headers http.Header) http.Header {
+	if f.AzureBlobToken == nil {
+		return headers
+	}
+	token, err := f.AzureBlobToken(f)
+	if err != nil {
+		f.Logger.Warning("failed to get Azure access token, fetching anonymously: %v", err)
+		return headers
+	}
+
+	h := http.Header{}
+	for k, v := range headers {
+		h[k] = v
+	}
+	h.Set("Authorization", "Bearer "+token)
+	h.Set("x-ms-version", azureBlobVersion)
+	return h
+}
@@ -0,0 +1,97 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/flatcar-linux/ignition/internal/log"
+)
+
+func TestFetchFromAzureBlob(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" || r.Header.Get("x-ms-version") == "" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		w.Write([]byte("private"))
+	}))
+	defer server.Close()
+
+	// treat the server as a blob endpoint
+	defer func(s string) { azureBlobHostSuffix = s }(azureBlobHostSuffix)
+	azureBlobHostSuffix = "127.0.0.1"
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	goodToken := func(*Fetcher) (string, error) { return "token", nil }
+	badToken := func(*Fetcher) (string, error) { return "", errors.New("no managed identity") }
+
+	tests := []struct {
+		u     url.URL
+		token func(*Fetcher) (string, error)
+		err   error
+	}{
+		{u: *serverURL, token: goodToken},
+		{u: *serverURL, token: badToken, err: ErrFailed},
+		{u: *serverURL, err: ErrFailed},
+	}
+
+	logger := log.New(true)
+	defer logger.Close()
+	for i, test := range tests {
+		f := Fetcher{Logger: &logger, AzureBlobToken: test.token}
+		if err := f.newHttpClient(); err != nil {
+			t.Fatal(err)
+		}
+		f.client.client = server.Client()
+
+		dest, err := ioutil.TempFile("", "ignition-azure-test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(dest.Name())
+		defer dest.Close()
+
+		err = f.FetchFromHTTP(test.u, dest, FetchOptions{})
+		if err != test.err {
+			t.Errorf("#%d: expected error %v, got %v", i, test.err, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		out, err := ioutil.ReadFile(dest.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != "private" {
+			t.Errorf("#%d: expected %q, got %q", i, "private", out)
+		}
+	}
+
+	// tokens aren't sent over http
+	if isAzureBlob(url.URL{Scheme: "http", Host: "account.blob.core.windows.net"}) {
+		t.Errorf("http URL treated as blob")
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// cachePath returns the path of the blob in the download cache holding the
+// contents expected by opts, or "" if the cache is disabled or opts don't
+// expect a particular sum. Sums of the supported hash functions differ in
+// length, so blobs expected with different functions never share a path.
+func (f *Fetcher) cachePath(opts FetchOptions) string {
+	if f.CacheDir == "" || opts.Hash == nil || len(opts.ExpectedSum) == 0 {
+		return ""
+	}
+	return filepath.Join(f.CacheDir, hex.EncodeToString(opts.ExpectedSum))
+}
+
+// fetchFromCache copies the cached blob for opts into dest after verifying it
+// against opts.ExpectedSum. It returns false, leaving dest empty, if there is
+// no such blob or it doesn't match.
+func (f *Fetcher) fetchFromCache(dest *os.File, opts FetchOptions) bool {
+	path := f.cachePath(opts)
+	if path == "" {
+		return false
+	}
+
+	blob, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return false
+	} else if err != nil {
+		f.Logger.Warning("failed to open cached blob %q: %v", path, err)
+		return false
+	}
+	defer blob.Close()
+
+	err = f.decompressCopyHashAndVerify(dest, blob, FetchOptions{
+		Hash:        opts.Hash,
+		ExpectedSum: opts.ExpectedSum,
+	})
+	if err == nil {
+		f.Logger.Info("using cached blob %q", path)
+		return true
+	}
+
+	f.Logger.Warning("ignoring cached blob %q: %v", path, err)
+	if err := dest.Truncate(0); err != nil {
+		return false
+	}
+	dest.Seek(0, os.SEEK_SET)
+	return false
+}
+
+// addToCache stores the contents of dest, which were fetched and verified
+// against opts, in the download cache. Failures are logged but otherwise
+// ignored since the cache is only an optimization.
+func (f *Fetcher) addToCache(dest *os.File, opts FetchOptions) {
+	path := f.cachePath(opts)
+	if path == "" || f.Logger.DryRun() {
+		return
+	}
+	if _, err := os.Stat(path); err == nil {
+		return
+	}
+	if err := f.writeCacheBlob(path, dest); err != nil {
+		f.Logger.Warning("failed to cache blob %q: %v", path, err)
+	}
+}
+
+// writeCacheBlob atomically writes the contents of src to path without moving
+// the cursor of src.
+func (f *Fetcher) writeCacheBlob(path string, src *os.File) error {
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(f.CacheDir, 0700); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that parallel fetches and readers
+	// never see a partial blob.
+	tmp, err := ioutil.TempFile(f.CacheDir, ".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err := io.Copy(tmp, io.NewSectionReader(src, 0, info.Size())); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
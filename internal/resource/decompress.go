@@ -0,0 +1,114 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/flatcar-linux/ignition/internal/log"
+)
+
+// cmdReader is an io.ReadCloser reading the output of a decompression
+// command which is fed the compressed stream on stdin.
+type cmdReader struct {
+	cmd     *exec.Cmd
+	stdout  io.ReadCloser
+	stderr  bytes.Buffer
+	copyErr chan error
+	done    bool
+	err     error
+}
+
+// newCmdReader starts name with args, copying r to its stdin, and returns a
+// reader for its stdout. Reading fails if the command exits unsuccessfully.
+// Close must be called to reap the command.
+func (f *Fetcher) newCmdReader(r io.Reader, name string, args ...string) (io.ReadCloser, error) {
+	c := &cmdReader{
+		cmd:     exec.Command(name, args...),
+		copyErr: make(chan error, 1),
+	}
+	c.cmd.Stderr = &c.stderr
+	stdin, err := c.cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	c.stdout, err = c.cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	f.Logger.Debug("executing: %s", log.QuotedCmd(c.cmd))
+	if err := c.cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	// Copy the input ourselves rather than setting cmd.Stdin, since
+	// cmd.Wait would otherwise wait for r to be drained.
+	go func() {
+		_, err := io.Copy(stdin, r)
+		stdin.Close()
+		c.copyErr <- err
+	}()
+	return c, nil
+}
+
+func (c *cmdReader) Read(p []byte) (int, error) {
+	if c.done {
+		return 0, c.err
+	}
+	n, err := c.stdout.Read(p)
+	if err == io.EOF {
+		c.done = true
+		c.err = c.wait()
+		if c.err == nil {
+			c.err = io.EOF
+		}
+		return n, c.err
+	}
+	return n, err
+}
+
+// wait reaps the command. Errors reading the input take precedence over the
+// command's, since they are the likely cause of the command failing.
+func (c *cmdReader) wait() error {
+	err := c.cmd.Wait()
+	select {
+	case copyErr := <-c.copyErr:
+		if copyErr != nil {
+			return copyErr
+		}
+	default:
+	}
+	if err != nil {
+		return fmt.Errorf("%s failed: %v: %s", filepath.Base(c.cmd.Path), err, strings.TrimSpace(c.stderr.String()))
+	}
+	return nil
+}
+
+// Close kills the command if it is still running and reaps it.
+func (c *cmdReader) Close() error {
+	if c.done {
+		return nil
+	}
+	c.done = true
+	c.err = io.ErrClosedPipe
+	c.cmd.Process.Kill()
+	c.cmd.Wait()
+	return nil
+}
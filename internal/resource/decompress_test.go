@@ -0,0 +1,80 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"os/exec"
+	"testing"
+
+	"github.com/flatcar-linux/ignition/internal/log"
+)
+
+func TestCmdReader(t *testing.T) {
+	tests := []struct {
+		cmd        string
+		compressed string
+	}{
+		{
+			cmd:        "xz",
+			compressed: "/Td6WFoAAATm1rRGBMANCSEBFgAAAAAAAAAAAF9PM+QBAAhhc2RmCmZkc2EAAAAAANdXzrGK+iUAASkJZJIcHR+2830BAAAAAARZWg==",
+		},
+		{
+			cmd:        "zstd",
+			compressed: "KLUv/QRYSQAAYXNkZgpmZHNhMKNWHg==",
+		},
+	}
+
+	logger := log.New(true)
+	defer logger.Close()
+	f := Fetcher{Logger: &logger}
+
+	for _, test := range tests {
+		path, err := exec.LookPath(test.cmd)
+		if err != nil {
+			t.Logf("%s not found, skipping", test.cmd)
+			continue
+		}
+		in, err := base64.StdEncoding.DecodeString(test.compressed)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r, err := f.newCmdReader(bytes.NewReader(in), path, "--decompress", "--stdout")
+		if err != nil {
+			t.Fatalf("%s: couldn't start: %v", test.cmd, err)
+		}
+		out, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.cmd, err)
+		} else if string(out) != "asdf\nfdsa" {
+			t.Errorf("%s: expected %q, got %q", test.cmd, "asdf\nfdsa", out)
+		}
+
+		// truncated input
+		r, err = f.newCmdReader(bytes.NewReader(in[:len(in)/2]), path, "--decompress", "--stdout")
+		if err != nil {
+			t.Fatalf("%s: couldn't start: %v", test.cmd, err)
+		}
+		_, err = ioutil.ReadAll(r)
+		r.Close()
+		if err == nil {
+			t.Errorf("%s: expected an error for truncated input", test.cmd)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/flatcar-linux/ignition/internal/decrypt"
+	"github.com/flatcar-linux/ignition/internal/distro"
+)
+
+// fetchAndDecrypt fetches the encrypted resource at u, decrypts it with the
+// key referenced by opts.DecryptionKey and writes the decompressed and
+// verified plaintext to dest.
+func (f *Fetcher) fetchAndDecrypt(u url.URL, dest *os.File, opts FetchOptions) error {
+	envelope, err := f.FetchToBuffer(u, FetchOptions{
+		Headers:     opts.Headers,
+		RetryPolicy: opts.RetryPolicy,
+	})
+	if err != nil {
+		return err
+	}
+	key, err := f.loadDecryptionKey(opts.DecryptionKey)
+	if err != nil {
+		return err
+	}
+	plaintext, err := decrypt.Decrypt(envelope, key)
+	if err != nil {
+		f.Logger.Err("Unable to decrypt resource with key %q: %v", opts.DecryptionKey, err)
+		return err
+	}
+	return f.decompressCopyHashAndVerify(dest, bytes.NewReader(plaintext), opts)
+}
+
+// loadDecryptionKey reads the private key referenced by ref, which is either
+// an oem URL or a path relative to the system config directory.
+func (f *Fetcher) loadDecryptionKey(ref string) ([]byte, error) {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "oem" {
+		return f.FetchToBuffer(*u, FetchOptions{})
+	}
+	key, err := ioutil.ReadFile(filepath.Join(distro.SystemConfigDir(), filepath.FromSlash(ref)))
+	if err != nil {
+		f.Logger.Err("Unable to read decryption key %q: %v", ref, err)
+		return nil, err
+	}
+	return key, nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/flatcar-linux/ignition/internal/log"
+	"github.com/flatcar-linux/ignition/internal/util"
+)
+
+func TestFetchEncrypted(t *testing.T) {
+	// created with age
+	identity := "AGE-SECRET-KEY-1QR5TC9EP0S79VJ50VN8R52Z3D6J0VQQJ8RS0MD9X53UXNLR5S9MSD5AX5X\n"
+	envelope := "data:;base64,YWdlLWVuY3J5cHRpb24ub3JnL3YxCi0+IFgyNTUxOSBMMXQxSGZnS2pJVXU3Zll3WWhYeXhuOHhWNG1hNklDNGtNcVFtTi9meERFCmZ2Q1gvQzJiNHVoTElqRW9qNzdJUHpBTGYyUi9ScFhQMEtETHk5bDZSVFEKLS0tIGY4R2l1Y252UHFDYmU3U2xibmMzSjNBT200dEpyVXB4RjRKakc5RzVVWGMK/jFFhfuSFQDHz20rKtsNi93JK7PWpYAK/92tKYdEoOYpDrN5zDVLoMu4MZBEvzQ="
+	plaintext := "encrypted file\n"
+
+	dir, err := ioutil.TempDir("", "ignition-decrypt-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "ignition.key"), []byte(identity), 0600); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv("IGNITION_SYSTEM_CONFIG_DIR")
+	os.Setenv("IGNITION_SYSTEM_CONFIG_DIR", dir)
+
+	u, err := url.Parse(envelope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha512.Sum512([]byte(plaintext))
+
+	tests := []struct {
+		key         string
+		expectedSum []byte
+		out         string
+		err         bool
+	}{
+		{key: "ignition.key", out: plaintext},
+		{key: "ignition.key", expectedSum: sum[:], out: plaintext},
+		{key: "missing.key", err: true},
+	}
+
+	logger := log.New(true)
+	defer logger.Close()
+	for i, test := range tests {
+		f := Fetcher{Logger: &logger}
+		opts := FetchOptions{DecryptionKey: test.key}
+		if test.expectedSum != nil {
+			opts.Hash = sha512.New()
+			opts.ExpectedSum = test.expectedSum
+		}
+		blob, err := f.FetchToBuffer(*u, opts)
+		if test.err {
+			if err == nil {
+				t.Errorf("#%d: expected an error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+		} else if string(blob) != test.out {
+			t.Errorf("#%d: bad contents: want %q, got %q", i, test.out, blob)
+		}
+	}
+
+	// the hash is verified against the plaintext
+	f := Fetcher{Logger: &logger}
+	_, err = f.FetchToBuffer(*u, FetchOptions{
+		DecryptionKey: "ignition.key",
+		Hash:          sha512.New(),
+		ExpectedSum:   make([]byte, sha512.Size),
+	})
+	if _, ok := err.(util.ErrHashMismatch); !ok {
+		t.Errorf("expected a hash mismatch, got %v", err)
+	} else if err.(util.ErrHashMismatch).Calculated != hex.EncodeToString(sum[:]) {
+		t.Errorf("hash wasn't calculated over the plaintext: %v", err)
+	}
+}
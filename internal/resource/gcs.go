@@ -0,0 +1,54 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+)
+
+var (
+	// gcsURL is the base URL of the Cloud Storage XML API.
+	gcsURL = url.URL{
+		Scheme: "https",
+		Host:   "storage.googleapis.com",
+	}
+)
+
+// FetchFromGCS gets data from a Google Cloud Storage bucket as described by u
+// and writes it into dest, returning an error if one is encountered. The
+// object is fetched over HTTPS, authenticated with the token from GCSToken if
+// one is available and anonymously otherwise.
+func (f *Fetcher) FetchFromGCS(u url.URL, dest *os.File, opts FetchOptions) error {
+	objectURL := gcsURL
+	objectURL.Path = "/" + u.Host + u.Path
+
+	headers := http.Header{}
+	for k, v := range opts.Headers {
+		headers[k] = v
+	}
+	if f.GCSToken != nil {
+		token, err := f.GCSToken(f)
+		if err != nil {
+			f.Logger.Warning("failed to get GCS access token, fetching anonymously: %v", err)
+		} else {
+			headers.Set("Authorization", "Bearer "+token)
+		}
+	}
+	opts.Headers = headers
+
+	return f.FetchFromHTTP(objectURL, dest, opts)
+}
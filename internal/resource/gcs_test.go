@@ -0,0 +1,104 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/flatcar-linux/ignition/internal/log"
+)
+
+func TestFetchFromGCS(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/bucket/public/obj":
+			w.Write([]byte("public"))
+		case "/bucket/private/obj":
+			if r.Header.Get("Authorization") != "Bearer token" {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			w.Write([]byte("private"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(u url.URL) { gcsURL = u }(gcsURL)
+	gcsURL = url.URL{Scheme: serverURL.Scheme, Host: serverURL.Host}
+
+	goodToken := func(*Fetcher) (string, error) { return "token", nil }
+	badToken := func(*Fetcher) (string, error) { return "", errors.New("no metadata service") }
+
+	tests := []struct {
+		in    string
+		token func(*Fetcher) (string, error)
+		out   string
+		err   error
+	}{
+		{in: "gs://bucket/public/obj", out: "public"},
+		{in: "gs://bucket/public/obj", token: badToken, out: "public"},
+		{in: "gs://bucket/private/obj", token: goodToken, out: "private"},
+		{in: "gs://bucket/private/obj", token: badToken, err: ErrFailed},
+		{in: "gs://bucket/missing", token: goodToken, err: ErrNotFound},
+	}
+
+	logger := log.New(true)
+	defer logger.Close()
+	for i, test := range tests {
+		f := Fetcher{Logger: &logger, GCSToken: test.token}
+		if err := f.newHttpClient(); err != nil {
+			t.Fatal(err)
+		}
+
+		dest, err := ioutil.TempFile("", "ignition-gcs-test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(dest.Name())
+		defer dest.Close()
+
+		u, err := url.Parse(test.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = f.FetchFromGCS(*u, dest, FetchOptions{})
+		if err != test.err {
+			t.Errorf("#%d: expected error %v, got %v", i, test.err, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		out, err := ioutil.ReadFile(dest.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != test.out {
+			t.Errorf("#%d: expected %q, got %q", i, test.out, out)
+		}
+	}
+}
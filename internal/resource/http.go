@@ -40,9 +40,6 @@ import (
 )
 
 const (
-	initialBackoff = 100 * time.Millisecond
-	maxBackoff     = 5 * time.Second
-
 	defaultHttpResponseHeaderTimeout = 10
 	defaultHttpTotalTimeout          = 0
 )
@@ -60,16 +57,32 @@ type HttpClient struct {
 	timeout time.Duration
 
 	transport *http.Transport
-	cas       map[types.CaReference][]byte
+	cas       map[string][]byte
+}
+
+// SetLogger makes the fetcher and its HTTP client log to l. The HTTP client is
+// copied, so copies of the fetcher made before calling SetLogger still log to
+// the previous logger.
+func (f *Fetcher) SetLogger(l *log.Logger) {
+	f.Logger = l
+	if f.client != nil {
+		c := *f.client
+		c.logger = l
+		f.client = &c
+	}
 }
 
-func (f *Fetcher) UpdateHttpTimeoutsAndCAs(timeouts types.Timeouts, cas []types.CaReference, proxy types.Proxy) error {
+// UpdateHttpTimeoutsAndCAs applies the timeouts, retry policy, TLS (CAs and
+// client certificate) and proxy settings from a config to the fetcher.
+func (f *Fetcher) UpdateHttpTimeoutsAndCAs(timeouts types.Timeouts, tlsCfg types.TLS, proxy types.Proxy) error {
 	if f.client == nil {
 		if err := f.newHttpClient(); err != nil {
 			return err
 		}
 	}
 
+	f.RetryPolicy = RetryPolicyFromTimeouts(timeouts)
+
 	// Update timeouts
 	responseHeader := defaultHttpResponseHeaderTimeout
 	total := defaultHttpTotalTimeout
@@ -92,50 +105,103 @@ func (f *Fetcher) UpdateHttpTimeoutsAndCAs(timeouts types.Timeouts, cas []types.
 	}
 	f.client.client.Transport = f.client.transport
 
-	// Update CAs
-	if len(cas) == 0 {
+	// Update CAs and client certificate
+	if len(tlsCfg.CertificateAuthorities) == 0 && tlsCfg.ClientCertificate == nil {
 		return nil
 	}
 
+	tlsConfig := &tls.Config{}
+	if len(tlsCfg.CertificateAuthorities) > 0 {
+		pool, err := f.newCertPool(tlsCfg.CertificateAuthorities)
+		if err != nil {
+			return err
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if tlsCfg.ClientCertificate != nil && tlsCfg.ClientKey != nil {
+		cert, err := f.getClientCertificate(*tlsCfg.ClientCertificate, *tlsCfg.ClientKey)
+		if err != nil {
+			return err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	f.client.transport.TLSClientConfig = tlsConfig
+
+	return nil
+}
+
+// newCertPool returns the system certificate pool with the given CAs added.
+func (f *Fetcher) newCertPool(cas []types.CaReference) (*x509.CertPool, error) {
 	pool, err := x509.SystemCertPool()
 	if err != nil {
 		f.Logger.Err("Unable to read system certificate pool: %s", err)
-		return err
+		return nil, err
 	}
 
 	for _, ca := range cas {
 		cablob, err := f.getCABlob(ca)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		block, _ := pem.Decode(cablob)
 		if block == nil {
 			f.Logger.Err("Unable to decode CA (%s)", ca.Source)
-			return ErrPEMDecodeFailed
+			return nil, ErrPEMDecodeFailed
 		}
 
 		cert, err := x509.ParseCertificate(block.Bytes)
 		if err != nil {
 			f.Logger.Err("Unable to parse CA (%s): %s", ca.Source, err)
-			return err
+			return nil, err
 		}
 
 		f.Logger.Info("Adding %q to list of CAs", cert.Subject.CommonName)
 		pool.AddCert(cert)
 	}
+	return pool, nil
+}
 
-	f.client.transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+// getClientCertificate fetches the PEM encoded client certificate and key
+// referenced by certRef and keyRef and returns them as a key pair.
+func (f *Fetcher) getClientCertificate(certRef, keyRef types.CaReference) (tls.Certificate, error) {
+	certblob, err := f.getCABlob(certRef)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	keyblob, err := f.getCABlob(keyRef)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
 
-	return nil
+	cert, err := tls.X509KeyPair(certblob, keyblob)
+	if err != nil {
+		f.Logger.Err("Unable to load client certificate: %s", err)
+		return tls.Certificate{}, err
+	}
+	if leaf, err := x509.ParseCertificate(cert.Certificate[0]); err == nil {
+		f.Logger.Info("Using %q as client certificate", leaf.Subject.CommonName)
+	}
+	return cert, nil
+}
+
+// caKey returns the key of ca in the cache of fetched CAs and client
+// certificates.
+func caKey(ca types.CaReference) string {
+	key := ca.Source
+	if ca.Verification.Hash != nil {
+		key += "\x00" + *ca.Verification.Hash
+	}
+	return key
 }
 
 func (f *Fetcher) getCABlob(ca types.CaReference) ([]byte, error) {
-	if blob, ok := f.client.cas[ca]; ok {
+	if blob, ok := f.client.cas[caKey(ca)]; ok {
 		return blob, nil
 	}
 	u, err := url.Parse(ca.Source)
 	if err != nil {
-		f.Logger.Crit("Unable to parse CA URL: %s", err)
+		f.Logger.Crit("Unable to parse URL (%s): %s", ca.Source, err)
 		return nil, err
 	}
 	hasher, err := util.GetHasher(ca.Verification)
@@ -157,32 +223,81 @@ func (f *Fetcher) getCABlob(ca types.CaReference) ([]byte, error) {
 	}
 
 	cablob, err := f.FetchToBuffer(*u, FetchOptions{
+		Headers:     MergeHeaders(nil, ca.HTTPHeaders),
 		Hash:        hasher,
 		ExpectedSum: expectedSum,
 	})
 	if err != nil {
-		f.Logger.Err("Unable to fetch CA (%s): %s", u, err)
+		f.Logger.Err("Unable to fetch TLS resource (%s): %s", u, err)
 		return nil, err
 	}
-	f.client.cas[ca] = cablob
+	f.client.cas[caKey(ca)] = cablob
 	return cablob, nil
 
 }
 
-// RewriteCAsWithDataUrls will modify the passed in slice of CA references to
-// contain the actual CA file via a dataurl in their source field.
-func (f *Fetcher) RewriteCAsWithDataUrls(cas []types.CaReference) error {
-	for i, ca := range cas {
-		blob, err := f.getCABlob(ca)
+// RewriteCAsWithDataUrls will modify the CA and client certificate references
+// in the passed in TLS config to contain the actual files via a dataurl in
+// their source field. The client key is left alone, so that it doesn't end up
+// in the config cache; see ClientKey and AddClientKey.
+func (f *Fetcher) RewriteCAsWithDataUrls(tlsCfg *types.TLS) error {
+	refs := []*types.CaReference{tlsCfg.ClientCertificate}
+	for i := range tlsCfg.CertificateAuthorities {
+		refs = append(refs, &tlsCfg.CertificateAuthorities[i])
+	}
+	for _, ref := range refs {
+		if ref == nil {
+			continue
+		}
+		blob, err := f.getCABlob(*ref)
 		if err != nil {
 			return err
 		}
 
-		cas[i].Source = dataurl.EncodeBytes(blob)
+		ref.Source = dataurl.EncodeBytes(blob)
+		// the headers are neither needed nor allowed for data URLs
+		ref.HTTPHeaders = nil
+	}
+	return nil
+}
+
+// ClientKey returns the client key referenced by tlsCfg, fetching it if
+// needed, or nil if there is none.
+func (f *Fetcher) ClientKey(tlsCfg types.TLS) ([]byte, error) {
+	if tlsCfg.ClientKey == nil {
+		return nil, nil
+	}
+	return f.getCABlob(*tlsCfg.ClientKey)
+}
+
+// AddClientKey makes the fetcher use blob as the contents of the client key
+// referenced by ref instead of fetching it.
+func (f *Fetcher) AddClientKey(ref types.CaReference, blob []byte) error {
+	if f.client == nil {
+		if err := f.newHttpClient(); err != nil {
+			return err
+		}
 	}
+	f.client.cas[caKey(ref)] = blob
 	return nil
 }
 
+// MergeHeaders returns the headers in base with the headers from a config
+// added, replacing those in base with the same name. base is not modified.
+func MergeHeaders(base http.Header, headers types.HTTPHeaders) http.Header {
+	if len(headers) == 0 {
+		return base
+	}
+	res := http.Header{}
+	for k, v := range base {
+		res[k] = v
+	}
+	for _, h := range headers {
+		res.Set(h.Name, h.Value)
+	}
+	return res
+}
+
 // DefaultHTTPClient builds the default `http.client` for Ignition.
 func defaultHTTPClient() (*http.Client, error) {
 	urand, err := earlyrand.UrandomReader()
@@ -223,7 +338,7 @@ func (f *Fetcher) newHttpClient() error {
 		logger:    f.Logger,
 		timeout:   time.Duration(defaultHttpTotalTimeout) * time.Second,
 		transport: defaultClient.Transport.(*http.Transport),
-		cas:       make(map[types.CaReference][]byte),
+		cas:       make(map[string][]byte),
 	}
 	return nil
 }
@@ -232,7 +347,9 @@ func (f *Fetcher) newHttpClient() error {
 // provided request header and returns the response body Reader, HTTP status
 // code, a cancel function for the result's context, and error (if any). By
 // default, User-Agent is added to the header but this can be overridden.
-func (c HttpClient) getReaderWithHeader(url string, header http.Header) (io.ReadCloser, int, context.CancelFunc, error) {
+// Requests which fail or receive a 5XX response are retried according to
+// policy.
+func (c HttpClient) getReaderWithHeader(url string, header http.Header, policy RetryPolicy) (io.ReadCloser, int, context.CancelFunc, error) {
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, 0, nil, err
@@ -253,29 +370,27 @@ func (c HttpClient) getReaderWithHeader(url string, header http.Header) (io.Read
 		ctx, cancelFn = context.WithTimeout(context.Background(), c.timeout)
 	}
 
-	duration := initialBackoff
 	for attempt := 1; ; attempt++ {
 		c.logger.Info("GET %s: attempt #%d", url, attempt)
 		resp, err := c.client.Do(req.WithContext(ctx))
+		lastAttempt := policy.MaxAttempts != 0 && attempt >= policy.MaxAttempts
 
 		if err == nil {
 			c.logger.Info("GET result: %s", http.StatusText(resp.StatusCode))
-			if resp.StatusCode < 500 {
+			if resp.StatusCode < 500 || lastAttempt {
 				return resp.Body, resp.StatusCode, cancelFn, nil
 			}
 			resp.Body.Close()
 		} else {
 			c.logger.Info("GET error: %v", err)
-		}
-
-		duration = duration * 2
-		if duration > maxBackoff {
-			duration = maxBackoff
+			if lastAttempt || !policy.retryable(err) {
+				return nil, 0, cancelFn, err
+			}
 		}
 
 		// Wait before next attempt or exit if we timeout while waiting
 		select {
-		case <-time.After(duration):
+		case <-time.After(policy.backoff(attempt)):
 		case <-ctx.Done():
 			return nil, 0, cancelFn, ErrTimeout
 		}
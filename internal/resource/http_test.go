@@ -0,0 +1,140 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/flatcar-linux/ignition/internal/config/types"
+	"github.com/flatcar-linux/ignition/internal/log"
+
+	"github.com/vincent-petithory/dataurl"
+)
+
+// newTestKeyPair returns a PEM encoded self-signed certificate for 127.0.0.1
+// and its key.
+func newTestKeyPair(t *testing.T) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ignition-test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+}
+
+func TestClientCertificate(t *testing.T) {
+	certPEM, keyPEM := newTestKeyPair(t)
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(certPEM)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("mutual"))
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ca := []types.CaReference{{Source: dataurl.EncodeBytes(certPEM)}}
+	certRef := &types.CaReference{Source: dataurl.EncodeBytes(certPEM)}
+	keyRef := &types.CaReference{Source: dataurl.EncodeBytes(keyPEM)}
+	badRef := &types.CaReference{Source: dataurl.EncodeBytes([]byte("garbage"))}
+
+	tests := []struct {
+		tls     types.TLS
+		out     string
+		err     bool
+		loadErr bool
+	}{
+		{tls: types.TLS{CertificateAuthorities: ca, ClientCertificate: certRef, ClientKey: keyRef}, out: "mutual"},
+		{tls: types.TLS{CertificateAuthorities: ca}, err: true},
+		{tls: types.TLS{CertificateAuthorities: ca, ClientCertificate: badRef, ClientKey: keyRef}, loadErr: true},
+		{tls: types.TLS{CertificateAuthorities: ca, ClientCertificate: certRef, ClientKey: badRef}, loadErr: true},
+	}
+
+	logger := log.New(true)
+	defer logger.Close()
+	total, attempts := 5, 1
+	timeouts := types.Timeouts{HTTPTotal: &total, RetryMaxAttempts: &attempts}
+	for i, test := range tests {
+		f := Fetcher{Logger: &logger}
+		err := f.UpdateHttpTimeoutsAndCAs(timeouts, test.tls, types.Proxy{})
+		if test.loadErr {
+			if err == nil {
+				t.Errorf("#%d: expected an error loading the client certificate", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+			continue
+		}
+
+		blob, err := f.FetchToBuffer(*u, FetchOptions{})
+		if test.err {
+			if err == nil {
+				t.Errorf("#%d: expected an error fetching without a client certificate", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+		} else if string(blob) != test.out {
+			t.Errorf("#%d: bad contents: want %q, got %q", i, test.out, blob)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"errors"
+	"math/rand"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	configErrors "github.com/flatcar-linux/ignition/config/shared/errors"
+	"github.com/flatcar-linux/ignition/internal/config/types"
+	"github.com/flatcar-linux/ignition/internal/util"
+)
+
+const (
+	defaultInitialBackoff = 100 * time.Millisecond
+	defaultMaxBackoff     = 5 * time.Second
+)
+
+var (
+	jitterMu   sync.Mutex
+	jitterRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// RetryPolicy describes how failed fetches are retried. The zero value is
+// the default policy.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts made. If zero, http(s)
+	// fetches are retried until the total HTTP timeout expires while all
+	// other schemes are only attempted once.
+	MaxAttempts int
+
+	// InitialBackoff is the time waited after the first failed attempt. It
+	// doubles for every further failed attempt, up to MaxBackoff. If zero,
+	// 100ms and 5s are used respectively.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+
+	// Jitter is the fraction, between 0 and 1, by which every backoff is
+	// randomly lengthened or shortened.
+	Jitter float64
+
+	// Retryable returns whether a fetch which failed with err should be
+	// retried. If nil, DefaultRetryable is used.
+	Retryable func(err error) bool
+}
+
+var permanentErrors = []error{
+	ErrSchemeUnsupported,
+	ErrPathNotAbsolute,
+	ErrNotFound,
+	ErrTooLarge,
+	ErrS3Options,
+	configErrors.ErrCompressionInvalid,
+}
+
+// DefaultRetryable retries all errors except those which will not go away by
+// trying again, like unsupported schemes, missing resources or contents
+// which don't match the expected hash, even if they are wrapped.
+func DefaultRetryable(err error) bool {
+	var mismatch util.ErrHashMismatch
+	if errors.As(err, &mismatch) {
+		return false
+	}
+	for _, permanent := range permanentErrors {
+		if errors.Is(err, permanent) {
+			return false
+		}
+	}
+	return true
+}
+
+// RetryPolicyFromTimeouts returns the retry policy described by the retry
+// fields of timeouts.
+func RetryPolicyFromTimeouts(timeouts types.Timeouts) RetryPolicy {
+	var p RetryPolicy
+	if timeouts.RetryMaxAttempts != nil {
+		p.MaxAttempts = *timeouts.RetryMaxAttempts
+	}
+	if timeouts.RetryInitialBackoff != nil {
+		p.InitialBackoff = time.Duration(*timeouts.RetryInitialBackoff) * time.Millisecond
+	}
+	if timeouts.RetryMaxBackoff != nil {
+		p.MaxBackoff = time.Duration(*timeouts.RetryMaxBackoff) * time.Millisecond
+	}
+	if timeouts.RetryJitter != nil {
+		p.Jitter = float64(*timeouts.RetryJitter) / 100
+	}
+	return p
+}
+
+func (p RetryPolicy) retryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return DefaultRetryable(err)
+}
+
+// backoff returns the time to wait after the given (1-based) failed attempt.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	initial := p.InitialBackoff
+	if initial <= 0 {
+		initial = defaultInitialBackoff
+	}
+	max := p.MaxBackoff
+	if max <= 0 {
+		max = defaultMaxBackoff
+	}
+	if initial > max {
+		initial = max
+	}
+
+	d := initial
+	for i := 1; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+
+	if p.Jitter > 0 {
+		jitterMu.Lock()
+		r := jitterRand.Float64()
+		jitterMu.Unlock()
+		d += time.Duration(float64(d) * p.Jitter * (2*r - 1))
+	}
+	return d
+}
+
+// retryPolicy returns the policy to use for a fetch with the given options.
+func (f *Fetcher) retryPolicy(opts FetchOptions) RetryPolicy {
+	if opts.RetryPolicy != nil {
+		return *opts.RetryPolicy
+	}
+	return f.RetryPolicy
+}
+
+// fetchWithRetries calls fetch until it succeeds or the retry policy gives up,
+// emptying dest between attempts.
+func (f *Fetcher) fetchWithRetries(u url.URL, dest *os.File, opts FetchOptions, fetch func(url.URL, *os.File, FetchOptions) error) error {
+	policy := f.retryPolicy(opts)
+	for attempt := 1; ; attempt++ {
+		err := fetch(u, dest, opts)
+		if err == nil || attempt >= policy.MaxAttempts || !policy.retryable(err) {
+			return err
+		}
+
+		wait := policy.backoff(attempt)
+		f.Logger.Info("fetching %s failed (attempt #%d): %v, retrying in %v", u.String(), attempt, err, wait)
+		if err := dest.Truncate(0); err != nil {
+			return err
+		}
+		if _, err := dest.Seek(0, os.SEEK_SET); err != nil {
+			return err
+		}
+		time.Sleep(wait)
+	}
+}
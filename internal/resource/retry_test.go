@@ -0,0 +1,47 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	configErrors "github.com/flatcar-linux/ignition/config/shared/errors"
+	"github.com/flatcar-linux/ignition/internal/util"
+)
+
+func TestDefaultRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: errors.New("connection refused"), want: true},
+		{err: ErrFailed, want: true},
+		{err: ErrNotFound, want: false},
+		{err: ErrSchemeUnsupported, want: false},
+		{err: configErrors.ErrCompressionInvalid, want: false},
+		{err: fmt.Errorf("fetching %q: %w", "s3://bucket/key", ErrNotFound), want: false},
+		{err: fmt.Errorf("fetching %q: %w", "s3://bucket/key", ErrTooLarge), want: false},
+		{err: util.ErrHashMismatch{Calculated: "ab", Expected: "cd"}, want: false},
+		{err: fmt.Errorf("verifying: %w", util.ErrHashMismatch{}), want: false},
+	}
+
+	for i, test := range tests {
+		if got := DefaultRetryable(test.err); got != test.want {
+			t.Errorf("#%d: %v: want %t, got %t", i, test.err, test.want, got)
+		}
+	}
+}
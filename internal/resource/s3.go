@@ -0,0 +1,145 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/flatcar-linux/ignition/internal/distro"
+	"github.com/flatcar-linux/ignition/internal/log"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+const (
+	s3EndpointFlag        = "ignition.s3.endpoint"
+	s3RegionFlag          = "ignition.s3.region"
+	s3PathStyleFlag       = "ignition.s3.path_style"
+	s3AccessKeyIDFlag     = "ignition.s3.access_key_id"
+	s3SecretAccessKeyFlag = "ignition.s3.secret_access_key"
+
+	// s3OptionsFilename is the file in the OEM lookaside dir which holds
+	// the same options as the kernel command line.
+	s3OptionsFilename = "ignition-s3.conf"
+)
+
+// S3Options configure the service used for s3:// URLs. The zero value uses
+// AWS, determining the region of each bucket and using the credentials of the
+// Fetcher's AWS session.
+type S3Options struct {
+	// Endpoint is the URL of an S3-compatible service, e.g. MinIO, to use
+	// instead of AWS.
+	Endpoint string
+	// Region is the region used with Endpoint, "us-east-1" if empty.
+	Region string
+	// PathStyle selects path-style addressing (http://endpoint/bucket/key)
+	// instead of virtual-hosted-style addressing.
+	PathStyle bool
+	// AccessKeyID and SecretAccessKey are static credentials used instead of
+	// the session's.
+	AccessKeyID     string
+	SecretAccessKey string
+
+	// Err is the error which occurred while loading the options, if any.
+	// Fetches from S3 fail with it.
+	Err error
+}
+
+// LoadS3Options reads the S3 options from ignition-s3.conf in the OEM
+// lookaside dir and from the kernel command line, which takes precedence.
+// Both contain whitespace-separated key=value pairs.
+func LoadS3Options(logger *log.Logger) (S3Options, error) {
+	var opts S3Options
+
+	path := filepath.Join(distro.OEMLookasideDir(), s3OptionsFilename)
+	conf, err := ioutil.ReadFile(path)
+	if err == nil {
+		logger.Info("reading S3 options from %q", path)
+		if err := opts.parse(conf); err != nil {
+			return S3Options{}, fmt.Errorf("invalid S3 options in %q: %v", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return S3Options{}, err
+	}
+
+	cmdline, err := ioutil.ReadFile(distro.KernelCmdlinePath())
+	if err != nil {
+		return S3Options{}, err
+	}
+	if err := opts.parse(cmdline); err != nil {
+		return S3Options{}, fmt.Errorf("invalid S3 options on the kernel command line: %v", err)
+	}
+
+	if opts.Endpoint != "" {
+		logger.Info("using S3 endpoint %q", opts.Endpoint)
+	}
+	if (opts.AccessKeyID == "") != (opts.SecretAccessKey == "") {
+		return S3Options{}, fmt.Errorf("%s and %s must be set together", s3AccessKeyIDFlag, s3SecretAccessKeyFlag)
+	}
+	return opts, nil
+}
+
+// parse updates o with the S3 options in the whitespace-separated key=value
+// pairs of b, ignoring other keys.
+func (o *S3Options) parse(b []byte) error {
+	for _, arg := range strings.Fields(string(b)) {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		switch value := parts[1]; parts[0] {
+		case s3EndpointFlag:
+			o.Endpoint = value
+		case s3RegionFlag:
+			o.Region = value
+		case s3PathStyleFlag:
+			pathStyle, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid value %q for %s", value, s3PathStyleFlag)
+			}
+			o.PathStyle = pathStyle
+		case s3AccessKeyIDFlag:
+			o.AccessKeyID = value
+		case s3SecretAccessKeyFlag:
+			o.SecretAccessKey = value
+		}
+	}
+	return nil
+}
+
+// apply configures sess to use the service described by o.
+func (o S3Options) apply(sess *session.Session) {
+	if o.Endpoint != "" {
+		sess.Config.Endpoint = aws.String(o.Endpoint)
+		region := o.Region
+		if region == "" {
+			region = "us-east-1"
+		}
+		sess.Config.Region = aws.String(region)
+	}
+	if o.PathStyle {
+		sess.Config.S3ForcePathStyle = aws.Bool(true)
+	}
+	if o.AccessKeyID != "" {
+		sess.Config.Credentials = credentials.NewStaticCredentials(o.AccessKeyID, o.SecretAccessKey, "")
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/flatcar-linux/ignition/internal/log"
+)
+
+func TestS3OptionsParse(t *testing.T) {
+	tests := []struct {
+		in  []string
+		out S3Options
+		err bool
+	}{
+		{
+			in:  []string{"root=/dev/sda1 console=ttyS0"},
+			out: S3Options{},
+		},
+		{
+			in: []string{"ignition.s3.endpoint=http://minio:9000 ignition.s3.path_style=1 ignition.s3.access_key_id=id ignition.s3.secret_access_key=secret"},
+			out: S3Options{
+				Endpoint:        "http://minio:9000",
+				PathStyle:       true,
+				AccessKeyID:     "id",
+				SecretAccessKey: "secret",
+			},
+		},
+		{
+			// the kernel command line is parsed last and overrides the file
+			in: []string{
+				"ignition.s3.endpoint=http://file:9000\nignition.s3.region=eu-west-1\n",
+				"quiet ignition.s3.endpoint=http://cmdline:9000",
+			},
+			out: S3Options{
+				Endpoint: "http://cmdline:9000",
+				Region:   "eu-west-1",
+			},
+		},
+		{
+			in:  []string{"ignition.s3.path_style=maybe"},
+			err: true,
+		},
+	}
+
+	for i, test := range tests {
+		var opts S3Options
+		var err error
+		for _, in := range test.in {
+			if err = opts.parse([]byte(in)); err != nil {
+				break
+			}
+		}
+		if test.err {
+			if err == nil {
+				t.Errorf("#%d: expected an error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+		} else if !reflect.DeepEqual(test.out, opts) {
+			t.Errorf("#%d: expected %+v, got %+v", i, test.out, opts)
+		}
+	}
+}
+
+func TestFetchFromS3Endpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bucket/key" {
+			http.NotFound(w, r)
+			return
+		}
+		if !strings.Contains(r.Header.Get("Authorization"), "Credential=id/") {
+			http.Error(w, "missing credentials", http.StatusForbidden)
+			return
+		}
+		w.Write([]byte("asdf\nfdsa"))
+	}))
+	defer server.Close()
+
+	logger := log.New(true)
+	defer logger.Close()
+	f := Fetcher{
+		Logger: &logger,
+		S3: S3Options{
+			Endpoint:        server.URL,
+			PathStyle:       true,
+			AccessKeyID:     "id",
+			SecretAccessKey: "secret",
+		},
+	}
+
+	dest, err := ioutil.TempFile("", "ignition-s3-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(dest.Name())
+	defer dest.Close()
+
+	if err := f.FetchFromS3(url.URL{Scheme: "s3", Host: "bucket", Path: "/key"}, dest, FetchOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, err := ioutil.ReadFile(dest.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "asdf\nfdsa" {
+		t.Errorf("expected %q, got %q", "asdf\nfdsa", out)
+	}
+
+	// options which failed to load fail the fetch without retrying
+	f.S3 = S3Options{Err: errors.New("bad option")}
+	if err := f.FetchFromS3(url.URL{Scheme: "s3", Host: "bucket", Path: "/key"}, dest, FetchOptions{}); !errors.Is(err, ErrS3Options) || DefaultRetryable(err) {
+		t.Errorf("invalid options: want a permanent %v, got %v", ErrS3Options, err)
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"bytes"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/flatcar-linux/ignition/internal/util"
+)
+
+var (
+	ErrNoSignatureLocation = errors.New("no detached signature location for config source")
+	ErrNoSignature         = errors.New("config is not signed")
+)
+
+// signatureBlockType is the type of the PEM block embedding the signature of
+// a config in front of it.
+const signatureBlockType = "IGNITION SIGNATURE"
+
+// SplitSignedConfig splits a config with an embedded signature, i.e. a PEM
+// block of type "IGNITION SIGNATURE" holding the signature followed by the
+// signed config, into the config and its signature. ok is false if raw
+// doesn't start with such a block.
+func SplitSignedConfig(raw []byte) (config, signature []byte, ok bool) {
+	if !bytes.HasPrefix(raw, []byte("-----BEGIN "+signatureBlockType+"-----")) {
+		return nil, nil, false
+	}
+	block, rest := pem.Decode(raw)
+	if block == nil || block.Type != signatureBlockType {
+		return nil, nil, false
+	}
+	return rest, block.Bytes, true
+}
+
+// CheckSignature checks that signature, which the caller located itself,
+// signs blob with one of the trusted signing keys. Nothing is checked when no
+// signing keys are trusted. A nil signature fails verification.
+func (f *Fetcher) CheckSignature(blob, signature []byte) error {
+	if len(f.SigningKeys) == 0 {
+		return nil
+	}
+	if signature == nil {
+		f.Logger.Err("Unable to verify config: %v", ErrNoSignature)
+		return ErrNoSignature
+	}
+	return f.assertSigned(blob, signature)
+}
+
+// VerifySignature fetches the detached signature at sig and checks that it
+// signs blob with one of the trusted signing keys.
+func (f *Fetcher) VerifySignature(blob []byte, sig url.URL, headers http.Header) error {
+	signature, err := f.FetchToBuffer(sig, FetchOptions{Headers: headers})
+	if err != nil {
+		f.Logger.Err("Unable to fetch signature: %v", err)
+		return err
+	}
+	return f.assertSigned(blob, signature)
+}
+
+// VerifyConfigSignature checks the config read from source against the
+// detached signature stored next to it, at the source with ".sig" appended.
+// Nothing is checked when no signing keys are trusted. Sources without such
+// a location, like data URLs, fail verification.
+func (f *Fetcher) VerifyConfigSignature(source string, blob []byte, headers http.Header) error {
+	if len(f.SigningKeys) == 0 {
+		return nil
+	}
+	u, err := url.Parse(source)
+	if err != nil {
+		f.Logger.Err("Unable to locate signature of %q: %v", source, err)
+		return ErrNoSignatureLocation
+	}
+	switch u.Scheme {
+	case "":
+		signature, err := ioutil.ReadFile(source + ".sig")
+		if err != nil {
+			f.Logger.Err("Unable to read signature: %v", err)
+			return err
+		}
+		return f.assertSigned(blob, signature)
+	case "http", "https", "tftp", "s3", "gs", "oem":
+		u.Path += ".sig"
+		u.RawPath = ""
+		if u.Scheme == "s3" {
+			// the version of the config says nothing about the signature's
+			u.RawQuery = ""
+		}
+		return f.VerifySignature(blob, *u, headers)
+	default:
+		f.Logger.Err("Unable to locate signature of %q", source)
+		return ErrNoSignatureLocation
+	}
+}
+
+func (f *Fetcher) assertSigned(blob, signature []byte) error {
+	if err := util.AssertSigned(f.SigningKeys, blob, signature); err != nil {
+		f.Logger.Err("Signature verification failed: %v", err)
+		return err
+	}
+	f.Logger.Info("verified config signature")
+	return nil
+}
@@ -0,0 +1,143 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/flatcar-linux/ignition/internal/log"
+	"github.com/flatcar-linux/ignition/internal/util"
+)
+
+func TestVerifyConfigSignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := []byte(`{"ignition": {"version": "2.4.0-experimental"}}`)
+	sig := ed25519.Sign(priv, config)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/config.ign.sig":
+			w.Write(sig)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "ignition-signature")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	signedPath := filepath.Join(dir, "signed.ign")
+	if err := ioutil.WriteFile(signedPath+".sig", sig, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		keys   []ed25519.PublicKey
+		source string
+		err    bool
+	}{
+		{source: "data:,unsigned"},
+		{keys: []ed25519.PublicKey{pub}, source: server.URL + "/config.ign"},
+		{keys: []ed25519.PublicKey{pub}, source: signedPath},
+		{keys: []ed25519.PublicKey{pub}, source: server.URL + "/unsigned.ign", err: true},
+		{keys: []ed25519.PublicKey{pub}, source: filepath.Join(dir, "unsigned.ign"), err: true},
+		{keys: []ed25519.PublicKey{pub}, source: "data:,unsigned", err: true},
+	}
+
+	logger := log.New(true)
+	defer logger.Close()
+	for i, test := range tests {
+		f := Fetcher{Logger: &logger, SigningKeys: test.keys}
+		err := f.VerifyConfigSignature(test.source, config, nil)
+		if test.err && err == nil {
+			t.Errorf("#%d: expected an error verifying %q", i, test.source)
+		} else if !test.err && err != nil {
+			t.Errorf("#%d: unexpected error verifying %q: %v", i, test.source, err)
+		}
+	}
+
+	// an explicit signature source is checked even without the config's
+	// signature next to it
+	u, err := url.Parse(server.URL + "/config.ign.sig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := Fetcher{Logger: &logger, SigningKeys: []ed25519.PublicKey{pub}}
+	if err := f.VerifySignature(config, *u, nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := f.VerifySignature([]byte("tampered"), *u, nil); err != util.ErrSignatureInvalid {
+		t.Errorf("bad error for a tampered config: want %v, got %v", util.ErrSignatureInvalid, err)
+	}
+}
+
+func TestSplitSignedConfig(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := []byte(`{"ignition": {"version": "2.4.0-experimental"}}`)
+	signed := append(pem.EncodeToMemory(&pem.Block{
+		Type:  "IGNITION SIGNATURE",
+		Bytes: ed25519.Sign(priv, config),
+	}), config...)
+
+	blob, sig, ok := SplitSignedConfig(signed)
+	if !ok || !bytes.Equal(blob, config) {
+		t.Fatalf("bad split: ok %v, config %q", ok, blob)
+	}
+	for _, raw := range [][]byte{
+		config,
+		append([]byte("\n"), signed...),
+		append(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: []byte("key")}), config...),
+	} {
+		if _, _, ok := SplitSignedConfig(raw); ok {
+			t.Errorf("split a config without an embedded signature: %q", raw)
+		}
+	}
+
+	logger := log.New(true)
+	defer logger.Close()
+	f := Fetcher{Logger: &logger, SigningKeys: []ed25519.PublicKey{pub}}
+	if err := f.CheckSignature(blob, sig); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := f.CheckSignature([]byte("tampered"), sig); err != util.ErrSignatureInvalid {
+		t.Errorf("bad error for a tampered config: want %v, got %v", util.ErrSignatureInvalid, err)
+	}
+	if err := f.CheckSignature(blob, nil); err != ErrNoSignature {
+		t.Errorf("bad error for a missing signature: want %v, got %v", ErrNoSignature, err)
+	}
+	f.SigningKeys = nil
+	if err := f.CheckSignature(blob, nil); err != nil {
+		t.Errorf("unexpected error without trusted keys: %v", err)
+	}
+}
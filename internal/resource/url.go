@@ -16,8 +16,10 @@ package resource
 
 import (
 	"bytes"
+	"compress/bzip2"
 	"compress/gzip"
 	"context"
+	"crypto/ed25519"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -48,11 +50,12 @@ import (
 )
 
 var (
-	ErrSchemeUnsupported      = errors.New("unsupported source scheme")
-	ErrPathNotAbsolute        = errors.New("path is not absolute")
-	ErrNotFound               = errors.New("resource not found")
-	ErrFailed                 = errors.New("failed to fetch resource")
-	ErrCompressionUnsupported = errors.New("compression is not supported with that scheme")
+	ErrSchemeUnsupported = errors.New("unsupported source scheme")
+	ErrPathNotAbsolute   = errors.New("path is not absolute")
+	ErrNotFound          = errors.New("resource not found")
+	ErrFailed            = errors.New("failed to fetch resource")
+	ErrTooLarge          = errors.New("resource exceeds the size limit")
+	ErrS3Options         = errors.New("invalid S3 options")
 
 	// ConfigHeaders are the HTTP headers that should be used when the Ignition
 	// config is being fetched
@@ -80,6 +83,39 @@ type Fetcher struct {
 	// The region where the EC2 machine trying to fetch is.
 	// This is used as a hint to fetch the S3 bucket from the right partition and region.
 	S3RegionHint string
+
+	// GCSToken returns an OAuth2 access token used to fetch gs:// URLs. If
+	// nil or if it fails, objects are fetched anonymously.
+	GCSToken func(f *Fetcher) (string, error)
+
+	// AzureBlobToken returns an OAuth2 access token attached to https
+	// requests for Azure Blob Storage. If nil or if it fails, blobs are
+	// fetched anonymously.
+	AzureBlobToken func(f *Fetcher) (string, error)
+
+	// S3 configures the S3 service used for s3:// URLs.
+	S3 S3Options
+
+	// S3MaxObjectSize is the maximum size in bytes of S3 objects. Larger
+	// objects fail to fetch. If zero, the size is not limited.
+	S3MaxObjectSize int64
+
+	// RetryPolicy is the policy used for fetches which don't specify their own.
+	RetryPolicy RetryPolicy
+
+	// Concurrency is the maximum number of resources stages may fetch in
+	// parallel. Values below 2 disable parallel fetching.
+	Concurrency int
+
+	// SigningKeys are the ed25519 public keys trusted to sign configs. If
+	// any are set, fetched configs must carry a valid detached signature.
+	SigningKeys []ed25519.PublicKey
+
+	// CacheDir is the directory of the download cache. Resources fetched
+	// with an expected sum are stored there, named after their hex-encoded
+	// sum, and later fetches expecting the same sum are served from it. If
+	// empty, no cache is used.
+	CacheDir string
 }
 
 type FetchOptions struct {
@@ -98,6 +134,15 @@ type FetchOptions struct {
 	// Compression specifies the type of compression to use when decompressing
 	// the fetched object. If left empty, no decompression will be used.
 	Compression string
+
+	// RetryPolicy overrides the Fetcher's retry policy for this fetch.
+	RetryPolicy *RetryPolicy
+
+	// DecryptionKey references the private key used to decrypt the fetched
+	// object, an age file or JWE, before it is decompressed and verified:
+	// either an oem URL or a path relative to the system config directory.
+	// If left empty, the object isn't decrypted.
+	DecryptionKey string
 }
 
 // FetchToBuffer will fetch the given url into a temporrary file, and then read
@@ -131,22 +176,54 @@ func (f *Fetcher) FetchToBuffer(u url.URL, opts FetchOptions) ([]byte, error) {
 // hashed and compared against opts.ExpectedSum, and any match failures will
 // result in an error being returned.
 //
+// Failed fetches are retried according to the retry policy in opts, or the
+// Fetcher's if opts doesn't have one. Data URLs are never retried since they
+// can't fail transiently.
+//
+// If the Fetcher has a CacheDir and opts has an ExpectedSum, the download
+// cache is checked before fetching and updated afterwards. Data URLs and
+// encrypted resources bypass the cache.
+//
+// If opts has a DecryptionKey, the fetched data is decrypted before it is
+// decompressed and verified.
+//
 // Fetch expects dest to be an empty file and for the cursor in the file to be
 // at the beginning. Since some url schemes (ex: s3) use chunked downloads and
 // fetch chunks out of order, Fetch's behavior when dest is not an empty file is
 // undefined.
 func (f *Fetcher) Fetch(u url.URL, dest *os.File, opts FetchOptions) error {
+	if opts.DecryptionKey != "" {
+		return f.fetchAndDecrypt(u, dest, opts)
+	}
+	if u.Scheme == "data" || u.Scheme == "" {
+		return f.fetch(u, dest, opts)
+	}
+
+	if f.fetchFromCache(dest, opts) {
+		return nil
+	}
+	if err := f.fetch(u, dest, opts); err != nil {
+		return err
+	}
+	f.addToCache(dest, opts)
+	return nil
+}
+
+// fetch calls the appropriate FetchFrom* function based on the scheme of u.
+func (f *Fetcher) fetch(u url.URL, dest *os.File, opts FetchOptions) error {
 	switch u.Scheme {
 	case "http", "https":
 		return f.FetchFromHTTP(u, dest, opts)
 	case "tftp":
-		return f.FetchFromTFTP(u, dest, opts)
+		return f.fetchWithRetries(u, dest, opts, f.FetchFromTFTP)
 	case "data":
 		return f.FetchFromDataURL(u, dest, opts)
 	case "oem":
-		return f.FetchFromOEM(u, dest, opts)
+		return f.fetchWithRetries(u, dest, opts, f.FetchFromOEM)
 	case "s3":
-		return f.FetchFromS3(u, dest, opts)
+		return f.fetchWithRetries(u, dest, opts, f.FetchFromS3)
+	case "gs":
+		return f.FetchFromGCS(u, dest, opts)
 	case "":
 		return nil
 	default:
@@ -229,7 +306,12 @@ func (f *Fetcher) FetchFromHTTP(u url.URL, dest *os.File, opts FetchOptions) err
 		}
 	}
 
-	dataReader, status, ctxCancel, err := f.client.getReaderWithHeader(u.String(), opts.Headers)
+	headers := opts.Headers
+	if isAzureBlob(u) {
+		headers = f.azureBlobHeaders(headers)
+	}
+
+	dataReader, status, ctxCancel, err := f.client.getReaderWithHeader(u.String(), headers, f.retryPolicy(opts))
 	if ctxCancel != nil {
 		// whatever context getReaderWithHeader created for the request should
 		// be cancelled once we're done reading the response
@@ -255,9 +337,6 @@ func (f *Fetcher) FetchFromHTTP(u url.URL, dest *os.File, opts FetchOptions) err
 // FetchFromDataURL writes the data stored in the dataurl u into dest, returning
 // an error if one is encountered.
 func (f *Fetcher) FetchFromDataURL(u url.URL, dest *os.File, opts FetchOptions) error {
-	if opts.Compression != "" {
-		return ErrCompressionUnsupported
-	}
 	url, err := dataurl.DecodeString(u.String())
 	if err != nil {
 		return err
@@ -318,9 +397,6 @@ func (f *Fetcher) FetchFromOEM(u url.URL, dest *os.File, opts FetchOptions) erro
 // IAM credentials from the EC2 metadata service, and if this fails will attempt
 // to fetch the object with anonymous credentials.
 func (f *Fetcher) FetchFromS3(u url.URL, dest *os.File, opts FetchOptions) error {
-	if opts.Compression != "" {
-		return ErrCompressionUnsupported
-	}
 	ctx := context.Background()
 	if f.client != nil && f.client.timeout != 0 {
 		var cancelFn context.CancelFunc
@@ -337,22 +413,28 @@ func (f *Fetcher) FetchFromS3(u url.URL, dest *os.File, opts FetchOptions) error
 			return err
 		}
 	}
+	if f.S3.Err != nil {
+		return fmt.Errorf("%w: %v", ErrS3Options, f.S3.Err)
+	}
 	sess := f.AWSSession.Copy()
+	f.S3.apply(sess)
 
-	// Determine the partition and region this bucket is in
-	regionHint := "us-east-1"
-	if f.S3RegionHint != "" {
-		regionHint = f.S3RegionHint
-	}
-	region, err := s3manager.GetBucketRegion(ctx, sess, u.Host, regionHint)
-	if err != nil {
-		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == "NotFound" {
-			return fmt.Errorf("couldn't determine the region for bucket %q: %v", u.Host, err)
+	if f.S3.Endpoint == "" {
+		// Determine the partition and region this bucket is in
+		regionHint := "us-east-1"
+		if f.S3RegionHint != "" {
+			regionHint = f.S3RegionHint
+		}
+		region, err := s3manager.GetBucketRegion(ctx, sess, u.Host, regionHint)
+		if err != nil {
+			if aerr, ok := err.(awserr.Error); ok && aerr.Code() == "NotFound" {
+				return fmt.Errorf("couldn't determine the region for bucket %q: %v", u.Host, err)
+			}
+			return err
 		}
-		return err
-	}
 
-	sess.Config.Region = aws.String(region)
+		sess.Config.Region = aws.String(region)
+	}
 
 	var versionId *string
 	if v, ok := u.Query()["versionId"]; ok && len(v) > 0 {
@@ -364,33 +446,10 @@ func (f *Fetcher) FetchFromS3(u url.URL, dest *os.File, opts FetchOptions) error
 		Key:       &u.Path,
 		VersionId: versionId,
 	}
-	err = f.fetchFromS3WithCreds(ctx, dest, input, sess)
-	if err != nil {
-		return err
-	}
-	if opts.Hash != nil {
-		opts.Hash.Reset()
-		_, err = dest.Seek(0, os.SEEK_SET)
-		if err != nil {
-			return err
-		}
-		_, err = io.Copy(opts.Hash, dest)
-		if err != nil {
-			return err
-		}
-		calculatedSum := opts.Hash.Sum(nil)
-		if !bytes.Equal(calculatedSum, opts.ExpectedSum) {
-			return util.ErrHashMismatch{
-				Calculated: hex.EncodeToString(calculatedSum),
-				Expected:   hex.EncodeToString(opts.ExpectedSum),
-			}
-		}
-		f.Logger.Debug("file matches expected sum of: %s", hex.EncodeToString(opts.ExpectedSum))
-	}
-	return nil
+	return f.fetchFromS3WithCreds(ctx, dest, input, sess, opts)
 }
 
-func (f *Fetcher) fetchFromS3WithCreds(ctx context.Context, dest *os.File, input *s3.GetObjectInput, sess *session.Session) error {
+func (f *Fetcher) fetchFromS3WithCreds(ctx context.Context, dest *os.File, input *s3.GetObjectInput, sess *session.Session, opts FetchOptions) error {
 	httpClient, err := defaultHTTPClient()
 	if err != nil {
 		return err
@@ -398,19 +457,64 @@ func (f *Fetcher) fetchFromS3WithCreds(ctx context.Context, dest *os.File, input
 
 	awsConfig := aws.NewConfig().WithHTTPClient(httpClient)
 	s3Client := s3.New(sess, awsConfig)
-	downloader := s3manager.NewDownloaderWithClient(s3Client)
-	if _, err := downloader.DownloadWithContext(ctx, dest, input); err != nil {
+	if opts.Hash != nil || opts.Compression != "" || f.S3MaxObjectSize > 0 {
+		// The downloader fetches chunks out of order, so the object can
+		// only be hashed, decompressed or limited while it is fetched with
+		// a single request.
+		err = f.streamFromS3(ctx, s3Client, dest, input, opts)
+	} else {
+		downloader := s3manager.NewDownloaderWithClient(s3Client)
+		_, err = downloader.DownloadWithContext(ctx, dest, input)
+	}
+	if err != nil {
 		if awserrval, ok := err.(awserr.Error); ok && awserrval.Code() == "EC2RoleRequestError" {
 			// If this error was due to an EC2 role request error, try again
 			// with the anonymous credentials.
 			sess.Config.Credentials = credentials.AnonymousCredentials
-			return f.fetchFromS3WithCreds(ctx, dest, input, sess)
+			return f.fetchFromS3WithCreds(ctx, dest, input, sess, opts)
 		}
 		return err
 	}
 	return nil
 }
 
+// streamFromS3 fetches the object described by input with a single request
+// and streams it through decompressCopyHashAndVerify into dest.
+func (f *Fetcher) streamFromS3(ctx context.Context, client *s3.S3, dest io.Writer, input *s3.GetObjectInput, opts FetchOptions) error {
+	output, err := client.GetObjectWithContext(ctx, input)
+	if err != nil {
+		return err
+	}
+	defer output.Body.Close()
+
+	var body io.Reader = output.Body
+	if f.S3MaxObjectSize > 0 {
+		if output.ContentLength != nil && *output.ContentLength > f.S3MaxObjectSize {
+			f.Logger.Err("s3 object is %d bytes, exceeding the limit of %d bytes", *output.ContentLength, f.S3MaxObjectSize)
+			return ErrTooLarge
+		}
+		// don't rely on the advertised length
+		body = &limitedReader{r: body, n: f.S3MaxObjectSize}
+	}
+	return f.decompressCopyHashAndVerify(dest, body, opts)
+}
+
+// limitedReader reads from r, failing with ErrTooLarge once more than n bytes
+// were read.
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n, ErrTooLarge
+	}
+	return n, err
+}
+
 // uncompress will wrap the given io.Reader in a decompresser specified in the
 // FetchOptions, and return an io.ReadCloser with the decompressed data stream.
 func (f *Fetcher) uncompress(r io.Reader, opts FetchOptions) (io.ReadCloser, error) {
@@ -419,6 +523,12 @@ func (f *Fetcher) uncompress(r io.Reader, opts FetchOptions) (io.ReadCloser, err
 		return ioutil.NopCloser(r), nil
 	case "gzip":
 		return gzip.NewReader(r)
+	case "bzip2":
+		return ioutil.NopCloser(bzip2.NewReader(r)), nil
+	case "xz":
+		return f.newCmdReader(r, distro.XzCmd(), "--decompress", "--stdout")
+	case "zstd":
+		return f.newCmdReader(r, distro.ZstdCmd(), "--decompress", "--stdout")
 	default:
 		return nil, configErrors.ErrCompressionInvalid
 	}
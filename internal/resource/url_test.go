@@ -0,0 +1,127 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha512"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/flatcar-linux/ignition/internal/log"
+	"github.com/flatcar-linux/ignition/internal/util"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+func TestStreamFromS3(t *testing.T) {
+	contents := []byte("asdf\nfdsa")
+	var gzipped bytes.Buffer
+	w := gzip.NewWriter(&gzipped)
+	w.Write(contents)
+	w.Close()
+
+	objects := map[string][]byte{
+		"/bucket/plain": contents,
+		"/bucket/gzip":  gzipped.Bytes(),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		obj, ok := objects[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(obj)
+	}))
+	defer server.Close()
+
+	sess, err := session.NewSession(&aws.Config{
+		Credentials:      credentials.AnonymousCredentials,
+		Endpoint:         aws.String(server.URL),
+		Region:           aws.String("us-east-1"),
+		S3ForcePathStyle: aws.Bool(true),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := s3.New(sess)
+
+	sum := sha512.Sum512(contents)
+	badSum := sha512.Sum512(nil)
+
+	tests := []struct {
+		key     string
+		opts    FetchOptions
+		maxSize int64
+		err     error
+	}{
+		{
+			key:  "plain",
+			opts: FetchOptions{Hash: sha512.New(), ExpectedSum: sum[:]},
+		},
+		{
+			key:  "gzip",
+			opts: FetchOptions{Compression: "gzip", Hash: sha512.New(), ExpectedSum: sum[:]},
+		},
+		{
+			key:  "plain",
+			opts: FetchOptions{Hash: sha512.New(), ExpectedSum: badSum[:]},
+			err:  util.ErrHashMismatch{},
+		},
+		{
+			key:     "plain",
+			maxSize: int64(len(contents)),
+		},
+		{
+			key:     "plain",
+			maxSize: int64(len(contents)) - 1,
+			err:     ErrTooLarge,
+		},
+	}
+
+	logger := log.New(true)
+	defer logger.Close()
+	for i, test := range tests {
+		f := Fetcher{Logger: &logger, S3MaxObjectSize: test.maxSize}
+		var dest bytes.Buffer
+		err := f.streamFromS3(context.Background(), client, &dest, &s3.GetObjectInput{
+			Bucket: aws.String("bucket"),
+			Key:    aws.String(test.key),
+		}, test.opts)
+
+		switch test.err.(type) {
+		case nil:
+			if err != nil {
+				t.Errorf("#%d: unexpected error: %v", i, err)
+			} else if !bytes.Equal(dest.Bytes(), contents) {
+				t.Errorf("#%d: expected %q, got %q", i, contents, dest.Bytes())
+			}
+		case util.ErrHashMismatch:
+			if _, ok := err.(util.ErrHashMismatch); !ok {
+				t.Errorf("#%d: expected a hash mismatch, got %v", i, err)
+			}
+		default:
+			if err != test.err {
+				t.Errorf("#%d: expected %v, got %v", i, test.err, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,282 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sfdisk writes dos (MBR) partition tables with sfdisk. It mirrors
+// the Operation API of the sgdisk package, but since sfdisk has no notion of
+// deleting and creating single partitions in one go, every operation is
+// applied by writing the whole resulting table as an sfdisk script.
+package sfdisk
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/flatcar-linux/ignition/internal/config/types"
+	"github.com/flatcar-linux/ignition/internal/distro"
+	"github.com/flatcar-linux/ignition/internal/log"
+)
+
+const (
+	// defaultType is the type of partitions without a dosType, "Linux"
+	defaultType = "83"
+)
+
+var (
+	ErrNotDos = errors.New("disk does not have a dos partition table")
+)
+
+type Operation struct {
+	logger    *log.Logger
+	dev       string
+	wipe      bool
+	parts     []types.Partition
+	deletions []int
+}
+
+// Table is a partition table as read by DumpPartitionTable.
+type Table struct {
+	// Label is the type of the table, e.g. "dos" or "gpt", or empty if the
+	// disk has no partition table.
+	Label      string
+	ID         string
+	Partitions []types.Partition
+}
+
+// Begin begins an sfdisk operation
+func Begin(logger *log.Logger, dev string) *Operation {
+	return &Operation{logger: logger, dev: dev}
+}
+
+// CreatePartition adds the supplied partition to the list of partitions to be created as part of an operation.
+func (op *Operation) CreatePartition(p types.Partition) {
+	op.parts = append(op.parts, p)
+}
+
+func (op *Operation) DeletePartition(num int) {
+	op.deletions = append(op.deletions, num)
+}
+
+// Info is a no-op kept for parity with sgdisk; the output of Pretend always
+// lists every partition.
+func (op *Operation) Info(num int) {}
+
+// WipeTable toggles if the table is to be replaced by an empty one first when commiting this operation.
+func (op *Operation) WipeTable(wipe bool) {
+	op.wipe = wipe
+}
+
+// Pretend is like Commit() but uses the --no-act flag and returns the output
+// on stdout for parsing. The table after the operation is listed after a
+// "New situation:" line.
+func (op *Operation) Pretend() (string, error) {
+	script, err := op.buildScript()
+	if err != nil {
+		return "", err
+	}
+	op.logger.Info("running sfdisk --no-act with script: %q", script)
+
+	cmd := exec.Command(distro.SfdiskCmd(), "--no-act", "--wipe-partitions", "never", op.dev)
+	cmd.Stdin = strings.NewReader(script)
+	// the output is parsed, so keep it untranslated
+	cmd.Env = append(os.Environ(), "LC_ALL=C")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("Failed to pretend to create partitions. Err: %v. Stderr: %v", err, stderr.String())
+	}
+
+	return string(output), nil
+}
+
+// Commit commits an partitioning operation.
+func (op *Operation) Commit() error {
+	if !op.wipe && len(op.parts) == 0 && len(op.deletions) == 0 {
+		return nil
+	}
+	script, err := op.buildScript()
+	if err != nil {
+		return err
+	}
+	op.logger.Info("running sfdisk with script: %q", script)
+
+	// existing data is kept when partitions are recreated, so don't wipe signatures
+	cmd := exec.Command(distro.SfdiskCmd(), "--wipe-partitions", "never", op.dev)
+	cmd.Stdin = strings.NewReader(script)
+	if _, err := op.logger.LogCmd(cmd, "deleting %d partitions and creating %d partitions on %q", len(op.deletions), len(op.parts), op.dev); err != nil {
+		return fmt.Errorf("create partitions failed: %v", err)
+	}
+
+	return nil
+}
+
+// buildScript returns the sfdisk script describing the table after the
+// operation: the existing partitions minus the deleted ones, with the
+// created ones added or replacing those of the same number.
+func (op *Operation) buildScript() (string, error) {
+	table := Table{}
+	if !op.wipe {
+		var err error
+		table, err = DumpPartitionTable(op.dev)
+		if err != nil {
+			return "", err
+		}
+		if table.Label != "" && table.Label != "dos" {
+			return "", fmt.Errorf("%v: %q has a %s partition table", ErrNotDos, op.dev, table.Label)
+		}
+	}
+
+	parts := map[int]types.Partition{}
+	for _, p := range table.Partitions {
+		parts[p.Number] = p
+	}
+	for _, num := range op.deletions {
+		delete(parts, num)
+	}
+	for _, p := range op.parts {
+		parts[p.Number] = p
+	}
+	numbers := []int{}
+	for num := range parts {
+		numbers = append(numbers, num)
+	}
+	// extended partitions (1-4) come before their logical partitions (5+)
+	sort.Ints(numbers)
+
+	var b strings.Builder
+	b.WriteString("label: dos\n")
+	if table.ID != "" {
+		// keep the disk identifier so the PARTUUIDs of existing partitions don't change
+		fmt.Fprintf(&b, "label-id: %s\n", table.ID)
+	}
+	b.WriteString("unit: sectors\n\n")
+	for _, num := range numbers {
+		fmt.Fprintf(&b, "%s : %s\n", partitionName(op.dev, num), strings.Join(partitionFields(parts[num]), ", "))
+	}
+	return b.String(), nil
+}
+
+// partitionFields returns the sfdisk script fields for p. Starts and sizes
+// of zero are left out, which makes sfdisk use the first free sector and the
+// largest possible size respectively.
+func partitionFields(p types.Partition) []string {
+	fields := []string{}
+	if p.Start != nil && *p.Start != 0 {
+		fields = append(fields, fmt.Sprintf("start=%d", *p.Start))
+	} else if p.StartMiB != nil && *p.StartMiB != 0 {
+		fields = append(fields, fmt.Sprintf("start=%dMiB", *p.StartMiB))
+	}
+	if p.Size != nil && *p.Size != 0 {
+		fields = append(fields, fmt.Sprintf("size=%d", *p.Size))
+	} else if p.SizeMiB != nil && *p.SizeMiB != 0 {
+		fields = append(fields, fmt.Sprintf("size=%dMiB", *p.SizeMiB))
+	}
+	dosType := p.DosType
+	if dosType == "" {
+		dosType = defaultType
+	}
+	fields = append(fields, "type="+dosType)
+	return fields
+}
+
+// partitionName returns the name of partition num of dev the way the kernel
+// and sfdisk spell it. sfdisk takes the partition number from the trailing
+// digits of the name.
+func partitionName(dev string, num int) string {
+	if last := dev[len(dev)-1]; last >= '0' && last <= '9' {
+		return fmt.Sprintf("%sp%d", dev, num)
+	}
+	return fmt.Sprintf("%s%d", dev, num)
+}
+
+// PartitionNumber returns the number of the partition with the device node
+// name, i.e. its trailing digits, or -1 if it has none.
+func PartitionNumber(name string) int {
+	i := len(name)
+	for i > 0 && name[i-1] >= '0' && name[i-1] <= '9' {
+		i--
+	}
+	num, err := strconv.Atoi(name[i:])
+	if err != nil {
+		return -1
+	}
+	return num
+}
+
+// DumpPartitionTable reads the partition table of dev. A disk without a
+// partition table yields an empty Table. Partitions of dos tables have
+// their number, start, size and dosType set, and their PARTUUID as GUID.
+func DumpPartitionTable(dev string) (Table, error) {
+	cmd := exec.Command(distro.SfdiskCmd(), "--json", dev)
+	cmd.Env = append(os.Environ(), "LC_ALL=C")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if strings.Contains(stderr.String(), "does not contain a recognized partition table") {
+			return Table{}, nil
+		}
+		return Table{}, fmt.Errorf("sfdisk --json failed: %v: %s", err, stderr.String())
+	}
+	return parseDump(output)
+}
+
+func parseDump(output []byte) (Table, error) {
+	var dump struct {
+		PartitionTable struct {
+			Label      string `json:"label"`
+			ID         string `json:"id"`
+			Partitions []struct {
+				Node  string `json:"node"`
+				Start int    `json:"start"`
+				Size  int    `json:"size"`
+				Type  string `json:"type"`
+			} `json:"partitions"`
+		} `json:"partitiontable"`
+	}
+	if err := json.Unmarshal(output, &dump); err != nil {
+		return Table{}, fmt.Errorf("failed to parse sfdisk output: %v", err)
+	}
+
+	table := Table{
+		Label: dump.PartitionTable.Label,
+		ID:    dump.PartitionTable.ID,
+	}
+	if table.Label != "dos" {
+		return table, nil
+	}
+	diskID := strings.TrimPrefix(strings.ToLower(table.ID), "0x")
+	for _, p := range dump.PartitionTable.Partitions {
+		num := PartitionNumber(p.Node)
+		if num <= 0 {
+			return Table{}, fmt.Errorf("unexpected partition name %q in sfdisk output", p.Node)
+		}
+		start, size := p.Start, p.Size
+		table.Partitions = append(table.Partitions, types.Partition{
+			Number:  num,
+			Start:   &start,
+			Size:    &size,
+			DosType: p.Type,
+			GUID:    fmt.Sprintf("%s-%02x", diskID, num),
+		})
+	}
+	return table, nil
+}
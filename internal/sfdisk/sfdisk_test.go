@@ -0,0 +1,154 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sfdisk
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flatcar-linux/ignition/internal/config/types"
+)
+
+func TestParseDump(t *testing.T) {
+	intToPtr := func(i int) *int { return &i }
+
+	tests := []struct {
+		in  string
+		out Table
+		err bool
+	}{
+		{
+			in: `{
+   "partitiontable": {
+      "label": "dos",
+      "id": "0x5B6E8B4C",
+      "device": "/dev/sdb",
+      "unit": "sectors",
+      "sectorsize": 512,
+      "partitions": [
+         {"node": "/dev/sdb1", "start": 2048, "size": 65536, "type": "83", "bootable": true},
+         {"node": "/dev/sdb2", "start": 67584, "size": 200704, "type": "5"},
+         {"node": "/dev/sdb5", "start": 69632, "size": 32768, "type": "82"}
+      ]
+   }
+}`,
+			out: Table{
+				Label: "dos",
+				ID:    "0x5B6E8B4C",
+				Partitions: []types.Partition{
+					{Number: 1, Start: intToPtr(2048), Size: intToPtr(65536), DosType: "83", GUID: "5b6e8b4c-01"},
+					{Number: 2, Start: intToPtr(67584), Size: intToPtr(200704), DosType: "5", GUID: "5b6e8b4c-02"},
+					{Number: 5, Start: intToPtr(69632), Size: intToPtr(32768), DosType: "82", GUID: "5b6e8b4c-05"},
+				},
+			},
+		},
+		{
+			in: `{"partitiontable": {"label": "dos", "id": "0x00000001", "device": "/dev/loop0", "unit": "sectors",
+				"partitions": [{"node": "/dev/loop0p12", "start": 2048, "size": 2048, "type": "c"}]}}`,
+			out: Table{
+				Label: "dos",
+				ID:    "0x00000001",
+				Partitions: []types.Partition{
+					{Number: 12, Start: intToPtr(2048), Size: intToPtr(2048), DosType: "c", GUID: "00000001-0c"},
+				},
+			},
+		},
+		{
+			in: `{"partitiontable": {"label": "gpt", "id": "1A2B", "device": "/dev/sdb", "unit": "sectors",
+				"partitions": [{"node": "/dev/sdb1", "start": 2048, "size": 2048, "type": "0FC63DAF-8483-4772-8E79-3D69D8477DE4"}]}}`,
+			out: Table{Label: "gpt", ID: "1A2B"},
+		},
+		{
+			in:  `{"partitiontable": {"label": "dos", "partitions": [{"node": "/dev/sdb", "start": 1, "size": 1, "type": "83"}]}}`,
+			err: true,
+		},
+		{
+			in:  `Disk /dev/sdb: 1 GiB`,
+			err: true,
+		},
+	}
+
+	for i, test := range tests {
+		table, err := parseDump([]byte(test.in))
+		if test.err {
+			if err == nil {
+				t.Errorf("#%d: expected an error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+		} else if !reflect.DeepEqual(table, test.out) {
+			t.Errorf("#%d: bad table: want %+v, got %+v", i, test.out, table)
+		}
+	}
+}
+
+func TestPartitionFields(t *testing.T) {
+	intToPtr := func(i int) *int { return &i }
+
+	tests := []struct {
+		in  types.Partition
+		out []string
+	}{
+		{
+			in:  types.Partition{Number: 1},
+			out: []string{"type=83"},
+		},
+		{
+			in:  types.Partition{Number: 1, Start: intToPtr(2048), Size: intToPtr(65536), DosType: "c"},
+			out: []string{"start=2048", "size=65536", "type=c"},
+		},
+		{
+			in:  types.Partition{Number: 2, StartMiB: intToPtr(1), SizeMiB: intToPtr(0), DosType: "5"},
+			out: []string{"start=1MiB", "type=5"},
+		},
+		{
+			in:  types.Partition{Number: 5, Start: intToPtr(0), SizeMiB: intToPtr(32)},
+			out: []string{"size=32MiB", "type=83"},
+		},
+	}
+
+	for i, test := range tests {
+		if fields := partitionFields(test.in); !reflect.DeepEqual(fields, test.out) {
+			t.Errorf("#%d: bad fields: want %v, got %v", i, test.out, fields)
+		}
+	}
+}
+
+func TestPartitionName(t *testing.T) {
+	tests := []struct {
+		dev string
+		num int
+		out string
+	}{
+		{"/dev/sdb", 1, "/dev/sdb1"},
+		{"/dev/nvme0n1", 5, "/dev/nvme0n1p5"},
+		{"/run/ignition/dev_aliases/dev/loop0", 2, "/run/ignition/dev_aliases/dev/loop0p2"},
+	}
+
+	for i, test := range tests {
+		name := partitionName(test.dev, test.num)
+		if name != test.out {
+			t.Errorf("#%d: bad name: want %q, got %q", i, test.out, name)
+		}
+		if num := PartitionNumber(name); num != test.num {
+			t.Errorf("#%d: bad number for %q: want %d, got %d", i, name, test.num, num)
+		}
+	}
+	if num := PartitionNumber("/dev/sdb"); num != -1 {
+		t.Errorf("expected no number for /dev/sdb, got %d", num)
+	}
+}
@@ -0,0 +1,253 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The summary package collects a record of what Ignition did during a stage,
+// to be written out as a provisioning report once the stage has finished.
+package summary
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/flatcar-linux/ignition/internal/distro"
+)
+
+// RootReportDir is the directory, relative to the root filesystem, which
+// keeps the reports of all stages.
+const RootReportDir = "var/lib/ignition"
+
+// ReportDir returns the default directory for the report of stage: the
+// runtime directory for the stages which run before the root filesystem is
+// mounted (fetch and disks), whose reports the files stage copies to the
+// root later, and RootReportDir under root for the others.
+func ReportDir(root, stage string) string {
+	switch stage {
+	case "fetch", "disks":
+		return distro.RunReportDir()
+	default:
+		return filepath.Join(root, RootReportDir)
+	}
+}
+
+// ReportName returns the file name of the report of stage.
+func ReportName(stage string) string {
+	return fmt.Sprintf("report-%s.json", stage)
+}
+
+type Kind int
+
+const (
+	Partition Kind = iota
+	Raid
+	Luks
+	Lvm
+	Filesystem
+	Directory
+	File
+	Link
+	Group
+	User
+	Unit
+)
+
+type Action string
+
+const (
+	Created  Action = "created"
+	Modified Action = "modified"
+	Deleted  Action = "deleted"
+	Skipped  Action = "skipped"
+	Enabled  Action = "enabled"
+	Disabled Action = "disabled"
+	Masked   Action = "masked"
+)
+
+// Config is a config which was read while acquiring the config for the stage.
+type Config struct {
+	Source string `json:"source"`
+	SHA512 string `json:"sha512"`
+}
+
+// Entry is a single object which was created, modified or skipped.
+type Entry struct {
+	Name   string `json:"name"`
+	Action Action `json:"action"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Summary is the record of a single stage. All methods are safe for
+// concurrent use and may be called on a nil Summary, in which case nothing
+// is recorded.
+type Summary struct {
+	mu sync.Mutex
+
+	Stage       string   `json:"stage"`
+	Success     bool     `json:"success"`
+	Error       string   `json:"error,omitempty"`
+	Configs     []Config `json:"configs"`
+	Partitions  []Entry  `json:"partitions"`
+	Raid        []Entry  `json:"raid"`
+	Luks        []Entry  `json:"luks"`
+	Lvm         []Entry  `json:"lvm"`
+	Filesystems []Entry  `json:"filesystems"`
+	Directories []Entry  `json:"directories"`
+	Files       []Entry  `json:"files"`
+	Links       []Entry  `json:"links"`
+	Groups      []Entry  `json:"groups"`
+	Users       []Entry  `json:"users"`
+	Units       []Entry  `json:"units"`
+	Warnings    []string `json:"warnings"`
+}
+
+// New returns an empty summary for the named stage.
+func New(stage string) *Summary {
+	return &Summary{
+		Stage:       stage,
+		Configs:     []Config{},
+		Partitions:  []Entry{},
+		Raid:        []Entry{},
+		Luks:        []Entry{},
+		Lvm:         []Entry{},
+		Filesystems: []Entry{},
+		Directories: []Entry{},
+		Files:       []Entry{},
+		Links:       []Entry{},
+		Groups:      []Entry{},
+		Users:       []Entry{},
+		Units:       []Entry{},
+		Warnings:    []string{},
+	}
+}
+
+// AddConfig records a config read from source with the given hex encoded
+// SHA512 sum. The source is recorded without the parts which might contain
+// secrets, see redactSource.
+func (s *Summary) AddConfig(source, sha512 string) {
+	if s == nil {
+		return
+	}
+	source = redactSource(source)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Configs = append(s.Configs, Config{Source: source, SHA512: sha512})
+}
+
+// redactSource strips the contents of data URLs, and the userinfo, query and
+// fragment of other URLs, which carry credentials like S3 presigned URLs and
+// Azure SAS tokens. Sources which aren't URLs, like file paths, are kept.
+func redactSource(source string) string {
+	if strings.HasPrefix(source, "data:") {
+		return "data:"
+	}
+	u, err := url.Parse(source)
+	if err != nil {
+		if strings.Contains(source, "://") {
+			// don't risk recording a secret in a malformed URL
+			return "(invalid URL)"
+		}
+		return source
+	}
+	if u.Scheme == "" {
+		return source
+	}
+	u.User = nil
+	u.RawQuery = ""
+	u.ForceQuery = false
+	u.Fragment = ""
+	u.RawFragment = ""
+	return u.String()
+}
+
+// Add records that the named object of the given kind had action applied.
+func (s *Summary) Add(kind Kind, name string, action Action, detail string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := Entry{Name: name, Action: action, Detail: detail}
+	switch kind {
+	case Partition:
+		s.Partitions = append(s.Partitions, e)
+	case Raid:
+		s.Raid = append(s.Raid, e)
+	case Luks:
+		s.Luks = append(s.Luks, e)
+	case Lvm:
+		s.Lvm = append(s.Lvm, e)
+	case Filesystem:
+		s.Filesystems = append(s.Filesystems, e)
+	case Directory:
+		s.Directories = append(s.Directories, e)
+	case File:
+		s.Files = append(s.Files, e)
+	case Link:
+		s.Links = append(s.Links, e)
+	case Group:
+		s.Groups = append(s.Groups, e)
+	case User:
+		s.Users = append(s.Users, e)
+	case Unit:
+		s.Units = append(s.Units, e)
+	}
+}
+
+// AddWarning records a warning from validating a config.
+func (s *Summary) AddWarning(warning string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Warnings = append(s.Warnings, warning)
+}
+
+// Finish records the result of the stage.
+func (s *Summary) Finish(err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Success = err == nil
+	if err != nil {
+		s.Error = err.Error()
+	}
+}
+
+// WriteFile writes the summary as JSON to path, creating any missing parent
+// directories. The file is only readable by its owner.
+func (s *Summary) WriteFile(path string) error {
+	s.mu.Lock()
+	b, err := json.MarshalIndent(s, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path, append(b, '\n'), 0600); err != nil {
+		return err
+	}
+	// a report from an earlier run may have been readable by others
+	return os.Chmod(path, 0600)
+}
@@ -0,0 +1,72 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+)
+
+var (
+	ErrInvalidSigningKey  = errors.New("signing key is not a base64 encoded ed25519 public key")
+	ErrNoSigningKeys      = errors.New("no trusted signing keys configured")
+	ErrSignatureMalformed = errors.New("malformed signature")
+	ErrSignatureInvalid   = errors.New("signature verification failed")
+)
+
+// ParseSigningKey parses a base64 encoded ed25519 public key, which is either
+// the raw 32 byte key or its PKIX encoding (the body of a PEM "PUBLIC KEY").
+func ParseSigningKey(key string) (ed25519.PublicKey, error) {
+	der, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, ErrInvalidSigningKey
+	}
+	if len(der) == ed25519.PublicKeySize {
+		return ed25519.PublicKey(der), nil
+	}
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, ErrInvalidSigningKey
+	}
+	edPub, ok := pub.(ed25519.PublicKey)
+	if !ok {
+		return nil, ErrInvalidSigningKey
+	}
+	return edPub, nil
+}
+
+// AssertSigned checks that sig is a valid ed25519 signature of data made by
+// one of keys. The signature is either the raw 64 bytes or base64 encoded.
+func AssertSigned(keys []ed25519.PublicKey, data, sig []byte) error {
+	if len(keys) == 0 {
+		return ErrNoSigningKeys
+	}
+	if len(sig) != ed25519.SignatureSize {
+		decoded, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(sig)))
+		if err != nil || len(decoded) != ed25519.SignatureSize {
+			return ErrSignatureMalformed
+		}
+		sig = decoded
+	}
+	for _, key := range keys {
+		if ed25519.Verify(key, data, sig) {
+			return nil
+		}
+	}
+	return ErrSignatureInvalid
+}
@@ -0,0 +1,76 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"testing"
+)
+
+func TestAssertSigned(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkix, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, encoded := range []string{
+		base64.StdEncoding.EncodeToString(pub),
+		base64.StdEncoding.EncodeToString(pkix),
+	} {
+		key, err := ParseSigningKey(encoded)
+		if err != nil {
+			t.Fatalf("#%d: failed to parse key: %v", i, err)
+		}
+		if !key.Equal(pub) {
+			t.Errorf("#%d: parsed the wrong key", i)
+		}
+	}
+	if _, err := ParseSigningKey(base64.StdEncoding.EncodeToString(pub[:16])); err != ErrInvalidSigningKey {
+		t.Errorf("bad error for a short key: want %v, got %v", ErrInvalidSigningKey, err)
+	}
+
+	data := []byte("hello")
+	sig := ed25519.Sign(priv, data)
+	tests := []struct {
+		keys []ed25519.PublicKey
+		data []byte
+		sig  []byte
+		err  error
+	}{
+		{keys: []ed25519.PublicKey{pub}, data: data, sig: sig},
+		{keys: []ed25519.PublicKey{other, pub}, data: data, sig: []byte(base64.StdEncoding.EncodeToString(sig) + "\n")},
+		{keys: []ed25519.PublicKey{other}, data: data, sig: sig, err: ErrSignatureInvalid},
+		{keys: []ed25519.PublicKey{pub}, data: []byte("goodbye"), sig: sig, err: ErrSignatureInvalid},
+		{keys: []ed25519.PublicKey{pub}, data: data, sig: []byte("junk"), err: ErrSignatureMalformed},
+		{data: data, sig: sig, err: ErrNoSigningKeys},
+	}
+
+	for i, test := range tests {
+		if err := AssertSigned(test.keys, test.data, test.sig); err != test.err {
+			t.Errorf("#%d: bad error: want %v, got %v", i, test.err, err)
+		}
+	}
+}
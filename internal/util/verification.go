@@ -15,6 +15,7 @@
 package util
 
 import (
+	"crypto/sha256"
 	"crypto/sha512"
 	"encoding/hex"
 	"errors"
@@ -59,20 +60,18 @@ func HashParts(v types.Verification) (string, string, error) {
 
 func AssertValid(verify types.Verification, data []byte) error {
 	if hash := verify.Hash; hash != nil {
-		hashFunc, hashSum, err := HashParts(verify)
+		hasher, err := GetHasher(verify)
 		if err != nil {
 			return err
 		}
-
-		var sum []byte
-		switch hashFunc {
-		case "sha512":
-			rawSum := sha512.Sum512(data)
-			sum = rawSum[:]
-		default:
-			return ErrHashUnrecognized
+		_, hashSum, err := HashParts(verify)
+		if err != nil {
+			return err
 		}
 
+		hasher.Write(data)
+		sum := hasher.Sum(nil)
+
 		encodedSum := make([]byte, hex.EncodedLen(len(sum)))
 		hex.Encode(encodedSum, sum)
 		if string(encodedSum) != hashSum {
@@ -97,6 +96,10 @@ func GetHasher(verify types.Verification) (hash.Hash, error) {
 	}
 
 	switch function {
+	case "sha256":
+		return sha256.New(), nil
+	case "sha384":
+		return sha512.New384(), nil
 	case "sha512":
 		return sha512.New(), nil
 	default:
@@ -69,6 +69,36 @@ func TestAssertValid(t *testing.T) {
 				Expected:   "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
 			}},
 		},
+		{
+			in: in{
+				verification: types.Verification{
+					Hash: stringDeref("sha256-2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"),
+				},
+				data: []byte("hello"),
+			},
+			out: out{},
+		},
+		{
+			in: in{
+				verification: types.Verification{
+					Hash: stringDeref("sha384-59e1748777448c69de6b800d7a33bbfb9ff1b463e44354c3553bcdb9c666fa90125a3c79f90397bdf5f6a13de828684f"),
+				},
+				data: []byte("hello"),
+			},
+			out: out{},
+		},
+		{
+			in: in{
+				verification: types.Verification{
+					Hash: stringDeref("sha256-0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"),
+				},
+				data: []byte("hello"),
+			},
+			out: out{err: ErrHashMismatch{
+				Calculated: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+				Expected:   "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+			}},
+		},
 	}
 
 	for i, test := range tests {
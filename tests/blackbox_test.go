@@ -16,6 +16,7 @@ package blackbox
 
 import (
 	"context"
+	"crypto/ed25519"
 	"flag"
 	"fmt"
 	"io/ioutil"
@@ -276,6 +277,12 @@ func outer(t *testing.T, test types.Test, negativeTests bool) error {
 	if err := ioutil.WriteFile(filepath.Join(tmpDirectory, "config.ign"), []byte(test.Config), 0666); err != nil {
 		return fmt.Errorf("error writing config: %v", err)
 	}
+	if test.ConfigSigningKey != nil {
+		sig := ed25519.Sign(test.ConfigSigningKey, []byte(test.Config))
+		if err := ioutil.WriteFile(filepath.Join(tmpDirectory, "config.ign.sig"), sig, 0666); err != nil {
+			return fmt.Errorf("error writing config signature: %v", err)
+		}
+	}
 
 	// Ignition
 	appendEnv := []string{
@@ -283,7 +290,7 @@ func outer(t *testing.T, test types.Test, negativeTests bool) error {
 		"IGNITION_OEM_LOOKASIDE_DIR=" + oemLookasideDir,
 		"IGNITION_SYSTEM_CONFIG_DIR=" + systemConfigDir,
 	}
-	disksErr := runIgnition(t, ctx, "disks", rootPartition.MountPath, tmpDirectory, appendEnv)
+	disksErr := runIgnition(t, ctx, "disks", rootPartition.MountPath, tmpDirectory, appendEnv, test.Flags)
 	if !negativeTests && disksErr != nil {
 		return disksErr
 	}
@@ -295,7 +302,7 @@ func outer(t *testing.T, test types.Test, negativeTests bool) error {
 		if err := mountPartition(ctx, rootPartition); err != nil {
 			return err
 		}
-		filesErr = runIgnition(t, ctx, "files", rootPartition.MountPath, tmpDirectory, appendEnv)
+		filesErr = runIgnition(t, ctx, "files", rootPartition.MountPath, tmpDirectory, appendEnv, test.Flags)
 		if err := umountPartition(rootPartition); err != nil {
 			return err
 		}
@@ -159,9 +159,10 @@ func umountPartition(p *types.Partition) error {
 }
 
 // returns true if no error, false if error
-func runIgnition(t *testing.T, ctx context.Context, stage, root, cwd string, appendEnv []string) error {
+func runIgnition(t *testing.T, ctx context.Context, stage, root, cwd string, appendEnv, flags []string) error {
 	args := []string{"-clear-cache", "-oem", "file", "-stage", stage,
 		"-root", root, "-log-to-stdout", "--config-cache", filepath.Join(cwd, "ignition.json")}
+	args = append(args, flags...)
 	cmd := exec.CommandContext(ctx, "ignition", args...)
 	t.Log("ignition", args)
 	cmd.Dir = cwd
@@ -232,7 +233,12 @@ func setupDisk(ctx context.Context, disk *types.Disk, diskIndex int, imageSize i
 		return fmt.Errorf("Settling devices: %v", err)
 	}
 
-	if err = createPartitionTable(ctx, disk.Device, disk.Partitions); err != nil {
+	if disk.PartitionTable == "dos" {
+		err = createDosPartitionTable(ctx, disk.Device, disk.Partitions)
+	} else {
+		err = createPartitionTable(ctx, disk.Device, disk.Partitions)
+	}
+	if err != nil {
 		return err
 	}
 
@@ -364,6 +370,22 @@ func createPartitionTable(ctx context.Context, imageFile string, partitions []*t
 	return err
 }
 
+func createDosPartitionTable(ctx context.Context, imageFile string, partitions []*types.Partition) error {
+	script := "label: dos\nunit: sectors\n\n"
+	for _, p := range partitions {
+		if p.TypeCode == "blank" || p.Length == 0 {
+			continue
+		}
+		script += fmt.Sprintf("%sp%d : start=%d, size=%d, type=%s\n", imageFile, p.Number, p.Offset, p.Length, p.DosType)
+	}
+	cmd := exec.CommandContext(ctx, "sfdisk", imageFile)
+	cmd.Stdin = strings.NewReader(script)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("sfdisk failed: %v: %s", err, out)
+	}
+	return nil
+}
+
 func updateTypeGUID(partition *types.Partition) error {
 	partitionTypes := map[string]string{
 		"coreos-resize":   "3884DD41-8582-4404-B9A8-E9B84F2DF50E",
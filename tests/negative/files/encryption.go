@@ -0,0 +1,77 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package files
+
+import (
+	"github.com/flatcar-linux/ignition/tests/register"
+	"github.com/flatcar-linux/ignition/tests/types"
+)
+
+func init() {
+	register.Register(register.NegativeTest, CreateFileFromEncryptedDataURLWrongKey())
+	register.Register(register.NegativeTest, CreateFileFromEncryptedDataURLMissingKey())
+}
+
+const encryptedFileConfig = `{
+  "ignition": { "version": "$version" },
+  "storage": {
+    "files": [{
+      "filesystem": "root",
+      "path": "/foo/age",
+      "contents": {
+        "source": "data:;base64,YWdlLWVuY3J5cHRpb24ub3JnL3YxCi0+IFgyNTUxOSBMMXQxSGZnS2pJVXU3Zll3WWhYeXhuOHhWNG1hNklDNGtNcVFtTi9meERFCmZ2Q1gvQzJiNHVoTElqRW9qNzdJUHpBTGYyUi9ScFhQMEtETHk5bDZSVFEKLS0tIGY4R2l1Y252UHFDYmU3U2xibmMzSjNBT200dEpyVXB4RjRKakc5RzVVWGMK/jFFhfuSFQDHz20rKtsNi93JK7PWpYAK/92tKYdEoOYpDrN5zDVLoMu4MZBEvzQ=",
+        "decryption": { "key": "ignition.key" }
+      }
+    }]
+  }
+}`
+
+func CreateFileFromEncryptedDataURLWrongKey() types.Test {
+	name := "Create File from Encrypted Data URL with the Wrong Key"
+	in := types.GetBaseDisk()
+	out := types.GetBaseDisk()
+	configMinVersion := "2.4.0-experimental"
+
+	return types.Test{
+		Name: name,
+		In:   in,
+		Out:  out,
+		SystemDirFiles: []types.File{
+			{
+				Node: types.Node{
+					Name: "ignition.key",
+				},
+				Contents: "AGE-SECRET-KEY-198YKR8HYNFQYULWCRRRLLXP3AANH9UKD89J86SAPXND5J0XLHTTSV7HPZK\n",
+			},
+		},
+		Config:           encryptedFileConfig,
+		ConfigMinVersion: configMinVersion,
+	}
+}
+
+func CreateFileFromEncryptedDataURLMissingKey() types.Test {
+	name := "Create File from Encrypted Data URL without a Key"
+	in := types.GetBaseDisk()
+	out := types.GetBaseDisk()
+	configMinVersion := "2.4.0-experimental"
+
+	return types.Test{
+		Name:             name,
+		In:               in,
+		Out:              out,
+		Config:           encryptedFileConfig,
+		ConfigMinVersion: configMinVersion,
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package luks
+
+import (
+	"github.com/flatcar-linux/ignition/tests/register"
+	"github.com/flatcar-linux/ignition/tests/types"
+)
+
+func init() {
+	register.Register(register.NegativeTest, LuksInvalidName())
+	register.Register(register.NegativeTest, LuksKeyFileHashMismatch())
+}
+
+func LuksInvalidName() types.Test {
+	name := "LUKS volume with an invalid name"
+	in := types.GetBaseDisk()
+	out := in
+	mntDevices := []types.MntDevice{
+		{
+			Label:        "OEM",
+			Substitution: "$DEVICE",
+		},
+	}
+	config := `{
+		"ignition": {"version": "$version"},
+		"storage": {
+			"luks": [{
+				"name": "mapper/data",
+				"device": "$DEVICE",
+				"keyFile": {"source": "data:,secret"}
+			}]
+		}
+	}`
+	configMinVersion := "2.4.0-experimental"
+
+	return types.Test{
+		Name:              name,
+		In:                in,
+		Out:               out,
+		MntDevices:        mntDevices,
+		Config:            config,
+		ConfigMinVersion:  configMinVersion,
+		ConfigShouldBeBad: true,
+	}
+}
+
+func LuksKeyFileHashMismatch() types.Test {
+	name := "LUKS volume with a key file not matching its hash"
+	in := types.GetBaseDisk()
+	out := in
+	mntDevices := []types.MntDevice{
+		{
+			Label:        "OEM",
+			Substitution: "$DEVICE",
+		},
+	}
+	config := `{
+		"ignition": {"version": "$version"},
+		"storage": {
+			"luks": [{
+				"name": "data",
+				"device": "$DEVICE",
+				"wipeVolume": true,
+				"keyFile": {
+					"source": "data:,secret",
+					"verification": {"hash": "sha256-0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"}
+				}
+			}]
+		}
+	}`
+	configMinVersion := "2.4.0-experimental"
+
+	return types.Test{
+		Name:             name,
+		In:               in,
+		Out:              out,
+		MntDevices:       mntDevices,
+		Config:           config,
+		ConfigMinVersion: configMinVersion,
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lvm
+
+import (
+	"github.com/flatcar-linux/ignition/tests/register"
+	"github.com/flatcar-linux/ignition/tests/types"
+)
+
+func init() {
+	register.Register(register.NegativeTest, LvmThinVolumeWithoutPool())
+}
+
+func LvmThinVolumeWithoutPool() types.Test {
+	name := "LVM thin volume referencing a missing thin pool"
+	in := types.GetBaseDisk()
+	out := in
+	mntDevices := []types.MntDevice{
+		{
+			Label:        "OEM",
+			Substitution: "$DEVICE",
+		},
+	}
+	config := `{
+		"ignition": {"version": "$version"},
+		"storage": {
+			"lvm": {
+				"volumeGroups": [{
+					"name": "data",
+					"devices": ["$DEVICE"],
+					"logicalVolumes": [{
+						"name": "thin",
+						"pool": "pool",
+						"sizeMiB": 1024
+					}]
+				}]
+			}
+		}
+	}`
+	configMinVersion := "2.4.0-experimental"
+
+	return types.Test{
+		Name:              name,
+		In:                in,
+		Out:               out,
+		MntDevices:        mntDevices,
+		Config:            config,
+		ConfigMinVersion:  configMinVersion,
+		ConfigShouldBeBad: true,
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package partitions
+
+import (
+	"github.com/flatcar-linux/ignition/tests/register"
+	"github.com/flatcar-linux/ignition/tests/types"
+)
+
+func init() {
+	register.Register(register.NegativeTest, DeviceAndSelector())
+	register.Register(register.NegativeTest, SelectorMatchesNothing())
+}
+
+func DeviceAndSelector() types.Test {
+	name := "Disk with both a device and a selector"
+	in := types.GetBaseDisk()
+	out := in
+	config := `{
+		"ignition": {"version": "$version"},
+		"storage": {
+			"disks": [
+			{
+				"device": "$disk0",
+				"selector": {"rotational": false}
+			}
+			]
+		}
+	}`
+	configMinVersion := "2.4.0-experimental"
+
+	return types.Test{
+		Name:              name,
+		In:                in,
+		Out:               out,
+		Config:            config,
+		ConfigMinVersion:  configMinVersion,
+		ConfigShouldBeBad: true,
+	}
+}
+
+func SelectorMatchesNothing() types.Test {
+	name := "Disk selector matching no disk"
+	in := types.GetBaseDisk()
+	out := in
+	config := `{
+		"ignition": {"version": "$version"},
+		"storage": {
+			"disks": [
+			{
+				"selector": {"serial": "no-such-serial-*"},
+				"partitions": [{"number": 1, "sizeMiB": 32}]
+			}
+			]
+		}
+	}`
+	configMinVersion := "2.4.0-experimental"
+
+	return types.Test{
+		Name:             name,
+		In:               in,
+		Out:              out,
+		Config:           config,
+		ConfigMinVersion: configMinVersion,
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/flatcar-linux/ignition/tests/register"
+	"github.com/flatcar-linux/ignition/tests/types"
+
+	"github.com/vincent-petithory/dataurl"
+)
+
+func init() {
+	cer, err := tls.X509KeyPair(publicKey, privateKey)
+	if err != nil {
+		panic(fmt.Sprintf("error loading x509 keypair: %v", err))
+	}
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(publicKey)
+	clientCertServer.TLS = &tls.Config{
+		Certificates: []tls.Certificate{cer},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+	}
+	clientCertServer.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+	clientCertServer.StartTLS()
+
+	register.Register(register.NegativeTest, AppendConfigWithoutClientCert())
+	register.Register(register.NegativeTest, AppendConfigClientCertWithoutKey())
+	register.Register(register.NegativeTest, AppendConfigInvalidClientCert())
+	register.Register(register.NegativeTest, AppendConfigMissingClientCert())
+}
+
+var clientCertServer = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.Write(customCAServerFile)
+}))
+
+// clientCertConfig returns a config appending the config served by the
+// server requiring client certificates, with the given client certificate
+// and key references in its TLS section.
+func clientCertConfig(clientRefs string) string {
+	return fmt.Sprintf(`{
+		"ignition": {
+			"version": "$version",
+			"config": {
+			  "append": [{
+				"source": %q
+			  }]
+			},
+			"security": {
+				"tls": {
+					"certificateAuthorities": [{
+						"source": %q
+					}]%s
+				}
+			},
+			"timeouts": {
+				"httpTotal": 5
+			}
+		}
+	}`, clientCertServer.URL, dataurl.EncodeBytes(publicKey), clientRefs)
+}
+
+func AppendConfigWithoutClientCert() types.Test {
+	name := "Append config from server requiring a client cert without one"
+	in := types.GetBaseDisk()
+	out := types.GetBaseDisk()
+	config := clientCertConfig("")
+	configMinVersion := "2.4.0-experimental"
+
+	return types.Test{
+		Name:             name,
+		In:               in,
+		Out:              out,
+		Config:           config,
+		ConfigMinVersion: configMinVersion,
+	}
+}
+
+func AppendConfigClientCertWithoutKey() types.Test {
+	name := "Append config with client cert but no client key"
+	in := types.GetBaseDisk()
+	out := types.GetBaseDisk()
+	config := clientCertConfig(fmt.Sprintf(`,
+					"clientCertificate": {
+						"source": %q
+					}`, dataurl.EncodeBytes(publicKey)))
+	configMinVersion := "2.4.0-experimental"
+
+	return types.Test{
+		Name:             name,
+		In:               in,
+		Out:              out,
+		Config:           config,
+		ConfigMinVersion: configMinVersion,
+	}
+}
+
+func AppendConfigInvalidClientCert() types.Test {
+	name := "Append config with invalid client cert"
+	in := types.GetBaseDisk()
+	out := types.GetBaseDisk()
+	config := clientCertConfig(fmt.Sprintf(`,
+					"clientCertificate": {
+						"source": "data:,not%%20a%%20certificate"
+					},
+					"clientKey": {
+						"source": %q
+					}`, dataurl.EncodeBytes(privateKey)))
+	configMinVersion := "2.4.0-experimental"
+
+	return types.Test{
+		Name:             name,
+		In:               in,
+		Out:              out,
+		Config:           config,
+		ConfigMinVersion: configMinVersion,
+	}
+}
+
+func AppendConfigMissingClientCert() types.Test {
+	name := "Append config with missing client cert"
+	in := types.GetBaseDisk()
+	out := types.GetBaseDisk()
+	config := clientCertConfig(fmt.Sprintf(`,
+					"clientCertificate": {
+						"source": "http://127.0.0.1:8080/missing"
+					},
+					"clientKey": {
+						"source": %q
+					}`, dataurl.EncodeBytes(privateKey)))
+	configMinVersion := "2.4.0-experimental"
+
+	return types.Test{
+		Name:             name,
+		In:               in,
+		Out:              out,
+		Config:           config,
+		ConfigMinVersion: configMinVersion,
+	}
+}
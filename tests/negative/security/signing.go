@@ -0,0 +1,207 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/flatcar-linux/ignition/tests/register"
+	"github.com/flatcar-linux/ignition/tests/types"
+
+	"github.com/vincent-petithory/dataurl"
+)
+
+func init() {
+	register.Register(register.NegativeTest, UnsignedConfig())
+	register.Register(register.NegativeTest, ConfigSignedWithUntrustedKey())
+	register.Register(register.NegativeTest, AppendUnsignedConfig())
+	register.Register(register.NegativeTest, AppendConfigWithBadSignature())
+	register.Register(register.NegativeTest, AppendConfigTrustingItself())
+}
+
+var (
+	signingKey   = ed25519.NewKeyFromSeed([]byte("ignition blackbox signing key 01"))
+	untrustedKey = ed25519.NewKeyFromSeed([]byte("ignition blackbox signing key 02"))
+
+	appendedConfig = []byte(`{
+		"ignition": { "version": "2.4.0-experimental" },
+		"storage": {
+			"files": [{
+				"filesystem": "root",
+				"path": "/foo/bar",
+				"contents": { "source": "data:,unsigned%20config" }
+			}]
+		}
+	}`)
+
+	// serves configs without signatures next to them
+	unsignedConfigServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/config.ign" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(appendedConfig)
+	}))
+)
+
+// trustingBaseConfig returns a system base config trusting signingKey.
+func trustingBaseConfig() []types.File {
+	return []types.File{
+		{
+			Node: types.Node{
+				Name: "base.ign",
+			},
+			Contents: fmt.Sprintf(`{
+				"ignition": {
+					"version": "2.4.0-experimental",
+					"security": {
+						"signing": {
+							"trustedKeys": [%q]
+						}
+					}
+				}
+			}`, base64.StdEncoding.EncodeToString(signingKey.Public().(ed25519.PublicKey))),
+		},
+	}
+}
+
+// appendConfig returns a config appending the unsigned config with the given
+// verification section.
+func appendConfig(verification string) string {
+	return fmt.Sprintf(`{
+		"ignition": {
+			"version": "$version",
+			"config": {
+				"append": [{
+					"source": %q,
+					"verification": %s
+				}]
+			}
+		}
+	}`, unsignedConfigServer.URL+"/config.ign", verification)
+}
+
+func UnsignedConfig() types.Test {
+	name := "Unsigned config with trusted signing keys"
+	in := types.GetBaseDisk()
+	out := in
+	config := `{
+		"ignition": { "version": "$version" }
+	}`
+	configMinVersion := "2.4.0-experimental"
+
+	return types.Test{
+		Name:             name,
+		In:               in,
+		Out:              out,
+		SystemDirFiles:   trustingBaseConfig(),
+		Config:           config,
+		ConfigMinVersion: configMinVersion,
+	}
+}
+
+func ConfigSignedWithUntrustedKey() types.Test {
+	name := "Config signed with an untrusted key"
+	in := types.GetBaseDisk()
+	out := in
+	config := `{
+		"ignition": { "version": "$version" }
+	}`
+	configMinVersion := "2.4.0-experimental"
+
+	return types.Test{
+		Name:             name,
+		In:               in,
+		Out:              out,
+		SystemDirFiles:   trustingBaseConfig(),
+		Config:           config,
+		ConfigMinVersion: configMinVersion,
+		ConfigSigningKey: untrustedKey,
+	}
+}
+
+func AppendUnsignedConfig() types.Test {
+	name := "Append config without detached signature"
+	in := types.GetBaseDisk()
+	out := in
+	config := appendConfig("{}")
+	configMinVersion := "2.4.0-experimental"
+
+	return types.Test{
+		Name:             name,
+		In:               in,
+		Out:              out,
+		SystemDirFiles:   trustingBaseConfig(),
+		Config:           config,
+		ConfigMinVersion: configMinVersion,
+		ConfigSigningKey: signingKey,
+	}
+}
+
+func AppendConfigWithBadSignature() types.Test {
+	name := "Append config with signature by an untrusted key"
+	in := types.GetBaseDisk()
+	out := in
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(untrustedKey, appendedConfig))
+	config := appendConfig(fmt.Sprintf(`{"signature": %q}`, dataurl.EncodeBytes([]byte(sig))))
+	configMinVersion := "2.4.0-experimental"
+
+	return types.Test{
+		Name:             name,
+		In:               in,
+		Out:              out,
+		SystemDirFiles:   trustingBaseConfig(),
+		Config:           config,
+		ConfigMinVersion: configMinVersion,
+		ConfigSigningKey: signingKey,
+	}
+}
+
+func AppendConfigTrustingItself() types.Test {
+	name := "Append config with signature by a key trusted by the config"
+	in := types.GetBaseDisk()
+	out := in
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(untrustedKey, appendedConfig))
+	config := fmt.Sprintf(`{
+		"ignition": {
+			"version": "$version",
+			"config": {
+				"append": [{
+					"source": %q,
+					"verification": {"signature": %q}
+				}]
+			},
+			"security": {
+				"signing": {
+					"trustedKeys": [%q]
+				}
+			}
+		}
+	}`, unsignedConfigServer.URL+"/config.ign", dataurl.EncodeBytes([]byte(sig)),
+		base64.StdEncoding.EncodeToString(untrustedKey.Public().(ed25519.PublicKey)))
+	configMinVersion := "2.4.0-experimental"
+
+	return types.Test{
+		Name:             name,
+		In:               in,
+		Out:              out,
+		Config:           config,
+		ConfigMinVersion: configMinVersion,
+	}
+}
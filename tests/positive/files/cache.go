@@ -0,0 +1,127 @@
+// Copyright 2017 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package files
+
+import (
+	"github.com/flatcar-linux/ignition/tests/register"
+	"github.com/flatcar-linux/ignition/tests/types"
+)
+
+func init() {
+	register.Register(register.PositiveTest, ReuseCachedFileContents())
+	register.Register(register.PositiveTest, UsePreseededFileContents())
+}
+
+func ReuseCachedFileContents() types.Test {
+	name := "Reuse Cached File Contents"
+	in := types.GetBaseDisk()
+	out := types.GetBaseDisk()
+	// The second file doesn't exist on the server, so it can only be written
+	// from the contents cached when fetching the first.
+	config := `{
+	  "ignition": { "version": "$version" },
+	  "storage": {
+	    "files": [{
+	      "filesystem": "root",
+	      "path": "/foo/bar",
+	      "contents": {
+	        "source": "http://127.0.0.1:8080/contents",
+	        "verification": {"hash": "sha512-1a04c76c17079cd99e688ba4f1ba095b927d3fecf2b1e027af361dfeafb548f7f5f6fdd675aaa2563950db441d893ca77b0c3e965cdcb891784af96e330267d7"}
+	      }
+	    },{
+	      "filesystem": "root",
+	      "path": "/foo/baz",
+	      "contents": {
+	        "source": "http://127.0.0.1:8080/missing",
+	        "verification": {"hash": "sha512-1a04c76c17079cd99e688ba4f1ba095b927d3fecf2b1e027af361dfeafb548f7f5f6fdd675aaa2563950db441d893ca77b0c3e965cdcb891784af96e330267d7"}
+	      }
+	    }]
+	  }
+	}`
+	out[0].Partitions.AddFiles("ROOT", []types.File{
+		{
+			Node: types.Node{
+				Name:      "bar",
+				Directory: "foo",
+			},
+			Contents: "asdf\nfdsa",
+		},
+		{
+			Node: types.Node{
+				Name:      "baz",
+				Directory: "foo",
+			},
+			Contents: "asdf\nfdsa",
+		},
+	})
+	configMinVersion := "2.0.0"
+
+	return types.Test{
+		Name:             name,
+		In:               in,
+		Out:              out,
+		Config:           config,
+		ConfigMinVersion: configMinVersion,
+		Flags:            []string{"--fetch-cache-dir=cache"},
+	}
+}
+
+func UsePreseededFileContents() types.Test {
+	name := "Use Preseeded File Contents"
+	in := types.GetBaseDisk()
+	out := types.GetBaseDisk()
+	config := `{
+	  "ignition": { "version": "$version" },
+	  "storage": {
+	    "files": [{
+	      "filesystem": "root",
+	      "path": "/foo/bar",
+	      "contents": {
+	        "source": "http://127.0.0.1:8080/missing",
+	        "verification": {"hash": "sha512-1a04c76c17079cd99e688ba4f1ba095b927d3fecf2b1e027af361dfeafb548f7f5f6fdd675aaa2563950db441d893ca77b0c3e965cdcb891784af96e330267d7"}
+	      }
+	    }]
+	  }
+	}`
+	out[0].Partitions.AddFiles("ROOT", []types.File{
+		{
+			Node: types.Node{
+				Name:      "bar",
+				Directory: "foo",
+			},
+			Contents: "asdf\nfdsa",
+		},
+	})
+	configMinVersion := "2.0.0"
+
+	return types.Test{
+		Name: name,
+		In:   in,
+		Out:  out,
+		// The OEM lookaside directory is relative to Ignition's working
+		// directory, so it doubles as a pre-seeded cache directory.
+		OEMLookasideFiles: []types.File{
+			{
+				Node: types.Node{
+					Name: "1a04c76c17079cd99e688ba4f1ba095b927d3fecf2b1e027af361dfeafb548f7f5f6fdd675aaa2563950db441d893ca77b0c3e965cdcb891784af96e330267d7",
+				},
+				Contents: "asdf\nfdsa",
+			},
+		},
+		Config:           config,
+		ConfigMinVersion: configMinVersion,
+		Flags:            []string{"--fetch-cache-dir=oem-lookaside"},
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package files
+
+import (
+	"github.com/flatcar-linux/ignition/tests/register"
+	"github.com/flatcar-linux/ignition/tests/types"
+)
+
+func init() {
+	register.Register(register.PositiveTest, CreateFilesFromCompressedDataURLs())
+}
+
+func CreateFilesFromCompressedDataURLs() types.Test {
+	name := "Create Files from Compressed Data URLs"
+	in := types.GetBaseDisk()
+	out := types.GetBaseDisk()
+	config := `{
+	  "ignition": { "version": "$version" },
+	  "storage": {
+	    "files": [{
+	      "filesystem": "root",
+	      "path": "/foo/gzip",
+	      "contents": {
+	        "compression": "gzip",
+	        "source": "data:;base64,H4sIAAAAAAACA0ssTknjSkspTgQA8jseCwkAAAA="
+	      }
+	    },
+	    {
+	      "filesystem": "root",
+	      "path": "/foo/bzip2",
+	      "contents": {
+	        "compression": "bzip2",
+	        "source": "data:;base64,QlpoOTFBWSZTWTiaaaUAAAFBgAAQJQAIACAAIYNBmgxpQhxdyRThQkDiaaaU"
+	      }
+	    }]
+	  }
+	}`
+	out[0].Partitions.AddFiles("ROOT", []types.File{
+		{
+			Node: types.Node{
+				Name:      "gzip",
+				Directory: "foo",
+			},
+			Contents: "asdf\nfdsa",
+		},
+		{
+			Node: types.Node{
+				Name:      "bzip2",
+				Directory: "foo",
+			},
+			Contents: "asdf\nfdsa",
+		},
+	})
+	configMinVersion := "2.4.0-experimental"
+
+	return types.Test{
+		Name:             name,
+		In:               in,
+		Out:              out,
+		Config:           config,
+		ConfigMinVersion: configMinVersion,
+	}
+}
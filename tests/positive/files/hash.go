@@ -22,6 +22,8 @@ import (
 func init() {
 	register.Register(register.PositiveTest, ValidateFileHashFromDataURL())
 	register.Register(register.PositiveTest, ValidateFileHashFromHTTPURL())
+	register.Register(register.PositiveTest, ValidateFileSHA256HashFromDataURL())
+	register.Register(register.PositiveTest, ValidateFileSHA384HashFromHTTPURL())
 }
 
 func ValidateFileHashFromDataURL() types.Test {
@@ -97,3 +99,77 @@ func ValidateFileHashFromHTTPURL() types.Test {
 		ConfigMinVersion: configMinVersion,
 	}
 }
+
+func ValidateFileSHA256HashFromDataURL() types.Test {
+	name := "Validate File SHA256 Hash from Data URL"
+	in := types.GetBaseDisk()
+	out := types.GetBaseDisk()
+	config := `{
+	  "ignition": { "version": "$version" },
+	  "storage": {
+	    "files": [{
+	      "filesystem": "root",
+	      "path": "/foo/bar",
+	      "contents": {
+			"source": "data:,example%20file%0A",
+			"verification": {"hash": "sha256-352cb4e231c03f9941d54aeee7da755504a7f2096338c609ba5d1b82143419c6"}
+		  }
+	    }]
+	  }
+	}`
+	out[0].Partitions.AddFiles("ROOT", []types.File{
+		{
+			Node: types.Node{
+				Name:      "bar",
+				Directory: "foo",
+			},
+			Contents: "example file\n",
+		},
+	})
+	configMinVersion := "2.4.0-experimental"
+
+	return types.Test{
+		Name:             name,
+		In:               in,
+		Out:              out,
+		Config:           config,
+		ConfigMinVersion: configMinVersion,
+	}
+}
+
+func ValidateFileSHA384HashFromHTTPURL() types.Test {
+	name := "Validate File SHA384 Hash from HTTP URL"
+	in := types.GetBaseDisk()
+	out := types.GetBaseDisk()
+	config := `{
+	  "ignition": { "version": "$version" },
+	  "storage": {
+	    "files": [{
+	      "filesystem": "root",
+	      "path": "/foo/bar",
+	      "contents": {
+	        "source": "http://127.0.0.1:8080/contents",
+			"verification": {"hash": "sha384-dc3ebf25b11e54006ee662b78d53643c1c111f5f313043c171608831e1a277044841c6db6437a7dbb5bfc84588d02b78"}
+	      }
+	    }]
+	  }
+	}`
+	out[0].Partitions.AddFiles("ROOT", []types.File{
+		{
+			Node: types.Node{
+				Name:      "bar",
+				Directory: "foo",
+			},
+			Contents: "asdf\nfdsa",
+		},
+	})
+	configMinVersion := "2.4.0-experimental"
+
+	return types.Test{
+		Name:             name,
+		In:               in,
+		Out:              out,
+		Config:           config,
+		ConfigMinVersion: configMinVersion,
+	}
+}
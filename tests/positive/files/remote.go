@@ -23,6 +23,10 @@ func init() {
 	register.Register(register.PositiveTest, CreateFileFromRemoteContentsHTTP())
 	register.Register(register.PositiveTest, CreateFileFromRemoteContentsTFTP())
 	register.Register(register.PositiveTest, CreateFileFromRemoteContentsOEM())
+	register.Register(register.PositiveTest, CreateFileFromRemoteContentsS3Endpoint())
+	register.Register(register.PositiveTest, CreateFileFromRemoteContentsWithHTTPHeaders())
+	register.Register(register.PositiveTest, CreateFilesFromRemoteContentsInParallel())
+	register.Register(register.PositiveTest, CreateFilesFromRemoteContentsSequentially())
 }
 
 func CreateFileFromRemoteContentsHTTP() types.Test {
@@ -140,3 +144,227 @@ func CreateFileFromRemoteContentsOEM() types.Test {
 		ConfigMinVersion: configMinVersion,
 	}
 }
+
+func CreateFileFromRemoteContentsS3Endpoint() types.Test {
+	name := "Create Files from Remote Contents - S3 Endpoint"
+	in := types.GetBaseDisk()
+	out := types.GetBaseDisk()
+	config := `{
+	  "ignition": { "version": "$version" },
+	  "storage": {
+	    "files": [{
+	      "filesystem": "root",
+	      "path": "/foo/bar",
+	      "contents": {
+	        "source": "s3://bucket/contents"
+	      }
+	    }]
+	  }
+	}`
+	out[0].Partitions.AddFiles("ROOT", []types.File{
+		{
+			Node: types.Node{
+				Name:      "bar",
+				Directory: "foo",
+			},
+			Contents: "asdf\nfdsa",
+		},
+	})
+	configMinVersion := "2.1.0"
+
+	return types.Test{
+		Name: name,
+		In:   in,
+		Out:  out,
+		OEMLookasideFiles: []types.File{
+			{
+				Node: types.Node{
+					Name: "ignition-s3.conf",
+				},
+				Contents: "ignition.s3.endpoint=http://127.0.0.1:8080\nignition.s3.path_style=true\n",
+			},
+		},
+		Config:           config,
+		ConfigMinVersion: configMinVersion,
+	}
+}
+
+func CreateFileFromRemoteContentsWithHTTPHeaders() types.Test {
+	name := "Create Files from Remote Contents - HTTP Headers"
+	in := types.GetBaseDisk()
+	out := types.GetBaseDisk()
+	config := `{
+	  "ignition": { "version": "$version" },
+	  "storage": {
+	    "files": [{
+	      "filesystem": "root",
+	      "path": "/foo/bar",
+	      "contents": {
+	        "source": "http://127.0.0.1:8080/private",
+	        "httpHeaders": [{
+	          "name": "X-Auth",
+	          "value": "r8ewap98gfh4d8"
+	        }]
+	      }
+	    }]
+	  }
+	}`
+	out[0].Partitions.AddFiles("ROOT", []types.File{
+		{
+			Node: types.Node{
+				Name:      "bar",
+				Directory: "foo",
+			},
+			Contents: "asdf\nfdsa",
+		},
+	})
+	configMinVersion := "2.4.0-experimental"
+
+	return types.Test{
+		Name:             name,
+		In:               in,
+		Out:              out,
+		Config:           config,
+		ConfigMinVersion: configMinVersion,
+	}
+}
+
+func CreateFilesFromRemoteContentsInParallel() types.Test {
+	return createManyFilesFromRemoteContents("Create Files from Remote Contents - Parallel", "--fetch-concurrency=8")
+}
+
+func CreateFilesFromRemoteContentsSequentially() types.Test {
+	return createManyFilesFromRemoteContents("Create Files from Remote Contents - Sequential", "--fetch-concurrency=1")
+}
+
+// createManyFilesFromRemoteContents returns a test which mixes remote files
+// with directories, links, appends and overwrites, so that running it with
+// different fetch concurrencies checks that they all produce the same result.
+func createManyFilesFromRemoteContents(name string, flags ...string) types.Test {
+	in := types.GetBaseDisk()
+	out := types.GetBaseDisk()
+	config := `{
+	  "ignition": { "version": "$version" },
+	  "storage": {
+	    "directories": [{
+	      "filesystem": "root",
+	      "path": "/foo/dir"
+	    }],
+	    "files": [{
+	      "filesystem": "root",
+	      "path": "/foo/dir/1",
+	      "contents": { "source": "http://127.0.0.1:8080/contents" }
+	    },{
+	      "filesystem": "root",
+	      "path": "/foo/dir/2",
+	      "contents": { "source": "tftp://127.0.0.1:69/contents" }
+	    },{
+	      "filesystem": "root",
+	      "path": "/foo/dir/3",
+	      "contents": { "source": "http://127.0.0.1:8080/contents" }
+	    },{
+	      "filesystem": "root",
+	      "path": "/foo/dir/4",
+	      "contents": { "source": "tftp://127.0.0.1:69/contents" }
+	    },{
+	      "filesystem": "root",
+	      "path": "/foo/appended",
+	      "contents": { "source": "data:,hello%0A" }
+	    },{
+	      "filesystem": "root",
+	      "path": "/foo/appended",
+	      "contents": { "source": "http://127.0.0.1:8080/contents" },
+	      "append": true
+	    },{
+	      "filesystem": "root",
+	      "path": "/foo/appended",
+	      "contents": { "source": "tftp://127.0.0.1:69/contents" },
+	      "append": true
+	    },{
+	      "filesystem": "root",
+	      "path": "/foo/replaced",
+	      "contents": { "source": "http://127.0.0.1:8080/contents" }
+	    },{
+	      "filesystem": "root",
+	      "path": "/foo/replaced",
+	      "contents": { "source": "data:,replaced" }
+	    }],
+	    "links": [{
+	      "filesystem": "root",
+	      "path": "/foo/link",
+	      "target": "/foo/dir/1"
+	    }]
+	  }
+	}`
+	out[0].Partitions.AddDirectories("ROOT", []types.Directory{
+		{
+			Node: types.Node{
+				Name:      "dir",
+				Directory: "foo",
+			},
+		},
+	})
+	out[0].Partitions.AddFiles("ROOT", []types.File{
+		{
+			Node: types.Node{
+				Name:      "1",
+				Directory: "foo/dir",
+			},
+			Contents: "asdf\nfdsa",
+		},
+		{
+			Node: types.Node{
+				Name:      "2",
+				Directory: "foo/dir",
+			},
+			Contents: "asdf\nfdsa",
+		},
+		{
+			Node: types.Node{
+				Name:      "3",
+				Directory: "foo/dir",
+			},
+			Contents: "asdf\nfdsa",
+		},
+		{
+			Node: types.Node{
+				Name:      "4",
+				Directory: "foo/dir",
+			},
+			Contents: "asdf\nfdsa",
+		},
+		{
+			Node: types.Node{
+				Name:      "appended",
+				Directory: "foo",
+			},
+			Contents: "hello\nasdf\nfdsaasdf\nfdsa",
+		},
+		{
+			Node: types.Node{
+				Name:      "replaced",
+				Directory: "foo",
+			},
+			Contents: "replaced",
+		},
+	})
+	out[0].Partitions.AddLinks("ROOT", []types.Link{
+		{
+			Node: types.Node{
+				Name:      "link",
+				Directory: "foo",
+			},
+			Target: "/foo/dir/1",
+		},
+	})
+	configMinVersion := "2.1.0"
+
+	return types.Test{
+		Name:             name,
+		In:               in,
+		Out:              out,
+		Config:           config,
+		ConfigMinVersion: configMinVersion,
+		Flags:            flags,
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package partitions
+
+import (
+	"github.com/flatcar-linux/ignition/tests/register"
+	"github.com/flatcar-linux/ignition/tests/types"
+)
+
+func init() {
+	register.Register(register.PositiveTest, CreateDosPartitions())
+	register.Register(register.PositiveTest, AppendDosPartition())
+}
+
+func CreateDosPartitions() types.Test {
+	name := "Create dos partitions on a blank disk"
+	in := append(types.GetBaseDisk(), types.Disk{Alignment: types.IgnitionAlignment})
+	out := append(types.GetBaseDisk(), types.Disk{
+		Alignment:      types.IgnitionAlignment,
+		PartitionTable: "dos",
+		Partitions: types.Partitions{
+			{
+				Number:  1,
+				Length:  65536,
+				DosType: "83",
+			},
+			{
+				Number:  2,
+				Length:  65536,
+				DosType: "82",
+			},
+		},
+	})
+	config := `{
+		"ignition": {
+			"version": "$version"
+		},
+		"storage": {
+			"disks": [
+			{
+				"device": "$disk1",
+				"wipeTable": true,
+				"partitionTable": "dos",
+				"partitions": [
+				{
+					"number": 1,
+					"sizeMiB": 32,
+					"dosType": "83"
+				},
+				{
+					"number": 2,
+					"sizeMiB": 32,
+					"dosType": "82"
+				}
+				]
+			}
+			]
+		}
+	}`
+	configMinVersion := "2.4.0-experimental"
+
+	return types.Test{
+		Name:             name,
+		In:               in,
+		Out:              out,
+		Config:           config,
+		ConfigMinVersion: configMinVersion,
+	}
+}
+
+func AppendDosPartition() types.Test {
+	name := "Append a partition to an existing dos partition table"
+	in := append(types.GetBaseDisk(), types.Disk{
+		Alignment:      types.IgnitionAlignment,
+		PartitionTable: "dos",
+		Partitions: types.Partitions{
+			{
+				Number:  1,
+				Length:  65536,
+				DosType: "83",
+			},
+		},
+	})
+	out := append(types.GetBaseDisk(), types.Disk{
+		Alignment:      types.IgnitionAlignment,
+		PartitionTable: "dos",
+		Partitions: types.Partitions{
+			{
+				Number:  1,
+				Length:  65536,
+				DosType: "83",
+			},
+			{
+				Number:  2,
+				Length:  65536,
+				DosType: "83",
+			},
+		},
+	})
+	config := `{
+		"ignition": {
+			"version": "$version"
+		},
+		"storage": {
+			"disks": [
+			{
+				"device": "$disk1",
+				"partitionTable": "dos",
+				"partitions": [
+				{
+					"number": 1,
+					"dosType": "83"
+				},
+				{
+					"number": 2,
+					"sizeMiB": 32,
+					"dosType": "83"
+				}
+				]
+			}
+			]
+		}
+	}`
+	configMinVersion := "2.4.0-experimental"
+
+	return types.Test{
+		Name:             name,
+		In:               in,
+		Out:              out,
+		Config:           config,
+		ConfigMinVersion: configMinVersion,
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package partitions
+
+import (
+	"github.com/flatcar-linux/ignition/tests/register"
+	"github.com/flatcar-linux/ignition/tests/types"
+)
+
+func init() {
+	register.Register(register.PositiveTest, GrowRootToFill())
+	register.Register(register.PositiveTest, GrowRootMiB())
+}
+
+func GrowRootToFill() types.Test {
+	name := "Grow the ROOT partition in place to fill the disk"
+	in := types.GetBaseDisk()
+	out := types.GetBaseDisk()
+	out[0].Partitions[9-2-1].Length = 12943360 + 65536
+	config := `{
+		"ignition": {
+			"version": "$version"
+		},
+		"storage": {
+			"disks": [{
+				"device": "$disk0",
+				"partitions": [{
+					"number": 9,
+					"resize": true
+				}
+				]
+			}]
+		}
+	}`
+
+	return types.Test{
+		Name:             name,
+		In:               in,
+		Out:              out,
+		Config:           config,
+		ConfigMinVersion: "2.4.0-experimental",
+	}
+}
+
+func GrowRootMiB() types.Test {
+	name := "Grow the ROOT partition in place using MiBs"
+	in := types.GetBaseDisk()
+	out := types.GetBaseDisk()
+	out[0].Partitions[9-2-1].Length = 12943360 + 65536
+	config := `{
+		"ignition": {
+			"version": "$version"
+		},
+		"storage": {
+			"disks": [{
+				"device": "$disk0",
+				"partitions": [{
+					"label": "ROOT",
+					"number": 9,
+					"sizeMiB": 6352,
+					"resize": true
+				}
+				]
+			}]
+		}
+	}`
+
+	return types.Test{
+		Name:             name,
+		In:               in,
+		Out:              out,
+		Config:           config,
+		ConfigMinVersion: "2.4.0-experimental",
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/flatcar-linux/ignition/tests/register"
+	"github.com/flatcar-linux/ignition/tests/types"
+
+	"github.com/vincent-petithory/dataurl"
+)
+
+func init() {
+	cer, err := tls.X509KeyPair(publicKey, privateKey)
+	if err != nil {
+		panic(fmt.Sprintf("error loading x509 keypair: %v", err))
+	}
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(publicKey)
+	clientCertServer.TLS = &tls.Config{
+		Certificates: []tls.Certificate{cer},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+	}
+	clientCertServer.StartTLS()
+
+	register.Register(register.PositiveTest, AppendConfigClientCert())
+	register.Register(register.PositiveTest, FetchFileClientCert())
+}
+
+var clientCertServer = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.Write(customCAServerFile)
+}))
+
+func AppendConfigClientCert() types.Test {
+	name := "Append config with tls client cert"
+	in := types.GetBaseDisk()
+	out := types.GetBaseDisk()
+	config := fmt.Sprintf(`{
+		"ignition": {
+			"version": "$version",
+			"config": {
+			  "append": [{
+				"source": %q
+			  }]
+			},
+			"security": {
+				"tls": {
+					"certificateAuthorities": [{
+						"source": %q
+					}],
+					"clientCertificate": {
+						"source": %q
+					},
+					"clientKey": {
+						"source": %q
+					}
+				}
+			}
+		}
+	}`, clientCertServer.URL, dataurl.EncodeBytes(publicKey), dataurl.EncodeBytes(publicKey), dataurl.EncodeBytes(privateKey))
+	configMinVersion := "2.4.0-experimental"
+
+	out[0].Partitions.AddFiles("ROOT", []types.File{
+		{
+			Node: types.Node{
+				Name:      "bar",
+				Directory: "foo",
+			},
+			Contents: "example file\n",
+		},
+	})
+
+	return types.Test{
+		Name:             name,
+		In:               in,
+		Out:              out,
+		Config:           config,
+		ConfigMinVersion: configMinVersion,
+	}
+}
+
+func FetchFileClientCert() types.Test {
+	name := "Fetch file with tls client cert"
+	in := types.GetBaseDisk()
+	out := types.GetBaseDisk()
+	config := fmt.Sprintf(`{
+		"ignition": {
+			"version": "$version",
+			"security": {
+				"tls": {
+					"certificateAuthorities": [{
+						"source": %q
+					}],
+					"clientCertificate": {
+						"source": %q
+					},
+					"clientKey": {
+						"source": %q
+					}
+				}
+			}
+		},
+		"storage": {
+			"files": [{
+				"filesystem": "root",
+				"path": "/foo/bar",
+				"contents": {
+					"source": %q
+				}
+			}]
+		}
+	}`, dataurl.EncodeBytes(publicKey), dataurl.EncodeBytes(publicKey), dataurl.EncodeBytes(privateKey), clientCertServer.URL)
+	configMinVersion := "2.4.0-experimental"
+
+	out[0].Partitions.AddFiles("ROOT", []types.File{
+		{
+			Node: types.Node{
+				Directory: "foo",
+				Name:      "bar",
+			},
+			Contents: string(customCAServerFile),
+		},
+	})
+
+	return types.Test{
+		Name:             name,
+		In:               in,
+		Out:              out,
+		Config:           config,
+		ConfigMinVersion: configMinVersion,
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/flatcar-linux/ignition/tests/register"
+	"github.com/flatcar-linux/ignition/tests/types"
+
+	"github.com/vincent-petithory/dataurl"
+)
+
+func init() {
+	register.Register(register.PositiveTest, SignedConfig())
+	register.Register(register.PositiveTest, AppendSignedConfig())
+	register.Register(register.PositiveTest, AppendConfigWithSignature())
+}
+
+var (
+	signingKey = ed25519.NewKeyFromSeed([]byte("ignition blackbox signing key 01"))
+
+	signedConfig = []byte(`{
+		"ignition": { "version": "2.4.0-experimental" },
+		"storage": {
+			"files": [{
+				"filesystem": "root",
+				"path": "/foo/bar",
+				"contents": { "source": "data:,signed%20config" }
+			}]
+		}
+	}`)
+	signedConfigSignature = base64.StdEncoding.EncodeToString(ed25519.Sign(signingKey, signedConfig))
+
+	signedConfigServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/config.ign", "/detached.ign":
+			w.Write(signedConfig)
+		case "/config.ign.sig":
+			w.Write([]byte(signedConfigSignature))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+)
+
+// trustingBaseConfig returns a system base config trusting signingKey.
+func trustingBaseConfig() []types.File {
+	return []types.File{
+		{
+			Node: types.Node{
+				Name: "base.ign",
+			},
+			Contents: fmt.Sprintf(`{
+				"ignition": {
+					"version": "2.4.0-experimental",
+					"security": {
+						"signing": {
+							"trustedKeys": [%q]
+						}
+					}
+				}
+			}`, base64.StdEncoding.EncodeToString(signingKey.Public().(ed25519.PublicKey))),
+		},
+	}
+}
+
+func signedConfigOutput() []types.Disk {
+	out := types.GetBaseDisk()
+	out[0].Partitions.AddFiles("ROOT", []types.File{
+		{
+			Node: types.Node{
+				Name:      "bar",
+				Directory: "foo",
+			},
+			Contents: "signed config",
+		},
+	})
+	return out
+}
+
+func SignedConfig() types.Test {
+	name := "Verify signed config"
+	in := types.GetBaseDisk()
+	config := `{
+		"ignition": { "version": "$version" },
+		"storage": {
+			"files": [{
+				"filesystem": "root",
+				"path": "/foo/bar",
+				"contents": { "source": "data:,signed%20config" }
+			}]
+		}
+	}`
+	configMinVersion := "2.4.0-experimental"
+
+	return types.Test{
+		Name:             name,
+		In:               in,
+		Out:              signedConfigOutput(),
+		SystemDirFiles:   trustingBaseConfig(),
+		Config:           config,
+		ConfigMinVersion: configMinVersion,
+		ConfigSigningKey: signingKey,
+	}
+}
+
+func AppendSignedConfig() types.Test {
+	name := "Append config with detached signature"
+	in := types.GetBaseDisk()
+	config := fmt.Sprintf(`{
+		"ignition": {
+			"version": "$version",
+			"config": {
+				"append": [{
+					"source": %q
+				}]
+			}
+		}
+	}`, signedConfigServer.URL+"/config.ign")
+	configMinVersion := "2.4.0-experimental"
+
+	return types.Test{
+		Name:             name,
+		In:               in,
+		Out:              signedConfigOutput(),
+		SystemDirFiles:   trustingBaseConfig(),
+		Config:           config,
+		ConfigMinVersion: configMinVersion,
+		ConfigSigningKey: signingKey,
+	}
+}
+
+func AppendConfigWithSignature() types.Test {
+	name := "Append config with signature source"
+	in := types.GetBaseDisk()
+	config := fmt.Sprintf(`{
+		"ignition": {
+			"version": "$version",
+			"config": {
+				"append": [{
+					"source": %q,
+					"verification": {
+						"signature": %q
+					}
+				}]
+			}
+		}
+	}`, signedConfigServer.URL+"/detached.ign", dataurl.EncodeBytes([]byte(signedConfigSignature)))
+	configMinVersion := "2.4.0-experimental"
+
+	return types.Test{
+		Name:             name,
+		In:               in,
+		Out:              signedConfigOutput(),
+		SystemDirFiles:   trustingBaseConfig(),
+		Config:           config,
+		ConfigMinVersion: configMinVersion,
+		ConfigSigningKey: signingKey,
+	}
+}
@@ -28,6 +28,7 @@ import (
 func init() {
 	register.Register(register.PositiveTest, IncreaseHTTPResponseHeadersTimeout())
 	register.Register(register.PositiveTest, ConfirmHTTPBackoffWorks())
+	register.Register(register.PositiveTest, RetryFlakyTFTPFetch())
 }
 
 var (
@@ -138,3 +139,48 @@ func ConfirmHTTPBackoffWorks() types.Test {
 		ConfigMinVersion: configMinVersion,
 	}
 }
+
+func RetryFlakyTFTPFetch() types.Test {
+	name := "Retry Flaky TFTP Fetch"
+	in := types.GetBaseDisk()
+	out := types.GetBaseDisk()
+	config := `{
+		"ignition": {
+			"version": "$version",
+			"timeouts": {
+				"retryMaxAttempts": 3,
+				"retryInitialBackoff": 50,
+				"retryJitter": 20
+			}
+		},
+		"storage": {
+		    "files": [
+			    {
+					"filesystem": "root",
+					"path": "/foo/bar",
+					"contents": {
+						"source": "tftp://127.0.0.1:69/flaky-contents-$version"
+					}
+				}
+			]
+		}
+	}`
+	configMinVersion := "2.4.0-experimental"
+	out[0].Partitions.AddFiles("ROOT", []types.File{
+		{
+			Node: types.Node{
+				Name:      "bar",
+				Directory: "foo",
+			},
+			Contents: "asdf\nfdsa",
+		},
+	})
+
+	return types.Test{
+		Name:             name,
+		In:               in,
+		Out:              out,
+		Config:           config,
+		ConfigMinVersion: configMinVersion,
+	}
+}
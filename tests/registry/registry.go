@@ -19,6 +19,8 @@ import (
 	_ "github.com/flatcar-linux/ignition/tests/negative/files"
 	_ "github.com/flatcar-linux/ignition/tests/negative/filesystems"
 	_ "github.com/flatcar-linux/ignition/tests/negative/general"
+	_ "github.com/flatcar-linux/ignition/tests/negative/luks"
+	_ "github.com/flatcar-linux/ignition/tests/negative/lvm"
 	_ "github.com/flatcar-linux/ignition/tests/negative/networkd"
 	_ "github.com/flatcar-linux/ignition/tests/negative/partitions"
 	_ "github.com/flatcar-linux/ignition/tests/negative/proxy"
@@ -21,6 +21,7 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pin/tftp"
@@ -45,11 +46,23 @@ func (server *HTTPServer) Contents(w http.ResponseWriter, r *http.Request) {
 fdsa`))
 }
 
+// Private serves the contents only to requests with the right X-Auth header.
+func (server *HTTPServer) Private(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Auth") != "r8ewap98gfh4d8" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	server.Contents(w, r)
+}
+
 type HTTPServer struct{}
 
 func (server *HTTPServer) Start() {
 	http.HandleFunc("/contents", server.Contents)
 	http.HandleFunc("/config", server.Config)
+	http.HandleFunc("/private", server.Private)
+	// path-style S3 GetObject for the bucket "bucket"
+	http.HandleFunc("/bucket/contents", server.Contents)
 
 	s := &http.Server{Addr: ":8080"}
 	go s.ListenAndServe()
@@ -57,6 +70,17 @@ func (server *HTTPServer) Start() {
 
 // TFTP Server
 func (server *TFTPServer) ReadHandler(filename string, rf io.ReaderFrom) error {
+	if strings.Contains(filename, "flaky") {
+		// Fail the first request for each flaky file to exercise retries
+		server.flakyLock.Lock()
+		seen := server.flakySeen[filename]
+		server.flakySeen[filename] = true
+		server.flakyLock.Unlock()
+		if !seen {
+			return fmt.Errorf("flaky file %q unavailable", filename)
+		}
+	}
+
 	var buf *bytes.Reader
 	if strings.Contains(filename, "contents") {
 		buf = bytes.NewReader([]byte(`asdf
@@ -84,9 +108,13 @@ fdsa`))
 	return nil
 }
 
-type TFTPServer struct{}
+type TFTPServer struct {
+	flakyLock sync.Mutex
+	flakySeen map[string]bool
+}
 
 func (server *TFTPServer) Start() {
+	server.flakySeen = map[string]bool{}
 	s := tftp.NewServer(server.ReadHandler, nil)
 	s.SetTimeout(5 * time.Second)
 	go s.ListenAndServe(":69")
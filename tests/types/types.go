@@ -15,6 +15,7 @@
 package types
 
 import (
+	"crypto/ed25519"
 	"fmt"
 	"regexp"
 	"strings"
@@ -57,10 +58,11 @@ type Node struct {
 }
 
 type Disk struct {
-	ImageFile  string
-	Device     string
-	Alignment  int
-	Partitions Partitions
+	ImageFile      string
+	Device         string
+	Alignment      int
+	PartitionTable string // "dos" for an MBR partition table, GPT otherwise
+	Partitions     Partitions
 }
 
 type Partitions []*Partition
@@ -71,6 +73,7 @@ type Partition struct {
 	TypeCode        string
 	TypeGUID        string
 	GUID            string
+	DosType         string
 	Device          string
 	Offset          int
 	Length          int
@@ -101,6 +104,8 @@ type Test struct {
 	ConfigMinVersion  string
 	ConfigVersion     string
 	ConfigShouldBeBad bool
+	ConfigSigningKey  ed25519.PrivateKey // signs the config into config.ign.sig if set
+	Flags             []string           // extra command line flags for ignition
 }
 
 func (ps Partitions) GetPartition(label string) *Partition {
@@ -343,6 +348,10 @@ func DeepCopy(t Test) Test {
 	copy(SystemDirFiles, t.SystemDirFiles)
 	t.SystemDirFiles = SystemDirFiles
 
+	flags := make([]string, len(t.Flags))
+	copy(flags, t.Flags)
+	t.Flags = flags
+
 	return t
 }
 
@@ -27,7 +27,9 @@ import (
 	"syscall"
 	"testing"
 
+	internalTypes "github.com/flatcar-linux/ignition/internal/config/types"
 	"github.com/flatcar-linux/ignition/internal/exec/util"
+	"github.com/flatcar-linux/ignition/internal/sfdisk"
 	"github.com/flatcar-linux/ignition/tests/types"
 )
 
@@ -65,7 +67,55 @@ func getPartitionSet(device string) (map[int]struct{}, error) {
 	return ret, nil
 }
 
+func validateDosDisk(t *testing.T, d types.Disk) error {
+	table, err := sfdisk.DumpPartitionTable(d.Device)
+	if err != nil {
+		return err
+	}
+	if table.Label != "dos" {
+		t.Errorf("Partition table is %q instead of dos", table.Label)
+		return nil
+	}
+	actual := map[int]internalTypes.Partition{}
+	for _, p := range table.Partitions {
+		actual[p.Number] = p
+	}
+
+	for _, e := range d.Partitions {
+		if e.TypeCode == "blank" {
+			continue
+		}
+
+		p, ok := actual[e.Number]
+		if !ok {
+			t.Errorf("Partition %d is missing", e.Number)
+			continue
+		}
+		delete(actual, e.Number)
+
+		expectedSectors := types.Align(e.Length, d.Alignment)
+		if *p.Size != expectedSectors {
+			t.Error("Sectors does not match!", expectedSectors, *p.Size)
+		}
+		if e.DosType != "" && !strings.EqualFold(strings.TrimLeft(e.DosType, "0"), strings.TrimLeft(p.DosType, "0")) {
+			t.Error("DosType does not match!", e.DosType, p.DosType)
+		}
+	}
+
+	if len(actual) != 0 {
+		t.Error("Disk had extra partitions", actual)
+	}
+
+	if _, err := runWithoutContext("udevadm", "settle"); err != nil {
+		t.Log(err)
+	}
+	return nil
+}
+
 func validateDisk(t *testing.T, d types.Disk) error {
+	if d.PartitionTable == "dos" {
+		return validateDosDisk(t, d)
+	}
 	partitionSet, err := getPartitionSet(d.Device)
 	if err != nil {
 		return err
@@ -22,17 +22,26 @@ import (
 	"strings"
 
 	config "github.com/flatcar-linux/ignition/config/v2_4_experimental"
+	"github.com/flatcar-linux/ignition/config/validate/report"
 	"github.com/flatcar-linux/ignition/internal/version"
 )
 
 var (
-	flagVersion bool
+	flagVersion     bool
+	flagRecursive   bool
+	flagLocalDir    string
+	flagLocalServer string
+	flagFormat      string
 )
 
 func init() {
 	flag.BoolVar(&flagVersion, "version", false, "print the version of ignition-validate")
+	flag.StringVar(&flagFormat, "format", "text", "output format (text, json or sarif)")
+	flag.BoolVar(&flagRecursive, "recursive", false, "also validate the configs referenced by ignition.config.append and ignition.config.replace")
+	flag.StringVar(&flagLocalDir, "local-dir", "", "with --recursive, read http(s) references from this directory, by URL path, instead of fetching them")
+	flag.StringVar(&flagLocalServer, "local-server", "", "with --recursive, fetch http(s) references from this server (e.g. http://localhost:8080), keeping their URL path")
 	flag.Usage = func() {
-		fmt.Fprintf(flag.CommandLine.Output(), "Usage:\n  %s config.ign [flags]\n\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage:\n  %s [flags] config.ign\n\n", os.Args[0])
 		flag.PrintDefaults()
 	}
 }
@@ -40,7 +49,7 @@ func init() {
 func main() {
 	flag.Parse()
 
-	runIgnValidate(os.Args[1:])
+	runIgnValidate(flag.Args())
 }
 
 func stdout(format string, a ...interface{}) {
@@ -66,24 +75,46 @@ func runIgnValidate(args []string) {
 		flag.Usage()
 		os.Exit(1)
 	}
+	if flagLocalDir != "" && flagLocalServer != "" {
+		die("--local-dir and --local-server cannot be used together")
+	}
+	if _, ok := reportWriters[flagFormat]; !ok {
+		die("unknown format %q", flagFormat)
+	}
+
 	var blob []byte
 	var err error
-	if args[0] == "-" {
+	name := args[0]
+	if name == "-" {
 		blob, err = ioutil.ReadAll(os.Stdin)
+		name = stdinName
 	} else {
-		blob, err = ioutil.ReadFile(args[0])
+		blob, err = ioutil.ReadFile(name)
 	}
 	if err != nil {
 		die("couldn't read config: %v", err)
 	}
-	_, rpt, err := config.Parse(blob)
-	if len(rpt.Entries) > 0 {
-		stdout(rpt.String())
+
+	var rpt report.Report
+	if flagRecursive {
+		rpt, err = validateRecursive(name, blob)
+		if err != nil {
+			die("%v", err)
+		}
+	} else {
+		_, rpt, err = config.Parse(blob)
+		if err != nil && !rpt.IsFatal() {
+			rpt.Add(report.Entry{
+				Kind:    report.EntryError,
+				Message: fmt.Sprintf("couldn't parse config: %v", err),
+			})
+		}
+	}
+
+	if err := reportWriters[flagFormat](os.Stdout, name, rpt); err != nil {
+		die("couldn't write report: %v", err)
 	}
 	if rpt.IsFatal() {
 		os.Exit(1)
 	}
-	if err != nil {
-		die("couldn't parse config: %v", err)
-	}
 }
@@ -0,0 +1,236 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/flatcar-linux/ignition/config/validate/report"
+	"github.com/flatcar-linux/ignition/internal/version"
+)
+
+// stdinName is the name of a config read from stdin.
+const stdinName = "stdin"
+
+// reportWriters maps the names of the output formats to functions writing a
+// report, about the config called name, in that format.
+var reportWriters = map[string]func(w io.Writer, name string, r report.Report) error{
+	"text":  writeText,
+	"json":  writeJSON,
+	"sarif": writeSARIF,
+}
+
+func writeText(w io.Writer, name string, r report.Report) error {
+	if len(r.Entries) == 0 {
+		return nil
+	}
+	_, err := fmt.Fprintln(w, r.String())
+	return err
+}
+
+type jsonReport struct {
+	Valid   bool        `json:"valid"`
+	Entries []jsonEntry `json:"entries"`
+}
+
+type jsonEntry struct {
+	Kind      string `json:"kind"`
+	Message   string `json:"message"`
+	Line      int    `json:"line,omitempty"`
+	Column    int    `json:"column,omitempty"`
+	Highlight string `json:"highlight,omitempty"`
+	Path      string `json:"path"`
+	Source    string `json:"source"`
+}
+
+// writeJSON writes r as a JSON object. Unlike report.Entry, entries always
+// include their path and source, and include their highlight.
+func writeJSON(w io.Writer, name string, r report.Report) error {
+	out := jsonReport{
+		Valid:   !r.IsFatal(),
+		Entries: []jsonEntry{},
+	}
+	for _, e := range r.Entries {
+		source := e.Source
+		if source == "" {
+			source = name
+		}
+		out.Entries = append(out.Entries, jsonEntry{
+			Kind:      e.Kind.String(),
+			Message:   e.Message,
+			Line:      e.Line,
+			Column:    e.Column,
+			Highlight: e.Highlight,
+			Path:      e.Path,
+			Source:    source,
+		})
+	}
+	return writeIndented(w, out)
+}
+
+// The subset of SARIF 2.1.0 needed to describe a report.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string `json:"name"`
+	Version        string `json:"version"`
+	InformationURI string `json:"informationUri"`
+}
+
+type sarifResult struct {
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+}
+
+// sarifLevel maps the kinds of report entries to SARIF result levels.
+func sarifLevel(e report.Entry) string {
+	switch e.Kind {
+	case report.EntryError:
+		return "error"
+	case report.EntryWarning, report.EntryDeprecated:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// sarifURI returns the URI of source, the name of a config in the report of
+// the config called name, or false if source isn't a file or a URL, like
+// stdin or a config embedded in another one as a data URL.
+func sarifURI(source, name string) (string, bool) {
+	if source == name {
+		if name == stdinName {
+			return "", false
+		}
+		return (&url.URL{Path: filepath.ToSlash(name)}).String(), true
+	}
+	// the names of embedded configs aren't URLs, but may start like one
+	if strings.Contains(source, " ") {
+		return "", false
+	}
+	u, err := url.Parse(source)
+	if err != nil || u.Scheme == "" || u.Scheme == "data" {
+		return "", false
+	}
+	return source, true
+}
+
+// writeSARIF writes r as a SARIF log, so that code review tools can show the
+// entries next to the lines they are about. The JSON pointer of each entry is
+// given as its logical location. Entries about configs which aren't files or
+// URLs have no physical location, since their lines aren't those of any
+// file, and name the config in their message instead.
+func writeSARIF(w io.Writer, name string, r report.Report) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "ignition-validate",
+			Version:        version.Raw,
+			InformationURI: "https://github.com/flatcar-linux/ignition",
+		}},
+		Results: []sarifResult{},
+	}
+	for _, e := range r.Entries {
+		source := e.Source
+		if source == "" {
+			source = name
+		}
+		message := e.Message
+		var loc sarifLocation
+		if uri, ok := sarifURI(source, name); ok {
+			loc.PhysicalLocation = &sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: uri},
+			}
+			if e.Line > 0 {
+				loc.PhysicalLocation.Region = &sarifRegion{
+					StartLine:   e.Line,
+					StartColumn: e.Column,
+				}
+			}
+		} else {
+			message = fmt.Sprintf("%s: %s", source, message)
+		}
+		if e.Path != "" {
+			loc.LogicalLocations = []sarifLogicalLocation{{FullyQualifiedName: e.Path}}
+		}
+		result := sarifResult{
+			Level:   sarifLevel(e),
+			Message: sarifMessage{Text: message},
+		}
+		if loc.PhysicalLocation != nil || loc.LogicalLocations != nil {
+			result.Locations = []sarifLocation{loc}
+		}
+		run.Results = append(run.Results, result)
+	}
+	return writeIndented(w, sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
+
+func writeIndented(w io.Writer, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", b)
+	return err
+}
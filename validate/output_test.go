@@ -0,0 +1,155 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/flatcar-linux/ignition/config/validate/report"
+)
+
+func TestWriteReport(t *testing.T) {
+	rpt := report.Report{Entries: []report.Entry{
+		{
+			Kind:      report.EntryError,
+			Message:   "path not absolute",
+			Line:      3,
+			Column:    12,
+			Highlight: "highlight",
+			Path:      "/storage/files/0/path",
+		},
+		{
+			Kind:    report.EntryWarning,
+			Message: "unused key foo",
+			Source:  "http://example.com/frag.ign",
+		},
+		{
+			Kind:    report.EntryError,
+			Message: "path not absolute",
+			Line:    1,
+			Column:  40,
+			Path:    "/storage/files/0/path",
+			Source:  "config.ign (append 0, data URL)",
+		},
+	}}
+
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, "config.ign", rpt); err != nil {
+		t.Fatal(err)
+	}
+	var gotJSON jsonReport
+	if err := json.Unmarshal(buf.Bytes(), &gotJSON); err != nil {
+		t.Fatal(err)
+	}
+	expectedJSON := jsonReport{
+		Valid: false,
+		Entries: []jsonEntry{
+			{
+				Kind:      "error",
+				Message:   "path not absolute",
+				Line:      3,
+				Column:    12,
+				Highlight: "highlight",
+				Path:      "/storage/files/0/path",
+				Source:    "config.ign",
+			},
+			{
+				Kind:    "warning",
+				Message: "unused key foo",
+				Source:  "http://example.com/frag.ign",
+			},
+			{
+				Kind:    "error",
+				Message: "path not absolute",
+				Line:    1,
+				Column:  40,
+				Path:    "/storage/files/0/path",
+				Source:  "config.ign (append 0, data URL)",
+			},
+		},
+	}
+	if !reflect.DeepEqual(expectedJSON, gotJSON) {
+		t.Errorf("json: expected %+v, got %+v", expectedJSON, gotJSON)
+	}
+
+	buf.Reset()
+	if err := writeSARIF(&buf, "config.ign", rpt); err != nil {
+		t.Fatal(err)
+	}
+	var gotSARIF sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &gotSARIF); err != nil {
+		t.Fatal(err)
+	}
+	if gotSARIF.Version != "2.1.0" || len(gotSARIF.Runs) != 1 {
+		t.Fatalf("sarif: unexpected log %+v", gotSARIF)
+	}
+	expectedResults := []sarifResult{
+		{
+			Level:   "error",
+			Message: sarifMessage{Text: "path not absolute"},
+			Locations: []sarifLocation{{
+				PhysicalLocation: &sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: "config.ign"},
+					Region:           &sarifRegion{StartLine: 3, StartColumn: 12},
+				},
+				LogicalLocations: []sarifLogicalLocation{{FullyQualifiedName: "/storage/files/0/path"}},
+			}},
+		},
+		{
+			Level:   "warning",
+			Message: sarifMessage{Text: "unused key foo"},
+			Locations: []sarifLocation{{
+				PhysicalLocation: &sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: "http://example.com/frag.ign"},
+				},
+			}},
+		},
+		{
+			// embedded configs have no lines of their own
+			Level:   "error",
+			Message: sarifMessage{Text: "config.ign (append 0, data URL): path not absolute"},
+			Locations: []sarifLocation{{
+				LogicalLocations: []sarifLogicalLocation{{FullyQualifiedName: "/storage/files/0/path"}},
+			}},
+		},
+	}
+	if !reflect.DeepEqual(expectedResults, gotSARIF.Runs[0].Results) {
+		t.Errorf("sarif: expected %+v, got %+v", expectedResults, gotSARIF.Runs[0].Results)
+	}
+}
+
+func TestSARIFStdin(t *testing.T) {
+	rpt := report.Report{Entries: []report.Entry{
+		{Kind: report.EntryError, Message: "invalid", Line: 2, Column: 3},
+	}}
+	var buf bytes.Buffer
+	if err := writeSARIF(&buf, stdinName, rpt); err != nil {
+		t.Fatal(err)
+	}
+	var got sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	expected := []sarifResult{{
+		Level:   "error",
+		Message: sarifMessage{Text: "stdin: invalid"},
+	}}
+	if !reflect.DeepEqual(expected, got.Runs[0].Results) {
+		t.Errorf("expected %+v, got %+v", expected, got.Runs[0].Results)
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"time"
+
+	config "github.com/flatcar-linux/ignition/config/v2_4_experimental"
+	"github.com/flatcar-linux/ignition/config/v2_4_experimental/types"
+	"github.com/flatcar-linux/ignition/config/validate/report"
+	itypes "github.com/flatcar-linux/ignition/internal/config/types"
+	"github.com/flatcar-linux/ignition/internal/util"
+
+	"github.com/vincent-petithory/dataurl"
+)
+
+const httpTimeout = 30 * time.Second
+
+// referenceValidator validates a config and, recursively, the configs it
+// references, collecting the results in a single report.
+type referenceValidator struct {
+	// localDir and localServer redirect http(s) references, see the flags of
+	// the same names.
+	localDir    string
+	localServer *url.URL
+	client      http.Client
+
+	report report.Report
+	// visiting holds the sources of the configs currently being validated,
+	// to detect reference cycles.
+	visiting map[string]bool
+}
+
+// validateRecursive validates the config in blob, named name, and the configs
+// it references.
+func validateRecursive(name string, blob []byte) (report.Report, error) {
+	v := referenceValidator{
+		localDir: flagLocalDir,
+		client:   http.Client{Timeout: httpTimeout},
+		visiting: map[string]bool{},
+	}
+	if flagLocalServer != "" {
+		u, err := url.Parse(flagLocalServer)
+		if err != nil {
+			return report.Report{}, fmt.Errorf("invalid local server: %v", err)
+		}
+		v.localServer = u
+	}
+
+	v.validate(name, blob)
+	return v.report, nil
+}
+
+// validate validates the config in blob, named name, and follows its
+// references.
+func (v *referenceValidator) validate(name string, blob []byte) {
+	cfg, rpt, err := config.Parse(blob)
+	rpt.SetSource(name)
+	v.report.Merge(rpt)
+	if err != nil {
+		if !rpt.IsFatal() {
+			v.errorf(name, "couldn't parse config: %v", err)
+		}
+		return
+	}
+
+	v.visiting[name] = true
+	defer delete(v.visiting, name)
+
+	// Like Ignition, only follow the replacement if there is one.
+	if replace := cfg.Ignition.Config.Replace; replace != nil && replace.Source != "" {
+		if len(cfg.Ignition.Config.Append) > 0 {
+			v.warnf(name, "config is replaced, so its appended configs are ignored")
+		}
+		v.follow(name, "replace", *replace)
+		return
+	}
+	for i, ref := range cfg.Ignition.Config.Append {
+		v.follow(name, fmt.Sprintf("append %d", i), ref)
+	}
+}
+
+// follow fetches, verifies and validates the config referenced by ref, which
+// is the config called field of parent.
+func (v *referenceValidator) follow(parent, field string, ref types.ConfigReference) {
+	name := ref.Source
+	u, err := url.Parse(ref.Source)
+	if err != nil {
+		v.errorf(parent, "invalid %s source: %v", field, err)
+		return
+	}
+	if u.Scheme == "data" {
+		// data URLs make unwieldy names
+		name = fmt.Sprintf("%s (%s, data URL)", parent, field)
+	}
+	if v.visiting[name] {
+		v.errorf(parent, "%s reference to %s forms a cycle", field, name)
+		return
+	}
+
+	switch u.Scheme {
+	case "data", "http", "https":
+	default:
+		v.infof(parent, "not following %s reference to %s: %q URLs are not supported", field, name, u.Scheme)
+		return
+	}
+	if ref.Decryption != nil {
+		v.infof(parent, "not following %s reference to %s: it is encrypted", field, name)
+		return
+	}
+	blob, err := v.fetch(*u, ref.HTTPHeaders)
+	if err != nil {
+		v.errorf(parent, "couldn't fetch %s config %s: %v", field, name, err)
+		return
+	}
+
+	if err := util.AssertValid(itypes.Verification{Hash: ref.Verification.Hash}, blob); err != nil {
+		v.errorf(name, "couldn't verify config: %v", err)
+		return
+	}
+	v.validate(name, blob)
+}
+
+// fetch returns the contents of u, which must be a data or http(s) URL,
+// sending headers with http(s) requests.
+func (v *referenceValidator) fetch(u url.URL, headers types.HTTPHeaders) ([]byte, error) {
+	if u.Scheme == "data" {
+		d, err := dataurl.DecodeString(u.String())
+		if err != nil {
+			return nil, err
+		}
+		return d.Data, nil
+	}
+
+	if v.localDir != "" {
+		return ioutil.ReadFile(filepath.Join(v.localDir, filepath.FromSlash(filepath.Clean("/"+u.Path))))
+	}
+	if v.localServer != nil {
+		u.Scheme = v.localServer.Scheme
+		u.Host = v.localServer.Host
+	}
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range headers {
+		req.Header.Set(h.Name, h.Value)
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s", resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+func (v *referenceValidator) errorf(source, format string, a ...interface{}) {
+	v.add(report.Entry{Kind: report.EntryError, Source: source}, format, a...)
+}
+
+func (v *referenceValidator) warnf(source, format string, a ...interface{}) {
+	v.add(report.Entry{Kind: report.EntryWarning, Source: source}, format, a...)
+}
+
+func (v *referenceValidator) infof(source, format string, a ...interface{}) {
+	v.add(report.Entry{Kind: report.EntryInfo, Source: source}, format, a...)
+}
+
+func (v *referenceValidator) add(e report.Entry, format string, a ...interface{}) {
+	e.Message = fmt.Sprintf(format, a...)
+	v.report.Add(e)
+}
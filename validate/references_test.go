@@ -0,0 +1,120 @@
+// Copyright 2018 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/flatcar-linux/ignition/config/shared/errors"
+	"github.com/flatcar-linux/ignition/config/validate/report"
+
+	"github.com/vincent-petithory/dataurl"
+)
+
+func TestReferenceValidator(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ignition-validate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	badFile := `{"ignition": {"version": "2.4.0-experimental"}, "storage": {"files": [{"filesystem": "root", "path": "relative", "mode": 420}]}}`
+	goodFile := `{"ignition": {"version": "2.2.0"}}`
+	cycle := `{"ignition": {"version": "2.2.0", "config": {"append": [{"source": "http://example.com/cycle.ign"}]}}}`
+	for name, contents := range map[string]string{
+		"good.ign":  goodFile,
+		"cycle.ign": cycle,
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	goodSum := sha512.Sum512([]byte(goodFile))
+	zeroSum := strings.Repeat("0", 2*sha512.Size)
+
+	type in struct {
+		config string
+	}
+	type out struct {
+		entries []report.Entry
+	}
+
+	tests := []struct {
+		in  in
+		out out
+	}{
+		{
+			in: in{config: `{"ignition": {"version": "2.2.0", "config": {"append": [
+				{"source": "http://example.com/good.ign", "verification": {"hash": "sha512-` + hex.EncodeToString(goodSum[:]) + `"}},
+				{"source": "tftp://example.com/good.ign"}
+			]}}}`},
+			out: out{entries: []report.Entry{
+				{Kind: report.EntryInfo, Source: "main", Message: `not following append 1 reference to tftp://example.com/good.ign: "tftp" URLs are not supported`},
+			}},
+		},
+		{
+			in: in{config: `{"ignition": {"version": "2.2.0", "config": {"append": [
+				{"source": "` + dataurl.EncodeBytes([]byte(badFile)) + `"}
+			]}}}`},
+			out: out{entries: []report.Entry{
+				{Kind: report.EntryError, Source: "main (append 0, data URL)", Path: "/storage/files/0/path", Message: errors.ErrPathRelative.Error()},
+			}},
+		},
+		{
+			in: in{config: `{"ignition": {"version": "2.2.0", "config": {"replace":
+				{"source": "http://example.com/good.ign", "verification": {"hash": "sha512-` + zeroSum + `"}}
+			}}}`},
+			out: out{entries: []report.Entry{
+				{Kind: report.EntryError, Source: "http://example.com/good.ign", Message: "couldn't verify config: hash verification failed (calculated " + hex.EncodeToString(goodSum[:]) + " but expected " + zeroSum + ")"},
+			}},
+		},
+		{
+			in: in{config: `{"ignition": {"version": "2.2.0", "config": {"append": [{"source": "http://example.com/cycle.ign"}]}}}`},
+			out: out{entries: []report.Entry{
+				{Kind: report.EntryError, Source: "http://example.com/cycle.ign", Message: "append 0 reference to http://example.com/cycle.ign forms a cycle"},
+			}},
+		},
+		{
+			in: in{config: `{"ignition": {"version": "2.2.0", "config": {"append": [{"source": "http://example.com/missing.ign"}]}}}`},
+			out: out{entries: []report.Entry{
+				{Kind: report.EntryError, Source: "main", Message: "couldn't fetch append 0 config http://example.com/missing.ign: open " + filepath.Join(dir, "missing.ign") + ": no such file or directory"},
+			}},
+		},
+	}
+
+	for i, test := range tests {
+		v := referenceValidator{
+			localDir: dir,
+			visiting: map[string]bool{},
+		}
+		v.validate("main", []byte(test.in.config))
+		for j := range v.report.Entries {
+			// positions and highlights aren't under test
+			v.report.Entries[j].Line = 0
+			v.report.Entries[j].Column = 0
+			v.report.Entries[j].Highlight = ""
+		}
+		if !reflect.DeepEqual(test.out.entries, v.report.Entries) {
+			t.Errorf("#%d: bad report: want %v, got %v", i, test.out.entries, v.report.Entries)
+		}
+	}
+}